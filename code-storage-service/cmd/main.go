@@ -9,11 +9,17 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Meldy183/code-storage-service/api"
 	"github.com/Meldy183/code-storage-service/internal/config"
+	"github.com/Meldy183/code-storage-service/internal/domain"
 	"github.com/Meldy183/code-storage-service/internal/service"
+	"github.com/Meldy183/code-storage-service/internal/storage"
 	"github.com/Meldy183/code-storage-service/internal/storage/postgres"
 	"github.com/Meldy183/code-storage-service/internal/transport"
+	"github.com/Meldy183/shared/pkg/health"
 	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/openapi"
+	"github.com/Meldy183/shared/pkg/tracing"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
@@ -45,8 +51,18 @@ func main() {
 		zap.String("env", env),
 	)
 
+	shutdownTracing, err := tracing.Init(ctx, "code-storage-service", cfg.Tracing)
+	if err != nil {
+		log.Fatal(ctx, "failed to init tracing", zap.Error(err))
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error(ctx, "failed to shutdown tracing", zap.Error(err))
+		}
+	}()
+
 	// Connect to database with retry logic
-	storage, err := connectWithRetry(
+	pgStorage, err := connectWithRetry(
 		ctx,
 		log,
 		cfg.Database.Host,
@@ -60,21 +76,34 @@ func main() {
 		log.Fatal(ctx, "Failed to connect to database after retries", zap.Error(err))
 	}
 	defer func() {
-		if closeErr := storage.Close(ctx); closeErr != nil {
+		if closeErr := pgStorage.Close(ctx); closeErr != nil {
 			log.Error(ctx, "Failed to close storage connection", zap.Error(closeErr))
 		}
 	}()
 	log.Info(ctx, "storage connection established")
 
 	// Initialize service and HTTP handler
-	svc := service.NewService(storage)
+	svc := service.NewService(storage.WithTracing(pgStorage))
 	handler := transport.NewHandler(svc)
 	router := mux.NewRouter()
 	handler.RegisterRoutes(router, log)
+	health.Register(router, health.Database("postgres", pgStorage.DB()))
+
+	// Validate traffic against the embedded OpenAPI spec; responses too outside prod
+	validator, err := openapi.NewValidator(api.Spec, func(code, message string) any {
+		return domain.NewErrorResponse(code, message)
+	}, env == "dev" || env == "development")
+	if err != nil {
+		log.Fatal(ctx, "failed to load OpenAPI spec", zap.Error(err))
+	}
+	if err := validator.CheckRoutes(router); err != nil {
+		log.Fatal(ctx, "registered routes do not match the OpenAPI spec", zap.Error(err))
+	}
+	router.Use(validator.Middleware)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
+		Handler:      tracing.Handler(router, "code-storage-service"),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
@@ -5,6 +5,7 @@ CREATE TABLE IF NOT EXISTS commits (
     root_commit UUID NOT NULL,
     parent_commit_ids TEXT[] NOT NULL DEFAULT '{}',
     code BYTEA NOT NULL,
+    is_tree BOOLEAN NOT NULL DEFAULT FALSE,
     created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
 
     CONSTRAINT fk_root_commit CHECK (
@@ -19,6 +20,21 @@ CREATE INDEX IF NOT EXISTS idx_commits_team_root ON commits(team_id, root_commit
 -- Index for finding children of a commit
 CREATE INDEX IF NOT EXISTS idx_commits_parent_ids ON commits USING GIN(parent_commit_ids);
 
+-- Blobs table - file contents of tree commits, stored once per SHA-256 of the content
+CREATE TABLE IF NOT EXISTS blobs (
+    hash CHAR(64) PRIMARY KEY,
+    content BYTEA NOT NULL
+);
+
+-- Tree entries table - the manifest of a tree commit (is_tree): the blob at each file path
+CREATE TABLE IF NOT EXISTS tree_entries (
+    commit_id UUID NOT NULL REFERENCES commits(id) ON DELETE CASCADE,
+    path TEXT NOT NULL,
+    blob_hash CHAR(64) NOT NULL REFERENCES blobs(hash),
+
+    PRIMARY KEY (commit_id, path)
+);
+
 -- Commit names table - maps human-readable names to commits
 CREATE TABLE IF NOT EXISTS commit_names (
     team_id UUID NOT NULL,
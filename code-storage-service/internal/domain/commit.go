@@ -13,10 +13,28 @@ type Commit struct {
 	RootCommit      uuid.UUID   `json:"root_commit"`
 	ParentCommitIDs []uuid.UUID `json:"parent_commit_ids"`
 	Code            []byte      `json:"-"`
+	Tree            bool        `json:"tree"`
 	CreatedAt       time.Time   `json:"createdAt"`
 	CommitName      *string     `json:"commit_name,omitempty"`
 }
 
+// Tree is the content of a tree commit: file contents by slash-separated relative path
+type Tree map[string][]byte
+
+// Archive formats a tree is uploaded and checked out in
+const (
+	ArchiveTar = "tar"
+	ArchiveZip = "zip"
+)
+
+// Checkout is what a commit checkout serves: a whole tree as an archive, a single file of it,
+// or the code of a single-file commit. Archive is the archive format, or "" for a single file.
+type Checkout struct {
+	Content  []byte
+	Archive  string
+	Filename string
+}
+
 // CommitResponse is the JSON response for commit operations
 type CommitResponse struct {
 	Commit *CommitDTO `json:"commit"`
@@ -28,6 +46,7 @@ type CommitDTO struct {
 	TeamID          uuid.UUID   `json:"team_id"`
 	RootCommit      uuid.UUID   `json:"root_commit"`
 	ParentCommitIDs []uuid.UUID `json:"parent_commit_ids"`
+	Tree            bool        `json:"tree"`
 	CreatedAt       time.Time   `json:"createdAt"`
 	CommitName      *string     `json:"commit_name,omitempty"`
 }
@@ -39,6 +58,7 @@ func (c *Commit) ToDTO() *CommitDTO {
 		TeamID:          c.TeamID,
 		RootCommit:      c.RootCommit,
 		ParentCommitIDs: c.ParentCommitIDs,
+		Tree:            c.Tree,
 		CreatedAt:       c.CreatedAt,
 		CommitName:      c.CommitName,
 	}
@@ -58,6 +78,21 @@ type MergeRequest struct {
 	RootCommit uuid.UUID `json:"root_commit"`
 	CommitID1  uuid.UUID `json:"commit_id1"`
 	CommitID2  uuid.UUID `json:"commit_id2"`
+	// DryRun only checks that the commits merge cleanly, without creating the merge commit
+	DryRun bool `json:"dry_run"`
+}
+
+// ConflictHunk is a region of the merge base that the two merged commits changed differently.
+// BaseStart is the 1-based line of the base where it starts; Base is empty when both sides inserted lines there.
+// In tree merges Path names the file; a file that can't be merged line by line (deleted on one side, or binary)
+// conflicts as a whole, with BaseStart 0, no lines and the Reason.
+type ConflictHunk struct {
+	Path      string   `json:"path,omitempty"`
+	Reason    string   `json:"reason,omitempty"`
+	BaseStart int      `json:"base_start"`
+	Base      []string `json:"base"`
+	Commit1   []string `json:"commit1"`
+	Commit2   []string `json:"commit2"`
 }
 
 // CommitNameResponse is the response for commit name lookup
@@ -70,3 +105,85 @@ type CommitNameResponse struct {
 type CommitIDResponse struct {
 	CommitID uuid.UUID `json:"commit_id"`
 }
+
+// MergeCheckResponse is the response for a dry-run merge
+type MergeCheckResponse struct {
+	CommitID1 uuid.UUID `json:"commit_id1"`
+	CommitID2 uuid.UUID `json:"commit_id2"`
+	MergeBase uuid.UUID `json:"merge_base"`
+}
+
+// MergeBaseResponse is the response for merge base lookup
+type MergeBaseResponse struct {
+	CommitID1 uuid.UUID `json:"commit_id1"`
+	CommitID2 uuid.UUID `json:"commit_id2"`
+	MergeBase uuid.UUID `json:"merge_base"`
+}
+
+// IsAncestorResponse is the response for ancestry check; a commit counts as its own ancestor
+type IsAncestorResponse struct {
+	AncestorID uuid.UUID `json:"ancestor_id"`
+	CommitID   uuid.UUID `json:"commit_id"`
+	IsAncestor bool      `json:"is_ancestor"`
+}
+
+// AheadBehind counts the commits reachable from one commit but not the other:
+// Ahead from CommitID1 only, Behind from CommitID2 only
+type AheadBehind struct {
+	CommitID1 uuid.UUID `json:"commit_id1"`
+	CommitID2 uuid.UUID `json:"commit_id2"`
+	Ahead     int       `json:"ahead"`
+	Behind    int       `json:"behind"`
+}
+
+// Diff line operations
+const (
+	DiffOpContext = "context"
+	DiffOpDelete  = "delete"
+	DiffOpInsert  = "insert"
+)
+
+// DiffLine is a line of a diff hunk; NoNewline marks the last line of a file that doesn't end with a newline
+type DiffLine struct {
+	Op        string `json:"op"`
+	Text      string `json:"text"`
+	NoNewline bool   `json:"no_newline,omitempty"`
+}
+
+// DiffHunk is a run of changes with its context. Starts are 1-based; for an empty range
+// the start is the line before it, as in unified diff headers.
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// File diff statuses
+const (
+	FileAdded    = "added"
+	FileDeleted  = "deleted"
+	FileModified = "modified"
+)
+
+// FileDiff is the diff of one file of a tree; binary files are not diffed line by line
+type FileDiff struct {
+	Path   string     `json:"path"`
+	Status string     `json:"status"`
+	Binary bool       `json:"binary"`
+	Hunks  []DiffHunk `json:"hunks"`
+}
+
+// Diff is the line diff between the code of two commits, as hunks and as unified diff text.
+// Binary code is not diffed line by line: Binary is set and there are no hunks.
+// Tree commits are diffed per file: Files lists the changed files, by path, and Hunks is empty.
+type Diff struct {
+	FromCommitID uuid.UUID  `json:"from_commit_id"`
+	ToCommitID   uuid.UUID  `json:"to_commit_id"`
+	Context      int        `json:"context"`
+	Binary       bool       `json:"binary"`
+	Hunks        []DiffHunk `json:"hunks"`
+	Files        []FileDiff `json:"files,omitempty"`
+	Unified      string     `json:"unified"`
+}
@@ -1,6 +1,11 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
 
 // Error codes matching OpenAPI spec
 const (
@@ -13,6 +18,9 @@ const (
 	ErrCodeMergeConflict         = "MERGE_CONFLICT"
 	ErrCodeInvalidCommitName     = "INVALID_COMMIT_NAME"
 	ErrCodeCommitNameExists      = "COMMIT_NAME_EXISTS"
+	ErrCodeFileNotFound          = "FILE_NOT_FOUND"
+	ErrCodeInvalidArchive        = "INVALID_ARCHIVE"
+	ErrCodeMixedContent          = "MIXED_CONTENT"
 )
 
 // Domain errors
@@ -26,8 +34,25 @@ var (
 	ErrMergeConflict         = errors.New("merge conflict detected")
 	ErrInvalidCommitName     = errors.New("invalid commit name")
 	ErrCommitNameExists      = errors.New("commit name already exists")
+	ErrFileNotFound          = errors.New("file not found")
+	ErrInvalidArchive        = errors.New("invalid archive")
+	ErrMixedContent          = errors.New("a tree commit can't be compared with a single-file commit")
 )
 
+// MergeConflictError is ErrMergeConflict with the hunks both sides of the merge changed differently
+type MergeConflictError struct {
+	BaseCommit uuid.UUID
+	Conflicts  []ConflictHunk
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%s: %d conflicting hunk(s) against base %s", ErrMergeConflict, len(e.Conflicts), e.BaseCommit)
+}
+
+func (e *MergeConflictError) Unwrap() error {
+	return ErrMergeConflict
+}
+
 // ErrorResponse represents API error response
 type ErrorResponse struct {
 	Error ErrorDetail `json:"error"`
@@ -35,8 +60,9 @@ type ErrorResponse struct {
 
 // ErrorDetail contains error code and message
 type ErrorDetail struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code      string         `json:"code"`
+	Message   string         `json:"message"`
+	Conflicts []ConflictHunk `json:"conflicts,omitempty"`
 }
 
 // NewErrorResponse creates a new error response
@@ -70,6 +96,12 @@ func MapErrorToCode(err error) string {
 		return ErrCodeInvalidCommitName
 	case errors.Is(err, ErrCommitNameExists):
 		return ErrCodeCommitNameExists
+	case errors.Is(err, ErrFileNotFound):
+		return ErrCodeFileNotFound
+	case errors.Is(err, ErrInvalidArchive):
+		return ErrCodeInvalidArchive
+	case errors.Is(err, ErrMixedContent):
+		return ErrCodeMixedContent
 	default:
 		return "INTERNAL_ERROR"
 	}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// checkCommits checks that the team, its repository and all the given commits of it exist
+func (s *Service) checkCommits(ctx context.Context, teamID, rootCommit uuid.UUID, commitIDs ...uuid.UUID) error {
+	log := logger.FromContext(ctx)
+
+	exists, err := s.storage.TeamExists(ctx, teamID)
+	if err != nil {
+		log.Error(ctx, "failed to check team existence", zap.Error(err))
+		return err
+	}
+	if !exists {
+		return domain.ErrTeamNotFound
+	}
+
+	rootExists, err := s.storage.RootCommitExists(ctx, teamID, rootCommit)
+	if err != nil {
+		log.Error(ctx, "failed to check root commit existence", zap.Error(err))
+		return err
+	}
+	if !rootExists {
+		return domain.ErrRootCommitNotFound
+	}
+
+	for _, commitID := range commitIDs {
+		if _, err := s.storage.GetCommit(ctx, teamID, rootCommit, commitID); err != nil {
+			if errors.Is(err, domain.ErrCommitNotFound) {
+				return domain.ErrCommitNotFound
+			}
+			log.Error(ctx, "failed to get commit", zap.Error(err), zap.String("commit_id", commitID.String()))
+			return err
+		}
+	}
+	return nil
+}
+
+// MergeBase returns the lowest common ancestor of two commits of a repository
+func (s *Service) MergeBase(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (uuid.UUID, error) {
+	if err := s.checkCommits(ctx, teamID, rootCommit, commitID1, commitID2); err != nil {
+		return uuid.Nil, err
+	}
+
+	mergeBase, err := s.storage.MergeBase(ctx, teamID, rootCommit, commitID1, commitID2)
+	if err != nil {
+		logger.FromContext(ctx).Error(ctx, "failed to find merge base", zap.Error(err))
+		return uuid.Nil, err
+	}
+	return mergeBase, nil
+}
+
+// IsAncestor checks if ancestorID is commitID or one of its ancestors
+func (s *Service) IsAncestor(ctx context.Context, teamID, rootCommit, ancestorID, commitID uuid.UUID) (bool, error) {
+	if err := s.checkCommits(ctx, teamID, rootCommit, ancestorID, commitID); err != nil {
+		return false, err
+	}
+
+	isAncestor, err := s.storage.IsAncestor(ctx, teamID, rootCommit, ancestorID, commitID)
+	if err != nil {
+		logger.FromContext(ctx).Error(ctx, "failed to check ancestry", zap.Error(err))
+		return false, err
+	}
+	return isAncestor, nil
+}
+
+// AheadBehind counts how many commits each of two commits has that the other doesn't
+func (s *Service) AheadBehind(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (*domain.AheadBehind, error) {
+	if err := s.checkCommits(ctx, teamID, rootCommit, commitID1, commitID2); err != nil {
+		return nil, err
+	}
+
+	ahead, behind, err := s.storage.CountAheadBehind(ctx, teamID, rootCommit, commitID1, commitID2)
+	if err != nil {
+		logger.FromContext(ctx).Error(ctx, "failed to count ahead/behind commits", zap.Error(err))
+		return nil, err
+	}
+	return &domain.AheadBehind{CommitID1: commitID1, CommitID2: commitID2, Ahead: ahead, Behind: behind}, nil
+}
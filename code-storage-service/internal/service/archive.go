@@ -0,0 +1,171 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+)
+
+// maxTreeSize caps the unpacked size of an uploaded tree, so a small compressed archive can't expand without bound
+const maxTreeSize = 512 << 20 // 512MB
+
+// readArchive unpacks a tar (optionally gzipped) or zip archive into a tree. Only regular files are kept;
+// paths are cleaned, and absolute, escaping or repeated paths make the archive invalid, as does a path
+// that is both a file and a directory.
+func readArchive(format string, data []byte) (domain.Tree, error) {
+	tree := domain.Tree{}
+	dirs := map[string]bool{}
+	size := int64(0)
+	add := func(name string, r io.Reader) error {
+		filePath, err := treePath(name)
+		if err != nil {
+			return err
+		}
+		if _, ok := tree[filePath]; ok {
+			return fmt.Errorf("%w: %s appears twice", domain.ErrInvalidArchive, filePath)
+		}
+		if dirs[filePath] {
+			return fmt.Errorf("%w: %s is both a file and a directory", domain.ErrInvalidArchive, filePath)
+		}
+		for dir := path.Dir(filePath); dir != "."; dir = path.Dir(dir) {
+			if _, ok := tree[dir]; ok {
+				return fmt.Errorf("%w: %s is both a file and a directory", domain.ErrInvalidArchive, dir)
+			}
+			dirs[dir] = true
+		}
+		content, err := io.ReadAll(io.LimitReader(r, maxTreeSize-size+1))
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", domain.ErrInvalidArchive, filePath, err)
+		}
+		if size += int64(len(content)); size > maxTreeSize {
+			return fmt.Errorf("%w: unpacked size exceeds %d bytes", domain.ErrInvalidArchive, int64(maxTreeSize))
+		}
+		tree[filePath] = content
+		return nil
+	}
+
+	switch format {
+	case domain.ArchiveZip:
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidArchive, err)
+		}
+		for _, f := range zr.File {
+			if !f.Mode().IsRegular() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", domain.ErrInvalidArchive, f.Name, err)
+			}
+			err = add(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+	case domain.ArchiveTar:
+		var r io.Reader = bytes.NewReader(data)
+		if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+			gz, err := gzip.NewReader(r)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrInvalidArchive, err)
+			}
+			defer gz.Close()
+			r = gz
+		}
+		tr := tar.NewReader(r)
+		for {
+			hdr, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", domain.ErrInvalidArchive, err)
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := add(hdr.Name, tr); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", domain.ErrInvalidArchive, format)
+	}
+
+	if len(tree) == 0 {
+		return nil, fmt.Errorf("%w: no files", domain.ErrInvalidArchive)
+	}
+	return tree, nil
+}
+
+// treePath cleans an archive entry name into a relative slash-separated tree path
+func treePath(name string) (string, error) {
+	name = strings.TrimPrefix(strings.ReplaceAll(name, "\\", "/"), "./")
+	cleaned := path.Clean(name)
+	if name == "" || path.IsAbs(cleaned) || cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("%w: bad path %q", domain.ErrInvalidArchive, name)
+	}
+	return cleaned, nil
+}
+
+// writeArchive packs a tree into a tar or zip archive, with entries in path order
+func writeArchive(format string, tree domain.Tree, modTime time.Time) ([]byte, error) {
+	paths := make([]string, 0, len(tree))
+	for filePath := range tree {
+		paths = append(paths, filePath)
+	}
+	slices.Sort(paths)
+
+	var buf bytes.Buffer
+	switch format {
+	case domain.ArchiveZip:
+		zw := zip.NewWriter(&buf)
+		for _, filePath := range paths {
+			w, err := zw.CreateHeader(&zip.FileHeader{Name: filePath, Method: zip.Deflate, Modified: modTime})
+			if err != nil {
+				return nil, err
+			}
+			if _, err := w.Write(tree[filePath]); err != nil {
+				return nil, err
+			}
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	case domain.ArchiveTar:
+		tw := tar.NewWriter(&buf)
+		for _, filePath := range paths {
+			hdr := &tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     filePath,
+				Mode:     0o644,
+				Size:     int64(len(tree[filePath])),
+				ModTime:  modTime,
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return nil, err
+			}
+			if _, err := tw.Write(tree[filePath]); err != nil {
+				return nil, err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown archive format %q", format)
+	}
+	return buf.Bytes(), nil
+}
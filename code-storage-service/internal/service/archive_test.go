@@ -0,0 +1,117 @@
+package service
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+)
+
+// entry is a file of a test archive
+type entry struct {
+	name    string
+	content string
+}
+
+func tarArchive(t *testing.T, entries ...entry) []byte {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, e := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: e.name, Mode: 0o644, Size: int64(len(e.content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipArchive(t *testing.T, entries ...entry) []byte {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		w, err := zw.Create(e.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadArchive(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []entry
+		want    domain.Tree
+	}{
+		{
+			name:    "nested files",
+			entries: []entry{{"./main.go", "package main\n"}, {"pkg/util.go", "package pkg\n"}, {`pkg\win.go`, "package pkg\n"}},
+			want:    domain.Tree{"main.go": []byte("package main\n"), "pkg/util.go": []byte("package pkg\n"), "pkg/win.go": []byte("package pkg\n")},
+		},
+		{name: "parent directory entry", entries: []entry{{"../evil.go", "x"}}},
+		{name: "parent directory inside the path", entries: []entry{{"pkg/../../evil.go", "x"}}},
+		{name: "absolute path", entries: []entry{{"/etc/passwd", "x"}}},
+		{name: "absolute windows-style path", entries: []entry{{`\etc\passwd`, "x"}}},
+		{name: "repeated path", entries: []entry{{"a.go", "x"}, {"./a.go", "y"}}},
+		{name: "file before a directory of the same name", entries: []entry{{"a", "x"}, {"a/b", "y"}}},
+		{name: "directory before a file of the same name", entries: []entry{{"a/b/c", "x"}, {"a/b", "y"}}},
+		{name: "no files"},
+	}
+	formats := map[string]func(*testing.T, ...entry) []byte{domain.ArchiveTar: tarArchive, domain.ArchiveZip: zipArchive}
+	for format, build := range formats {
+		for _, tt := range tests {
+			t.Run(format+"/"+tt.name, func(t *testing.T) {
+				tree, err := readArchive(format, build(t, tt.entries...))
+				if tt.want == nil {
+					if !errors.Is(err, domain.ErrInvalidArchive) {
+						t.Fatalf("readArchive() = %q, %v, want ErrInvalidArchive", tree, err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("readArchive(): %v", err)
+				}
+				if len(tree) != len(tt.want) {
+					t.Fatalf("tree %q, want %q", tree, tt.want)
+				}
+				for filePath, content := range tt.want {
+					if !bytes.Equal(tree[filePath], content) {
+						t.Fatalf("tree %q, want %q", tree, tt.want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	tree := domain.Tree{"README.md": []byte("# repo\n"), "cmd/main.go": []byte("package main\n"), "empty.txt": {}}
+	for _, format := range []string{domain.ArchiveTar, domain.ArchiveZip} {
+		data, err := writeArchive(format, tree, time.Unix(0, 0))
+		if err != nil {
+			t.Fatalf("%s: writeArchive(): %v", format, err)
+		}
+		got, err := readArchive(format, data)
+		if err != nil {
+			t.Fatalf("%s: readArchive(): %v", format, err)
+		}
+		if len(got) != len(tree) || string(got["cmd/main.go"]) != "package main\n" || got["empty.txt"] == nil {
+			t.Fatalf("%s: round trip gave %q, want %q", format, got, tree)
+		}
+	}
+}
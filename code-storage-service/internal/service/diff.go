@@ -0,0 +1,232 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// DefaultDiffContext is how many unchanged lines surround each change when no context is requested
+const DefaultDiffContext = 3
+
+// binaryProbe is how much of the code is searched for a NUL byte to tell binary code from text, as git does
+const binaryProbe = 8000
+
+// Diff returns the line diff turning the code of fromCommit into the code of toCommit,
+// with contextLines unchanged lines around each change. Tree commits are diffed file by file.
+func (s *Service) Diff(ctx context.Context, teamID, rootCommit, fromCommit, toCommit uuid.UUID, contextLines int) (*domain.Diff, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.checkCommits(ctx, teamID, rootCommit, fromCommit, toCommit); err != nil {
+		return nil, err
+	}
+
+	var commits [2]*domain.Commit
+	for i, commitID := range []uuid.UUID{fromCommit, toCommit} {
+		commit, err := s.storage.GetCommit(ctx, teamID, rootCommit, commitID)
+		if err != nil {
+			log.Error(ctx, "failed to get commit to diff", zap.Error(err), zap.String("commit_id", commitID.String()))
+			return nil, err
+		}
+		commits[i] = commit
+	}
+	if commits[0].Tree != commits[1].Tree {
+		return nil, domain.ErrMixedContent
+	}
+	if commits[0].Tree {
+		return s.diffTrees(ctx, teamID, rootCommit, fromCommit, toCommit, contextLines)
+	}
+
+	var codes [2][]byte
+	for i, commitID := range []uuid.UUID{fromCommit, toCommit} {
+		code, err := s.storage.GetCommitCode(ctx, teamID, rootCommit, commitID)
+		if err != nil {
+			log.Error(ctx, "failed to get code to diff", zap.Error(err), zap.String("commit_id", commitID.String()))
+			return nil, err
+		}
+		codes[i] = code
+	}
+
+	diff := &domain.Diff{
+		FromCommitID: fromCommit,
+		ToCommitID:   toCommit,
+		Context:      contextLines,
+		Hunks:        []domain.DiffHunk{},
+	}
+	fromName, toName := s.diffLabel(ctx, fromCommit), s.diffLabel(ctx, toCommit)
+	if isBinary(codes[0]) || isBinary(codes[1]) {
+		diff.Binary = true
+		if !bytes.Equal(codes[0], codes[1]) {
+			diff.Unified = fmt.Sprintf("Binary files a/%s and b/%s differ\n", fromName, toName)
+		}
+		return diff, nil
+	}
+
+	diff.Hunks = diffHunks(splitLines(codes[0]), splitLines(codes[1]), contextLines)
+	diff.Unified = formatUnified("a/"+fromName, "b/"+toName, diff.Hunks)
+	return diff, nil
+}
+
+// diffTrees diffs two tree commits file by file; the unified diff names files by path, and an added or
+// deleted file by /dev/null on the side it is missing from
+func (s *Service) diffTrees(ctx context.Context, teamID, rootCommit, fromCommit, toCommit uuid.UUID, contextLines int) (*domain.Diff, error) {
+	log := logger.FromContext(ctx)
+
+	var trees [2]domain.Tree
+	for i, commitID := range []uuid.UUID{fromCommit, toCommit} {
+		tree, err := s.storage.GetCommitTree(ctx, teamID, rootCommit, commitID)
+		if err != nil {
+			log.Error(ctx, "failed to get tree to diff", zap.Error(err), zap.String("commit_id", commitID.String()))
+			return nil, err
+		}
+		trees[i] = tree
+	}
+
+	diff := &domain.Diff{
+		FromCommitID: fromCommit,
+		ToCommitID:   toCommit,
+		Context:      contextLines,
+		Hunks:        []domain.DiffHunk{},
+		Files:        []domain.FileDiff{},
+	}
+	var unified strings.Builder
+	for _, filePath := range treePaths(trees[0], trees[1]) {
+		from, inFrom := trees[0][filePath]
+		to, inTo := trees[1][filePath]
+		if inFrom && inTo && bytes.Equal(from, to) {
+			continue
+		}
+
+		file := domain.FileDiff{Path: filePath, Status: domain.FileModified, Hunks: []domain.DiffHunk{}}
+		fromName, toName := "a/"+filePath, "b/"+filePath
+		if !inFrom {
+			file.Status, fromName = domain.FileAdded, "/dev/null"
+		}
+		if !inTo {
+			file.Status, toName = domain.FileDeleted, "/dev/null"
+		}
+
+		if isBinary(from) || isBinary(to) {
+			file.Binary = true
+			fmt.Fprintf(&unified, "Binary files %s and %s differ\n", fromName, toName)
+		} else {
+			file.Hunks = diffHunks(splitLines(from), splitLines(to), contextLines)
+			unified.WriteString(formatUnified(fromName, toName, file.Hunks))
+		}
+		diff.Files = append(diff.Files, file)
+	}
+	diff.Unified = unified.String()
+	return diff, nil
+}
+
+// diffLabel names a commit in diff headers: by its name, or by ID if it has none
+func (s *Service) diffLabel(ctx context.Context, commitID uuid.UUID) string {
+	name, err := s.storage.GetCommitName(ctx, commitID)
+	if err != nil || name == "" {
+		return commitID.String()
+	}
+	return name
+}
+
+// isBinary reports whether code looks binary: it has a NUL byte near its start
+func isBinary(code []byte) bool {
+	return bytes.IndexByte(code[:min(len(code), binaryProbe)], 0) >= 0
+}
+
+// diffHunks groups the changes turning a into b into hunks with contextLines lines of context;
+// changes closer than twice the context share a hunk
+func diffHunks(a, b []string, contextLines int) []domain.DiffHunk {
+	changes := diffLines(a, b)
+	hunks := []domain.DiffHunk{}
+	delta := 0 // how many lines longer b is than a before the current change
+	for i := 0; i < len(changes); {
+		j := i + 1
+		for j < len(changes) && changes[j].start-changes[j-1].end <= 2*contextLines {
+			j++
+		}
+
+		oldStart := max(changes[i].start-contextLines, 0)
+		oldEnd := min(changes[j-1].end+contextLines, len(a))
+		hunk := domain.DiffHunk{OldStart: oldStart, NewStart: oldStart + delta}
+		pos := oldStart
+		for _, change := range changes[i:j] {
+			hunk.Lines = append(hunk.Lines, diffLinesOf(domain.DiffOpContext, a[pos:change.start])...)
+			hunk.Lines = append(hunk.Lines, diffLinesOf(domain.DiffOpDelete, a[change.start:change.end])...)
+			hunk.Lines = append(hunk.Lines, diffLinesOf(domain.DiffOpInsert, change.lines)...)
+			delta += len(change.lines) - (change.end - change.start)
+			pos = change.end
+		}
+		hunk.Lines = append(hunk.Lines, diffLinesOf(domain.DiffOpContext, a[pos:oldEnd])...)
+
+		for _, line := range hunk.Lines {
+			if line.Op != domain.DiffOpInsert {
+				hunk.OldLines++
+			}
+			if line.Op != domain.DiffOpDelete {
+				hunk.NewLines++
+			}
+		}
+		// Headers count lines from 1, and an empty range starts at the line before it
+		if hunk.OldLines > 0 {
+			hunk.OldStart++
+		}
+		if hunk.NewLines > 0 {
+			hunk.NewStart++
+		}
+		hunks = append(hunks, hunk)
+		i = j
+	}
+	return hunks
+}
+
+// diffLinesOf turns lines, each keeping its trailing newline, into diff lines of the operation
+func diffLinesOf(op string, lines []string) []domain.DiffLine {
+	result := make([]domain.DiffLine, len(lines))
+	for i, line := range lines {
+		text, hasNewline := strings.CutSuffix(line, "\n")
+		result[i] = domain.DiffLine{Op: op, Text: text, NoNewline: !hasNewline}
+	}
+	return result
+}
+
+// formatUnified renders hunks as a unified diff of the labelled files; no hunks render as ""
+func formatUnified(fromLabel, toLabel string, hunks []domain.DiffHunk) string {
+	if len(hunks) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", fromLabel, toLabel)
+	for _, hunk := range hunks {
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(hunk.OldStart, hunk.OldLines), hunkRange(hunk.NewStart, hunk.NewLines))
+		for _, line := range hunk.Lines {
+			switch line.Op {
+			case domain.DiffOpDelete:
+				b.WriteByte('-')
+			case domain.DiffOpInsert:
+				b.WriteByte('+')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+			if line.NoNewline {
+				b.WriteString("\\ No newline at end of file\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// hunkRange formats a hunk header range, leaving out a count of 1 as diff does
+func hunkRange(start, lines int) string {
+	if lines == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
@@ -0,0 +1,57 @@
+package service
+
+import "testing"
+
+func TestDiffHunksUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		context int
+		want    string
+	}{
+		{
+			name:    "insert at end of file",
+			from:    "a\nb\nc\n",
+			to:      "a\nb\nc\nd\n",
+			context: 3,
+			want:    "--- from\n+++ to\n@@ -1,3 +1,4 @@\n a\n b\n c\n+d\n",
+		},
+		{
+			name:    "insert at end of file without context",
+			from:    "a\nb\nc\n",
+			to:      "a\nb\nc\nd\n",
+			context: 0,
+			want:    "--- from\n+++ to\n@@ -3,0 +4 @@\n+d\n",
+		},
+		{
+			name:    "zero context keeps nearby changes apart",
+			from:    "1\n2\n3\n4\n5\n",
+			to:      "1\nX\n3\n5\n",
+			context: 0,
+			want:    "--- from\n+++ to\n@@ -2 +2 @@\n-2\n+X\n@@ -4 +3,0 @@\n-4\n",
+		},
+		{
+			name:    "newline added at end of file",
+			from:    "a\nb",
+			to:      "a\nb\nc\n",
+			context: 3,
+			want:    "--- from\n+++ to\n@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n+b\n+c\n",
+		},
+		{
+			name:    "no changes",
+			from:    "a\nb\n",
+			to:      "a\nb\n",
+			context: 3,
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hunks := diffHunks(splitLines([]byte(tt.from)), splitLines([]byte(tt.to)), tt.context)
+			if got := formatUnified("from", "to", hunks); got != tt.want {
+				t.Fatalf("unified diff\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,334 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// lineHunk replaces lines [start, end) of the base with lines
+type lineHunk struct {
+	start, end int
+	lines      []string
+}
+
+// threeWayMerge merges the changes base->code1 and base->code2 line by line. Changes to different
+// parts of the base are all kept; where both sides changed the same or adjacent lines differently,
+// the region is reported as a conflict and the merged code must not be used.
+func threeWayMerge(base, code1, code2 []byte) ([]byte, []domain.ConflictHunk) {
+	baseLines := splitLines(base)
+	hunks1 := diffLines(baseLines, splitLines(code1))
+	hunks2 := diffLines(baseLines, splitLines(code2))
+
+	var merged []string
+	var conflicts []domain.ConflictHunk
+	pos := 0
+	for len(hunks1) > 0 || len(hunks2) > 0 {
+		// Group every hunk of either side that overlaps or touches the region started by the earliest one
+		start := len(baseLines)
+		if len(hunks1) > 0 {
+			start = hunks1[0].start
+		}
+		if len(hunks2) > 0 && hunks2[0].start < start {
+			start = hunks2[0].start
+		}
+		end := start
+		var group1, group2 []lineHunk
+	grouping:
+		for {
+			switch {
+			case len(hunks1) > 0 && hunks1[0].start <= end:
+				end = max(end, hunks1[0].end)
+				group1 = append(group1, hunks1[0])
+				hunks1 = hunks1[1:]
+			case len(hunks2) > 0 && hunks2[0].start <= end:
+				end = max(end, hunks2[0].end)
+				group2 = append(group2, hunks2[0])
+				hunks2 = hunks2[1:]
+			default:
+				break grouping
+			}
+		}
+		merged = append(merged, baseLines[pos:start]...)
+		side1 := applyHunks(baseLines, group1, start, end)
+		side2 := applyHunks(baseLines, group2, start, end)
+		switch {
+		case len(group2) == 0:
+			merged = append(merged, side1...)
+		case len(group1) == 0, slices.Equal(side1, side2):
+			merged = append(merged, side2...)
+		default:
+			conflicts = append(conflicts, domain.ConflictHunk{
+				BaseStart: start + 1,
+				Base:      trimNewlines(baseLines[start:end]),
+				Commit1:   trimNewlines(side1),
+				Commit2:   trimNewlines(side2),
+			})
+		}
+		pos = end
+	}
+	merged = append(merged, baseLines[pos:]...)
+
+	if len(conflicts) > 0 {
+		return nil, conflicts
+	}
+	return []byte(strings.Join(merged, "")), nil
+}
+
+// mergeTrees merges the changes base->tree1 and base->tree2 file by file. A file changed on one side only
+// takes that side; a file changed on both is merged line by line, unless it is binary or one side deleted it,
+// in which case the whole file conflicts, as does a file that the other side turned into a directory.
+// Conflicts are reported in path order.
+func mergeTrees(base, tree1, tree2 domain.Tree) (domain.Tree, []domain.ConflictHunk) {
+	merged := domain.Tree{}
+	var conflicts []domain.ConflictHunk
+	for _, filePath := range treePaths(base, tree1, tree2) {
+		baseCode, inBase := base[filePath]
+		code1, in1 := tree1[filePath]
+		code2, in2 := tree2[filePath]
+		unchanged1 := in1 == inBase && bytes.Equal(code1, baseCode)
+		unchanged2 := in2 == inBase && bytes.Equal(code2, baseCode)
+
+		switch {
+		case unchanged1 || (in1 == in2 && bytes.Equal(code1, code2)):
+			if in2 {
+				merged[filePath] = code2
+			}
+		case unchanged2:
+			if in1 {
+				merged[filePath] = code1
+			}
+		case !in1:
+			conflicts = append(conflicts, fileConflict(filePath, "deleted in commit1 and changed in commit2"))
+		case !in2:
+			conflicts = append(conflicts, fileConflict(filePath, "changed in commit1 and deleted in commit2"))
+		case isBinary(baseCode) || isBinary(code1) || isBinary(code2):
+			conflicts = append(conflicts, fileConflict(filePath, "binary file changed in both commits"))
+		default:
+			code, fileConflicts := threeWayMerge(baseCode, code1, code2)
+			for _, conflict := range fileConflicts {
+				conflict.Path = filePath
+				conflicts = append(conflicts, conflict)
+			}
+			merged[filePath] = code
+		}
+	}
+	for _, filePath := range fileDirClashes(merged) {
+		conflicts = append(conflicts, fileConflict(filePath, "path is a file in one commit and a directory in the other"))
+	}
+
+	if len(conflicts) > 0 {
+		slices.SortStableFunc(conflicts, func(a, b domain.ConflictHunk) int { return strings.Compare(a.Path, b.Path) })
+		return nil, conflicts
+	}
+	return merged, nil
+}
+
+// fileDirClashes returns the files of a tree that other files use as a directory, sorted
+func fileDirClashes(tree domain.Tree) []string {
+	var clashes []string
+	for filePath := range tree {
+		for dir := path.Dir(filePath); dir != "."; dir = path.Dir(dir) {
+			if _, ok := tree[dir]; ok {
+				clashes = append(clashes, dir)
+			}
+		}
+	}
+	slices.Sort(clashes)
+	return slices.Compact(clashes)
+}
+
+// fileConflict is a conflict over a whole file of a tree
+func fileConflict(filePath, reason string) domain.ConflictHunk {
+	return domain.ConflictHunk{Path: filePath, Reason: reason, Base: []string{}, Commit1: []string{}, Commit2: []string{}}
+}
+
+// treePaths returns the paths of all the trees' files, sorted
+func treePaths(trees ...domain.Tree) []string {
+	var paths []string
+	for _, tree := range trees {
+		for filePath := range tree {
+			paths = append(paths, filePath)
+		}
+	}
+	slices.Sort(paths)
+	return slices.Compact(paths)
+}
+
+// baseTree returns the tree of a merge base; a single-file base has no files in common with a tree
+func (s *Service) baseTree(ctx context.Context, teamID, rootCommit, baseCommit uuid.UUID) (domain.Tree, error) {
+	commit, err := s.storage.GetCommit(ctx, teamID, rootCommit, baseCommit)
+	if err != nil {
+		return nil, err
+	}
+	if !commit.Tree {
+		return domain.Tree{}, nil
+	}
+	return s.storage.GetCommitTree(ctx, teamID, rootCommit, baseCommit)
+}
+
+// applyHunks returns lines [start, end) of the base with the hunks, all inside that range, applied
+func applyHunks(base []string, hunks []lineHunk, start, end int) []string {
+	result := []string{}
+	pos := start
+	for _, h := range hunks {
+		result = append(result, base[pos:h.start]...)
+		result = append(result, h.lines...)
+		pos = h.end
+	}
+	return append(result, base[pos:end]...)
+}
+
+// diffLines returns the hunks turning a into b, in order, from a shortest edit script found with
+// Myers' linear-space algorithm: O((N+M)·D) time for D changed lines and O(N+M) memory
+func diffLines(a, b []string) []lineHunk {
+	var runs []lineRun
+	matchLines(a, b, 0, 0, &runs)
+	runs = append(runs, lineRun{a: len(a), b: len(b)})
+
+	var hunks []lineHunk
+	i, j := 0, 0
+	for _, run := range runs {
+		if run.a > i || run.b > j {
+			hunks = append(hunks, lineHunk{start: i, end: run.a, lines: b[j:run.b]})
+		}
+		i, j = run.a+run.n, run.b+run.n
+	}
+	return hunks
+}
+
+// lineRun is n equal lines starting at line a of the old code and line b of the new one
+type lineRun struct {
+	a, b, n int
+}
+
+// matchLines appends to runs, in order, the equal lines of a shortest edit script turning a into b;
+// aOff and bOff are where a and b start in the whole codes
+func matchLines(a, b []string, aOff, bOff int, runs *[]lineRun) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	if prefix > 0 {
+		*runs = append(*runs, lineRun{a: aOff, b: bOff, n: prefix})
+	}
+	a, b = a[prefix:], b[prefix:]
+	aOff, bOff = aOff+prefix, bOff+prefix
+
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	if len(a) > 0 && len(b) > 0 {
+		// With the common ends stripped, both halves of the split are smaller than the whole
+		if x, y, ok := middleSnake(a, b); ok {
+			matchLines(a[:x], b[:y], aOff, bOff, runs)
+			matchLines(a[x:], b[y:], aOff+x, bOff+y, runs)
+		}
+	}
+	if suffix > 0 {
+		*runs = append(*runs, lineRun{a: aOff + len(a), b: bOff + len(b), n: suffix})
+	}
+}
+
+// middleSnake finds the point (x, y) where a shortest edit script turning a into b is split in two,
+// searching from both ends at once until the forward and backward paths meet. It reports false when
+// a and b have no line in common.
+func middleSnake(a, b []string) (int, int, bool) {
+	n, m := len(a), len(b)
+	maxD := (n + m + 1) / 2
+	offset := maxD + 1
+	// forward[offset+k] and backward[offset+k] are the furthest x reached on diagonal k, counted
+	// from the start and from the end of both codes
+	forward := make([]int, 2*offset+1)
+	backward := make([]int, 2*offset+1)
+	for i := range forward {
+		forward[i], backward[i] = -1, -1
+	}
+	forward[offset+1], backward[offset+1] = 0, 0
+	delta := n - m
+	// With an odd delta the paths meet on a forward step, with an even one on a backward step
+	odd := delta%2 != 0
+	// Diagonals whose paths left the grid are trimmed from both ends of the search
+	fStart, fEnd, bStart, bEnd := 0, 0, 0, 0
+	for d := 0; d <= maxD; d++ {
+		for k := -d + fStart; k <= d-fEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+			switch {
+			case x > n:
+				fEnd += 2
+			case y > m:
+				fStart += 2
+			case odd:
+				if i := offset + delta - k; i >= 0 && i < len(backward) && backward[i] != -1 && x >= n-backward[i] {
+					return x, y, true
+				}
+			}
+		}
+		for k := -d + bStart; k <= d-bEnd; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+			switch {
+			case x > n:
+				bEnd += 2
+			case y > m:
+				bStart += 2
+			case !odd:
+				if i := offset + delta - k; i >= 0 && i < len(forward) && forward[i] != -1 && forward[i] >= n-x {
+					fx := forward[i]
+					return fx, fx - (delta - k), true
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// splitLines splits code into lines, each keeping its trailing newline
+func splitLines(code []byte) []string {
+	if len(code) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(code), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// trimNewlines drops the trailing newlines of lines for reporting
+func trimNewlines(lines []string) []string {
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = strings.TrimSuffix(line, "\n")
+	}
+	return result
+}
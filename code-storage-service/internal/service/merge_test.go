@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+)
+
+func TestThreeWayMerge(t *testing.T) {
+	base := "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n"
+	tests := []struct {
+		name      string
+		code1     string
+		code2     string
+		want      string
+		conflicts []domain.ConflictHunk
+	}{
+		{
+			name:  "clean merge of separate changes",
+			code1: "package main\n\nfunc a() { one() }\n\nfunc b() {}\n\nfunc c() {}\n",
+			code2: "package main\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() { two() }\n",
+			want:  "package main\n\nfunc a() { one() }\n\nfunc b() {}\n\nfunc c() { two() }\n",
+		},
+		{
+			name:  "insert and delete",
+			code1: "package main\n\nimport \"fmt\"\n\nfunc a() {}\n\nfunc b() {}\n\nfunc c() {}\n",
+			code2: "package main\n\nfunc a() {}\n\nfunc c() {}\n",
+			want:  "package main\n\nimport \"fmt\"\n\nfunc a() {}\n\nfunc c() {}\n",
+		},
+		{
+			name:  "same change on both sides",
+			code1: "package main\n\nfunc a() {}\n\nfunc b() { same() }\n\nfunc c() {}\n",
+			code2: "package main\n\nfunc a() {}\n\nfunc b() { same() }\n\nfunc c() {}\n",
+			want:  "package main\n\nfunc a() {}\n\nfunc b() { same() }\n\nfunc c() {}\n",
+		},
+		{
+			name:  "both sides edit the same line",
+			code1: "package main\n\nfunc a() {}\n\nfunc b() { one() }\n\nfunc c() {}\n",
+			code2: "package main\n\nfunc a() {}\n\nfunc b() { two() }\n\nfunc c() {}\n",
+			conflicts: []domain.ConflictHunk{{
+				BaseStart: 5,
+				Base:      []string{"func b() {}"},
+				Commit1:   []string{"func b() { one() }"},
+				Commit2:   []string{"func b() { two() }"},
+			}},
+		},
+		{
+			name:  "adjacent edits conflict",
+			code1: "package main\n\nfunc a() { one() }\n\nfunc b() {}\n\nfunc c() {}\n",
+			code2: "package main\n\nfunc a() {}\nfunc inserted() {}\n\nfunc b() {}\n\nfunc c() {}\n",
+			conflicts: []domain.ConflictHunk{{
+				BaseStart: 3,
+				Base:      []string{"func a() {}"},
+				Commit1:   []string{"func a() { one() }"},
+				Commit2:   []string{"func a() {}", "func inserted() {}"},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, conflicts := threeWayMerge([]byte(base), []byte(tt.code1), []byte(tt.code2))
+			if tt.conflicts != nil {
+				if merged != nil || len(conflicts) != len(tt.conflicts) {
+					t.Fatalf("merged %q with conflicts %+v, want conflicts %+v", merged, conflicts, tt.conflicts)
+				}
+				for i, want := range tt.conflicts {
+					got := conflicts[i]
+					if got.BaseStart != want.BaseStart || !slices.Equal(got.Base, want.Base) ||
+						!slices.Equal(got.Commit1, want.Commit1) || !slices.Equal(got.Commit2, want.Commit2) {
+						t.Fatalf("conflict %d = %+v, want %+v", i, got, want)
+					}
+				}
+				return
+			}
+			if len(conflicts) > 0 {
+				t.Fatalf("unexpected conflicts %+v", conflicts)
+			}
+			if string(merged) != tt.want {
+				t.Fatalf("merged\n%s\nwant\n%s", merged, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeTrees(t *testing.T) {
+	base := domain.Tree{"a.go": []byte("a\n"), "b.go": []byte("b\n"), "c.go": []byte("c\n")}
+	tree1 := domain.Tree{"a.go": []byte("a1\n"), "b.go": []byte("b\n"), "c.go": []byte("c\n"), "new.go": []byte("n\n")}
+	tree2 := domain.Tree{"a.go": []byte("a\n"), "b.go": []byte("b2\n")}
+
+	_, conflicts := mergeTrees(base, tree1, tree2)
+	if len(conflicts) != 0 {
+		t.Fatalf("unexpected conflicts %+v", conflicts)
+	}
+	merged, _ := mergeTrees(base, tree1, domain.Tree{"a.go": []byte("a\n"), "b.go": []byte("b2\n"), "c.go": []byte("c\n")})
+	want := domain.Tree{"a.go": []byte("a1\n"), "b.go": []byte("b2\n"), "c.go": []byte("c\n"), "new.go": []byte("n\n")}
+	if fmt.Sprint(merged) != fmt.Sprint(want) {
+		t.Fatalf("merged %q, want %q", merged, want)
+	}
+
+	_, conflicts = mergeTrees(base, domain.Tree{"a.go": []byte("a1\n"), "b.go": []byte("b\n")},
+		domain.Tree{"a.go": []byte("a2\n"), "b.go": []byte("b\n"), "c.go": []byte("c2\n")})
+	if len(conflicts) != 2 || conflicts[0].Path != "a.go" || conflicts[1].Path != "c.go" || conflicts[1].Reason == "" {
+		t.Fatalf("conflicts %+v, want a line conflict in a.go and a delete/change conflict in c.go", conflicts)
+	}
+
+	_, conflicts = mergeTrees(base, domain.Tree{"a.go": []byte("a\n"), "b.go": []byte("b\n"), "c.go": []byte("c\n"), "docs": []byte("d\n")},
+		domain.Tree{"a.go": []byte("a\n"), "b.go": []byte("b\n"), "c.go": []byte("c\n"), "docs/x.md": []byte("x\n"), "docs/y/z.md": []byte("z\n")})
+	if len(conflicts) != 1 || conflicts[0].Path != "docs" || conflicts[0].Reason == "" {
+		t.Fatalf("conflicts %+v, want a file/directory conflict on docs", conflicts)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	randomLines := func(n int) []string {
+		lines := make([]string, n)
+		for i := range lines {
+			lines[i] = string(rune('a'+rnd.IntN(4))) + "\n"
+		}
+		return lines
+	}
+	for i := range 500 {
+		a, b := randomLines(rnd.IntN(12)), randomLines(rnd.IntN(12))
+		hunks := diffLines(a, b)
+		if got := applyHunks(a, hunks, 0, len(a)); !slices.Equal(got, b) && len(a)+len(b) > 0 {
+			t.Fatalf("case %d: hunks %+v turn %q into %q, want %q", i, hunks, a, got, b)
+		}
+		changed := 0
+		for j, h := range hunks {
+			if j > 0 && h.start <= hunks[j-1].end {
+				t.Fatalf("case %d: hunks %+v overlap or touch", i, hunks)
+			}
+			changed += h.end - h.start + len(h.lines)
+		}
+		if want := len(a) + len(b) - 2*lcsLength(a, b); changed != want {
+			t.Fatalf("case %d: %q -> %q changes %d lines, want the shortest %d", i, a, b, changed, want)
+		}
+	}
+}
+
+func TestDiffLinesLargeInput(t *testing.T) {
+	// A quadratic table for 200k lines would need 40 billion cells
+	a := make([]string, 200_000)
+	for i := range a {
+		a[i] = fmt.Sprintf("line %d\n", i)
+	}
+	b := slices.Clone(a)
+	b[1000] = "changed\n"
+	b = slices.Insert(b, 150_000, "inserted\n")
+	b = slices.Delete(b, 190_000, 190_002)
+
+	hunks := diffLines(a, b)
+	if len(hunks) != 3 {
+		t.Fatalf("got %d hunks, want 3", len(hunks))
+	}
+	if got := applyHunks(a, hunks, 0, len(a)); !slices.Equal(got, b) {
+		t.Fatal("hunks don't turn the old code into the new one")
+	}
+	if merged, conflicts := threeWayMerge([]byte(strings.Join(a, "")), []byte(strings.Join(b, "")), []byte(strings.Join(a, ""))); len(conflicts) > 0 || string(merged) != strings.Join(b, "") {
+		t.Fatalf("merge with an unchanged side: %d conflicts", len(conflicts))
+	}
+}
+
+// lcsLength is the textbook quadratic longest common subsequence, for checking small inputs
+func lcsLength(a, b []string) int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	return lcs[0][0]
+}
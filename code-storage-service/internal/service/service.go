@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"path"
 
 	"github.com/Meldy183/code-storage-service/internal/domain"
 	"github.com/Meldy183/code-storage-service/internal/storage"
@@ -21,8 +22,9 @@ func NewService(s storage.Storage) *Service {
 	return &Service{storage: s}
 }
 
-// InitRepository initializes a new repository for a team with the initial code
-func (s *Service) InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte) (*domain.Commit, error) {
+// InitRepository initializes a new repository for a team with the initial code.
+// With an archive format the code is a tar or zip archive and the root commit is a tree commit.
+func (s *Service) InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte, format string) (*domain.Commit, error) {
 	log := logger.FromContext(ctx)
 
 	// Check if team exists
@@ -35,8 +37,13 @@ func (s *Service) InitRepository(ctx context.Context, teamID uuid.UUID, commitNa
 		return nil, domain.ErrTeamNotFound
 	}
 
+	tree, err := uploadedTree(format, code)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create root commit
-	commit, err := s.storage.InitRepository(ctx, teamID, commitName, code)
+	commit, err := s.storage.InitRepository(ctx, teamID, commitName, code, tree)
 	if err != nil {
 		log.Error(ctx, "failed to initialize repository", zap.Error(err))
 		return nil, err
@@ -51,8 +58,9 @@ func (s *Service) InitRepository(ctx context.Context, teamID uuid.UUID, commitNa
 	return commit, nil
 }
 
-// Push creates a new commit on top of an existing parent commit
-func (s *Service) Push(ctx context.Context, teamID, rootCommit, parentCommitID uuid.UUID, commitName string, code []byte) (*domain.Commit, error) {
+// Push creates a new commit on top of an existing parent commit.
+// With an archive format the code is a tar or zip archive and the commit is a tree commit.
+func (s *Service) Push(ctx context.Context, teamID, rootCommit, parentCommitID uuid.UUID, commitName string, code []byte, format string) (*domain.Commit, error) {
 	log := logger.FromContext(ctx)
 
 	// Check if team exists
@@ -85,8 +93,13 @@ func (s *Service) Push(ctx context.Context, teamID, rootCommit, parentCommitID u
 		return nil, err
 	}
 
+	tree, err := uploadedTree(format, code)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create new commit
-	commit, err := s.storage.CreateCommit(ctx, teamID, rootCommit, parentCommitID, commitName, code)
+	commit, err := s.storage.CreateCommit(ctx, teamID, rootCommit, parentCommitID, commitName, code, tree)
 	if err != nil {
 		log.Error(ctx, "failed to create commit", zap.Error(err))
 		return nil, err
@@ -101,8 +114,9 @@ func (s *Service) Push(ctx context.Context, teamID, rootCommit, parentCommitID u
 	return commit, nil
 }
 
-// Checkout retrieves the code from a specific commit
-func (s *Service) Checkout(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) ([]byte, error) {
+// Checkout retrieves the code from a specific commit. A tree commit is packed into an archive of the format
+// (zip by default), or, with a path, just that file is returned; a single-file commit has no paths.
+func (s *Service) Checkout(ctx context.Context, teamID, rootCommit, commitID uuid.UUID, filePath, format string) (*domain.Checkout, error) {
 	log := logger.FromContext(ctx)
 
 	// Check if team exists
@@ -125,99 +139,218 @@ func (s *Service) Checkout(ctx context.Context, teamID, rootCommit, commitID uui
 		return nil, domain.ErrRootCommitNotFound
 	}
 
-	// Get commit code
-	code, err := s.storage.GetCommitCode(ctx, teamID, rootCommit, commitID)
+	commit, err := s.storage.GetCommit(ctx, teamID, rootCommit, commitID)
 	if err != nil {
 		if errors.Is(err, domain.ErrCommitNotFound) {
 			return nil, domain.ErrCommitNotFound
 		}
-		log.Error(ctx, "failed to get commit code", zap.Error(err))
+		log.Error(ctx, "failed to get commit", zap.Error(err))
 		return nil, err
 	}
 
+	var checkout *domain.Checkout
+	switch {
+	case !commit.Tree:
+		if filePath != "" {
+			return nil, domain.ErrFileNotFound
+		}
+		// Get commit code
+		code, err := s.storage.GetCommitCode(ctx, teamID, rootCommit, commitID)
+		if err != nil {
+			if errors.Is(err, domain.ErrCommitNotFound) {
+				return nil, domain.ErrCommitNotFound
+			}
+			log.Error(ctx, "failed to get commit code", zap.Error(err))
+			return nil, err
+		}
+		checkout = &domain.Checkout{Content: code, Archive: domain.ArchiveZip, Filename: "code.zip"}
+	case filePath != "":
+		cleaned, err := treePath(filePath)
+		if err != nil {
+			return nil, domain.ErrFileNotFound
+		}
+		content, err := s.storage.GetTreeFile(ctx, teamID, rootCommit, commitID, cleaned)
+		if err != nil {
+			if !errors.Is(err, domain.ErrFileNotFound) {
+				log.Error(ctx, "failed to get tree file", zap.Error(err))
+			}
+			return nil, err
+		}
+		checkout = &domain.Checkout{Content: content, Filename: path.Base(cleaned)}
+	default:
+		tree, err := s.storage.GetCommitTree(ctx, teamID, rootCommit, commitID)
+		if err != nil {
+			log.Error(ctx, "failed to get commit tree", zap.Error(err))
+			return nil, err
+		}
+		if format == "" {
+			format = domain.ArchiveZip
+		}
+		archive, err := writeArchive(format, tree, commit.CreatedAt)
+		if err != nil {
+			log.Error(ctx, "failed to pack commit tree", zap.Error(err))
+			return nil, err
+		}
+		checkout = &domain.Checkout{Content: archive, Archive: format, Filename: commitID.String() + "." + format}
+	}
+
 	log.Info(ctx, "checkout successful",
 		zap.String("commit_id", commitID.String()),
+		zap.String("path", filePath),
 	)
 
-	return code, nil
+	return checkout, nil
 }
 
-// Merge merges two leaf commits into a new merge commit
+// uploadedTree unpacks uploaded code in an archive format into a tree; without a format the code is a single file
+func uploadedTree(format string, code []byte) (domain.Tree, error) {
+	if format == "" {
+		return nil, nil
+	}
+	return readArchive(format, code)
+}
+
+// Merge merges two leaf commits into a new merge commit, three-way against their nearest common ancestor.
+// Tree commits are merged file by file. Lines both commits changed differently, or a file one deleted and
+// the other changed, fail the merge with a *domain.MergeConflictError.
 func (s *Service) Merge(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (*domain.Commit, error) {
 	log := logger.FromContext(ctx)
 
+	baseCommit, code, tree, err := s.mergeContent(ctx, teamID, rootCommit, commitID1, commitID2)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create merge commit
+	commit, err := s.storage.MergeCommits(ctx, teamID, rootCommit, commitID1, commitID2, code, tree)
+	if err != nil {
+		log.Error(ctx, "failed to create merge commit", zap.Error(err))
+		return nil, err
+	}
+
+	log.Info(ctx, "merge commit created",
+		zap.String("commit_id", commit.ID.String()),
+		zap.String("parent1", commitID1.String()),
+		zap.String("parent2", commitID2.String()),
+		zap.String("base", baseCommit.String()),
+	)
+
+	return commit, nil
+}
+
+// CheckMerge runs the checks and the three-way merge of Merge without creating the merge commit,
+// and returns the merge base the commits would be merged against
+func (s *Service) CheckMerge(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (uuid.UUID, error) {
+	baseCommit, _, _, err := s.mergeContent(ctx, teamID, rootCommit, commitID1, commitID2)
+	return baseCommit, err
+}
+
+// mergeContent checks that two leaf commits of the repository can be merged and merges their content
+// three-way against their merge base, which it returns along with the merged code or tree
+func (s *Service) mergeContent(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (uuid.UUID, []byte, domain.Tree, error) {
+	log := logger.FromContext(ctx)
+
 	// Check if team exists
 	exists, err := s.storage.TeamExists(ctx, teamID)
 	if err != nil {
 		log.Error(ctx, "failed to check team existence", zap.Error(err))
-		return nil, err
+		return uuid.Nil, nil, nil, err
 	}
 	if !exists {
-		return nil, domain.ErrTeamNotFound
+		return uuid.Nil, nil, nil, domain.ErrTeamNotFound
 	}
 
 	// Check if root commit exists
 	rootExists, err := s.storage.RootCommitExists(ctx, teamID, rootCommit)
 	if err != nil {
 		log.Error(ctx, "failed to check root commit existence", zap.Error(err))
-		return nil, err
+		return uuid.Nil, nil, nil, err
 	}
 	if !rootExists {
-		return nil, domain.ErrRootCommitNotFound
+		return uuid.Nil, nil, nil, domain.ErrRootCommitNotFound
 	}
 
 	// Check if both commits exist
-	_, err = s.storage.GetCommit(ctx, teamID, rootCommit, commitID1)
+	commit1, err := s.storage.GetCommit(ctx, teamID, rootCommit, commitID1)
 	if err != nil {
 		if errors.Is(err, domain.ErrCommitNotFound) {
-			return nil, domain.ErrCommitNotFound
+			return uuid.Nil, nil, nil, domain.ErrCommitNotFound
 		}
 		log.Error(ctx, "failed to get commit1", zap.Error(err))
-		return nil, err
+		return uuid.Nil, nil, nil, err
 	}
 
-	_, err = s.storage.GetCommit(ctx, teamID, rootCommit, commitID2)
+	commit2, err := s.storage.GetCommit(ctx, teamID, rootCommit, commitID2)
 	if err != nil {
 		if errors.Is(err, domain.ErrCommitNotFound) {
-			return nil, domain.ErrCommitNotFound
+			return uuid.Nil, nil, nil, domain.ErrCommitNotFound
 		}
 		log.Error(ctx, "failed to get commit2", zap.Error(err))
-		return nil, err
+		return uuid.Nil, nil, nil, err
+	}
+	if commit1.Tree != commit2.Tree {
+		return uuid.Nil, nil, nil, domain.ErrMixedContent
 	}
 
 	// Check if both commits are leaf commits
 	isLeaf1, err := s.storage.IsLeafCommit(ctx, teamID, rootCommit, commitID1)
 	if err != nil {
 		log.Error(ctx, "failed to check if commit1 is leaf", zap.Error(err))
-		return nil, err
+		return uuid.Nil, nil, nil, err
 	}
 	if !isLeaf1 {
-		return nil, domain.ErrCommitNotLeaf
+		return uuid.Nil, nil, nil, domain.ErrCommitNotLeaf
 	}
 
 	isLeaf2, err := s.storage.IsLeafCommit(ctx, teamID, rootCommit, commitID2)
 	if err != nil {
 		log.Error(ctx, "failed to check if commit2 is leaf", zap.Error(err))
-		return nil, err
+		return uuid.Nil, nil, nil, err
 	}
 	if !isLeaf2 {
-		return nil, domain.ErrCommitNotLeaf
+		return uuid.Nil, nil, nil, domain.ErrCommitNotLeaf
 	}
 
-	// Create merge commit
-	commit, err := s.storage.MergeCommits(ctx, teamID, rootCommit, commitID1, commitID2)
+	// Three-way merge of both commits against their nearest common ancestor
+	baseCommit, err := s.storage.MergeBase(ctx, teamID, rootCommit, commitID1, commitID2)
 	if err != nil {
-		log.Error(ctx, "failed to create merge commit", zap.Error(err))
-		return nil, err
+		log.Error(ctx, "failed to find merge base", zap.Error(err))
+		return uuid.Nil, nil, nil, err
+	}
+	var code []byte
+	var tree domain.Tree
+	var conflicts []domain.ConflictHunk
+	if commit1.Tree {
+		var trees [3]domain.Tree
+		if trees[0], err = s.baseTree(ctx, teamID, rootCommit, baseCommit); err != nil {
+			log.Error(ctx, "failed to get base tree to merge", zap.Error(err))
+			return uuid.Nil, nil, nil, err
+		}
+		for i, commitID := range []uuid.UUID{commitID1, commitID2} {
+			if trees[i+1], err = s.storage.GetCommitTree(ctx, teamID, rootCommit, commitID); err != nil {
+				log.Error(ctx, "failed to get tree to merge", zap.Error(err), zap.String("commit_id", commitID.String()))
+				return uuid.Nil, nil, nil, err
+			}
+		}
+		tree, conflicts = mergeTrees(trees[0], trees[1], trees[2])
+	} else {
+		var codes [3][]byte
+		for i, commitID := range []uuid.UUID{baseCommit, commitID1, commitID2} {
+			if codes[i], err = s.storage.GetCommitCode(ctx, teamID, rootCommit, commitID); err != nil {
+				log.Error(ctx, "failed to get code to merge", zap.Error(err), zap.String("commit_id", commitID.String()))
+				return uuid.Nil, nil, nil, err
+			}
+		}
+		code, conflicts = threeWayMerge(codes[0], codes[1], codes[2])
 	}
-
-	log.Info(ctx, "merge commit created",
-		zap.String("commit_id", commit.ID.String()),
-		zap.String("parent1", commitID1.String()),
-		zap.String("parent2", commitID2.String()),
-	)
-
-	return commit, nil
+	if len(conflicts) > 0 {
+		log.Info(ctx, "merge conflict",
+			zap.String("base", baseCommit.String()),
+			zap.Int("conflicts", len(conflicts)),
+		)
+		return uuid.Nil, nil, nil, &domain.MergeConflictError{BaseCommit: baseCommit, Conflicts: conflicts}
+	}
+	return baseCommit, code, tree, nil
 }
 
 // GetCommitName retrieves the name of a commit
@@ -2,7 +2,9 @@ package postgres
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"fmt"
 	"time"
 
@@ -45,6 +47,11 @@ func NewPostgresStorage(ctx context.Context, host, port, user, password, dbname,
 	return &Storage{db: db}, nil
 }
 
+// DB exposes the connection pool to readiness checks.
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
+
 func (s *Storage) Close(ctx context.Context) error {
 	log := logger.FromContext(ctx)
 	if err := s.db.Close(); err != nil {
@@ -66,49 +73,90 @@ func (s *Storage) TeamExists(ctx context.Context, teamID uuid.UUID) (bool, error
 	return exists, nil
 }
 
-// InitRepository creates a root commit for a new repository
-func (s *Storage) InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte) (*domain.Commit, error) {
+// InitRepository creates a root commit for a new repository; a non-nil tree makes it a tree commit
+func (s *Storage) InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte, tree domain.Tree) (*domain.Commit, error) {
 	commitID := uuid.New()
-	now := time.Now()
+	commit, err := s.insertCommit(ctx, commitID, teamID, commitID, pq.StringArray{}, code, tree)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create root commit: %w", err)
+	}
+
+	// Save commit name if provided
+	if commitName != "" {
+		if err := s.SetCommitName(ctx, teamID, commit.RootCommit, commit.ID, commitName); err != nil {
+			return nil, fmt.Errorf("failed to set commit name: %w", err)
+		}
+		commit.CommitName = &commitName
+	}
+
+	return commit, nil
+}
+
+// insertCommit inserts a commit and, for a tree commit, its files and manifest, in one transaction
+func (s *Storage) insertCommit(ctx context.Context, commitID, teamID, rootCommit uuid.UUID, parentIDs pq.StringArray, code []byte, tree domain.Tree) (*domain.Commit, error) {
+	isTree := tree != nil
+	if isTree {
+		code = []byte{}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
 
 	query := `
-		INSERT INTO commits (id, team_id, root_commit, parent_commit_ids, code, created_at)
-		VALUES ($1, $2, $1, $3, $4, $5)
-		RETURNING id, team_id, root_commit, parent_commit_ids, created_at
+		INSERT INTO commits (id, team_id, root_commit, parent_commit_ids, code, is_tree, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, team_id, root_commit, parent_commit_ids, is_tree, created_at
 	`
 
 	var commit domain.Commit
-	var parentIDs pq.StringArray
+	var returnedParentIDs pq.StringArray
 
-	err := s.db.QueryRowContext(ctx, query, commitID, teamID, pq.StringArray{}, code, now).Scan(
+	err = tx.QueryRowContext(ctx, query, commitID, teamID, rootCommit, parentIDs, code, isTree, time.Now()).Scan(
 		&commit.ID,
 		&commit.TeamID,
 		&commit.RootCommit,
-		&parentIDs,
+		&returnedParentIDs,
+		&commit.Tree,
 		&commit.CreatedAt,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create root commit: %w", err)
+		return nil, err
 	}
 
-	commit.ParentCommitIDs = stringArrayToUUIDs(parentIDs)
-	commit.Code = code
-
-	// Save commit name if provided
-	if commitName != "" {
-		if err := s.SetCommitName(ctx, teamID, commit.RootCommit, commit.ID, commitName); err != nil {
-			return nil, fmt.Errorf("failed to set commit name: %w", err)
+	for path, content := range tree {
+		sum := sha256.Sum256(content)
+		hash := hex.EncodeToString(sum[:])
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO blobs (hash, content) VALUES ($1, $2) ON CONFLICT (hash) DO NOTHING`,
+			hash, content,
+		); err != nil {
+			return nil, fmt.Errorf("failed to save blob: %w", err)
 		}
-		commit.CommitName = &commitName
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO tree_entries (commit_id, path, blob_hash) VALUES ($1, $2, $3)`,
+			commitID, path, hash,
+		); err != nil {
+			return nil, fmt.Errorf("failed to save tree entry: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
+	commit.ParentCommitIDs = stringArrayToUUIDs(returnedParentIDs)
+	commit.Code = code
+
 	return &commit, nil
 }
 
 // GetCommit retrieves a commit by its identifiers
 func (s *Storage) GetCommit(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (*domain.Commit, error) {
 	query := `
-		SELECT c.id, c.team_id, c.root_commit, c.parent_commit_ids, c.created_at, cn.name
+		SELECT c.id, c.team_id, c.root_commit, c.parent_commit_ids, c.is_tree, c.created_at, cn.name
 		FROM commits c
 		LEFT JOIN commit_names cn ON c.id = cn.commit_id
 		WHERE c.id = $1 AND c.team_id = $2 AND c.root_commit = $3
@@ -123,6 +171,7 @@ func (s *Storage) GetCommit(ctx context.Context, teamID, rootCommit, commitID uu
 		&commit.TeamID,
 		&commit.RootCommit,
 		&parentIDs,
+		&commit.Tree,
 		&commit.CreatedAt,
 		&name,
 	)
@@ -160,35 +209,13 @@ func (s *Storage) GetCommitCode(ctx context.Context, teamID, rootCommit, commitI
 	return code, nil
 }
 
-// CreateCommit creates a new commit with a parent
-func (s *Storage) CreateCommit(ctx context.Context, teamID, rootCommit, parentID uuid.UUID, commitName string, code []byte) (*domain.Commit, error) {
-	commitID := uuid.New()
-	now := time.Now()
-	parentIDs := pq.StringArray{parentID.String()}
-
-	query := `
-		INSERT INTO commits (id, team_id, root_commit, parent_commit_ids, code, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, team_id, root_commit, parent_commit_ids, created_at
-	`
-
-	var commit domain.Commit
-	var returnedParentIDs pq.StringArray
-
-	err := s.db.QueryRowContext(ctx, query, commitID, teamID, rootCommit, parentIDs, code, now).Scan(
-		&commit.ID,
-		&commit.TeamID,
-		&commit.RootCommit,
-		&returnedParentIDs,
-		&commit.CreatedAt,
-	)
+// CreateCommit creates a new commit with a parent; a non-nil tree makes it a tree commit
+func (s *Storage) CreateCommit(ctx context.Context, teamID, rootCommit, parentID uuid.UUID, commitName string, code []byte, tree domain.Tree) (*domain.Commit, error) {
+	commit, err := s.insertCommit(ctx, uuid.New(), teamID, rootCommit, pq.StringArray{parentID.String()}, code, tree)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create commit: %w", err)
 	}
 
-	commit.ParentCommitIDs = stringArrayToUUIDs(returnedParentIDs)
-	commit.Code = code
-
 	// Save commit name if provided
 	if commitName != "" {
 		if err := s.SetCommitName(ctx, teamID, rootCommit, commit.ID, commitName); err != nil {
@@ -197,45 +224,72 @@ func (s *Storage) CreateCommit(ctx context.Context, teamID, rootCommit, parentID
 		commit.CommitName = &commitName
 	}
 
-	return &commit, nil
+	return commit, nil
 }
 
-// MergeCommits creates a merge commit from two parent commits
-func (s *Storage) MergeCommits(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (*domain.Commit, error) {
-	// Get code from the first commit (simplified merge - just use first commit's code)
-	code, err := s.GetCommitCode(ctx, teamID, rootCommit, commitID1)
+// MergeCommits creates a merge commit of two parent commits with their merged code or tree
+func (s *Storage) MergeCommits(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID, code []byte, tree domain.Tree) (*domain.Commit, error) {
+	parentIDs := pq.StringArray{commitID1.String(), commitID2.String()}
+	commit, err := s.insertCommit(ctx, uuid.New(), teamID, rootCommit, parentIDs, code, tree)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to create merge commit: %w", err)
 	}
 
-	commitID := uuid.New()
-	now := time.Now()
-	parentIDs := pq.StringArray{commitID1.String(), commitID2.String()}
+	return commit, nil
+}
 
+// GetCommitTree retrieves all files of a tree commit
+func (s *Storage) GetCommitTree(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (domain.Tree, error) {
 	query := `
-		INSERT INTO commits (id, team_id, root_commit, parent_commit_ids, code, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, team_id, root_commit, parent_commit_ids, created_at
+		SELECT te.path, b.content
+		FROM tree_entries te
+		JOIN commits c ON c.id = te.commit_id
+		JOIN blobs b ON b.hash = te.blob_hash
+		WHERE c.id = $1 AND c.team_id = $2 AND c.root_commit = $3
 	`
 
-	var commit domain.Commit
-	var returnedParentIDs pq.StringArray
-
-	err = s.db.QueryRowContext(ctx, query, commitID, teamID, rootCommit, parentIDs, code, now).Scan(
-		&commit.ID,
-		&commit.TeamID,
-		&commit.RootCommit,
-		&returnedParentIDs,
-		&commit.CreatedAt,
-	)
+	rows, err := s.db.QueryContext(ctx, query, commitID, teamID, rootCommit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create merge commit: %w", err)
+		return nil, fmt.Errorf("failed to get commit tree: %w", err)
 	}
+	defer rows.Close()
 
-	commit.ParentCommitIDs = stringArrayToUUIDs(returnedParentIDs)
-	commit.Code = code
+	tree := domain.Tree{}
+	for rows.Next() {
+		var path string
+		var content []byte
+		if err := rows.Scan(&path, &content); err != nil {
+			return nil, fmt.Errorf("failed to scan tree entry: %w", err)
+		}
+		tree[path] = content
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get commit tree: %w", err)
+	}
 
-	return &commit, nil
+	return tree, nil
+}
+
+// GetTreeFile retrieves a single file of a tree commit
+func (s *Storage) GetTreeFile(ctx context.Context, teamID, rootCommit, commitID uuid.UUID, path string) ([]byte, error) {
+	query := `
+		SELECT b.content
+		FROM tree_entries te
+		JOIN commits c ON c.id = te.commit_id
+		JOIN blobs b ON b.hash = te.blob_hash
+		WHERE c.id = $1 AND c.team_id = $2 AND c.root_commit = $3 AND te.path = $4
+	`
+
+	var content []byte
+	err := s.db.QueryRowContext(ctx, query, commitID, teamID, rootCommit, path).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrFileNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tree file: %w", err)
+	}
+
+	return content, nil
 }
 
 // IsLeafCommit checks if a commit has no children
@@ -277,7 +331,7 @@ func (s *Storage) RootCommitExists(ctx context.Context, teamID, rootCommit uuid.
 // ListCommits returns all commits for a repository (by team_id and root_commit)
 func (s *Storage) ListCommits(ctx context.Context, teamID, rootCommit uuid.UUID) ([]*domain.Commit, error) {
 	query := `
-		SELECT c.id, c.team_id, c.root_commit, c.parent_commit_ids, c.created_at, cn.name
+		SELECT c.id, c.team_id, c.root_commit, c.parent_commit_ids, c.is_tree, c.created_at, cn.name
 		FROM commits c
 		LEFT JOIN commit_names cn ON c.id = cn.commit_id AND c.team_id = cn.team_id AND c.root_commit = cn.root_commit
 		WHERE c.team_id = $1 AND c.root_commit = $2
@@ -296,7 +350,7 @@ func (s *Storage) ListCommits(ctx context.Context, teamID, rootCommit uuid.UUID)
 		var parentIDs pq.StringArray
 		var name sql.NullString
 
-		if err := rows.Scan(&commit.ID, &commit.TeamID, &commit.RootCommit, &parentIDs, &commit.CreatedAt, &name); err != nil {
+		if err := rows.Scan(&commit.ID, &commit.TeamID, &commit.RootCommit, &parentIDs, &commit.Tree, &commit.CreatedAt, &name); err != nil {
 			return nil, fmt.Errorf("failed to scan commit: %w", err)
 		}
 
@@ -379,6 +433,78 @@ func (s *Storage) GetRootCommitByRepoName(ctx context.Context, teamID uuid.UUID,
 	return rootCommitID, nil
 }
 
+// ancestorsCTE is a recursive CTE named name listing the ancestors of the commit in parameter param
+// (the commit included) over parent_commit_ids; $1 and $2 are the team and root commit
+func ancestorsCTE(name, param string) string {
+	return fmt.Sprintf(`%[1]s(id) AS (
+			SELECT %[2]s::uuid
+			UNION
+			SELECT unnest(c.parent_commit_ids)::uuid
+			FROM commits c JOIN %[1]s a ON c.id = a.id
+			WHERE c.team_id = $1 AND c.root_commit = $2
+		)`, name, param)
+}
+
+// MergeBase returns the lowest common ancestor of two commits. Of all common ancestors it is the
+// most recent one: commits are always created after their parents, so no other common ancestor descends from it.
+func (s *Storage) MergeBase(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (uuid.UUID, error) {
+	query := `
+		WITH RECURSIVE ` + ancestorsCTE("ancestors1", "$3") + `, ` + ancestorsCTE("ancestors2", "$4") + `
+		SELECT c.id FROM commits c
+		JOIN ancestors1 a1 ON c.id = a1.id
+		JOIN ancestors2 a2 ON c.id = a2.id
+		WHERE c.team_id = $1 AND c.root_commit = $2
+		ORDER BY c.created_at DESC, c.id
+		LIMIT 1
+	`
+
+	var mergeBase uuid.UUID
+	err := s.db.QueryRowContext(ctx, query, teamID, rootCommit, commitID1, commitID2).Scan(&mergeBase)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, domain.ErrCommitNotFound
+	}
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to find merge base: %w", err)
+	}
+
+	return mergeBase, nil
+}
+
+// IsAncestor checks if ancestorID is reachable from commitID through parent commits (or is commitID itself)
+func (s *Storage) IsAncestor(ctx context.Context, teamID, rootCommit, ancestorID, commitID uuid.UUID) (bool, error) {
+	query := `
+		WITH RECURSIVE ` + ancestorsCTE("ancestors", "$4") + `
+		SELECT EXISTS(SELECT 1 FROM ancestors WHERE id = $3)
+	`
+
+	var isAncestor bool
+	err := s.db.QueryRowContext(ctx, query, teamID, rootCommit, ancestorID, commitID).Scan(&isAncestor)
+	if err != nil {
+		return false, fmt.Errorf("failed to check ancestry: %w", err)
+	}
+
+	return isAncestor, nil
+}
+
+// CountAheadBehind counts the commits reachable from commitID1 but not commitID2 (ahead)
+// and from commitID2 but not commitID1 (behind)
+func (s *Storage) CountAheadBehind(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (int, int, error) {
+	query := `
+		WITH RECURSIVE ` + ancestorsCTE("ancestors1", "$3") + `, ` + ancestorsCTE("ancestors2", "$4") + `
+		SELECT
+			(SELECT COUNT(*) FROM (SELECT id FROM ancestors1 EXCEPT SELECT id FROM ancestors2) ahead),
+			(SELECT COUNT(*) FROM (SELECT id FROM ancestors2 EXCEPT SELECT id FROM ancestors1) behind)
+	`
+
+	var ahead, behind int
+	err := s.db.QueryRowContext(ctx, query, teamID, rootCommit, commitID1, commitID2).Scan(&ahead, &behind)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count ahead/behind commits: %w", err)
+	}
+
+	return ahead, behind, nil
+}
+
 // Helper function to convert string array to UUID slice
 func stringArrayToUUIDs(arr pq.StringArray) []uuid.UUID {
 	result := make([]uuid.UUID, 0, len(arr))
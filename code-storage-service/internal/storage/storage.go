@@ -13,15 +13,22 @@ type Storage interface {
 	TeamExists(ctx context.Context, teamID uuid.UUID) (bool, error)
 
 	// Repository/Commit operations
-	InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte) (*domain.Commit, error)
+	InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte, tree domain.Tree) (*domain.Commit, error)
 	GetCommit(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (*domain.Commit, error)
 	GetCommitCode(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) ([]byte, error)
-	CreateCommit(ctx context.Context, teamID, rootCommit, parentID uuid.UUID, commitName string, code []byte) (*domain.Commit, error)
-	MergeCommits(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (*domain.Commit, error)
+	CreateCommit(ctx context.Context, teamID, rootCommit, parentID uuid.UUID, commitName string, code []byte, tree domain.Tree) (*domain.Commit, error)
+	MergeCommits(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID, code []byte, tree domain.Tree) (*domain.Commit, error)
+	GetCommitTree(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (domain.Tree, error)
+	GetTreeFile(ctx context.Context, teamID, rootCommit, commitID uuid.UUID, path string) ([]byte, error)
 	IsLeafCommit(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (bool, error)
 	RootCommitExists(ctx context.Context, teamID, rootCommit uuid.UUID) (bool, error)
 	ListCommits(ctx context.Context, teamID, rootCommit uuid.UUID) ([]*domain.Commit, error)
 
+	// Ancestry operations
+	MergeBase(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (uuid.UUID, error)
+	IsAncestor(ctx context.Context, teamID, rootCommit, ancestorID, commitID uuid.UUID) (bool, error)
+	CountAheadBehind(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (int, int, error)
+
 	// Commit name operations
 	GetCommitName(ctx context.Context, commitID uuid.UUID) (string, error)
 	GetCommitIDByName(ctx context.Context, teamID, rootCommit uuid.UUID, name string) (uuid.UUID, error)
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/Meldy183/code-storage-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/tracing"
+	"github.com/google/uuid"
+)
+
+// tracedStorage opens a span around every call of the wrapped storage.
+type tracedStorage struct {
+	next Storage
+}
+
+// WithTracing wraps a Storage so each call is traced as "storage.<Method>".
+func WithTracing(next Storage) Storage {
+	return &tracedStorage{next: next}
+}
+
+func (s *tracedStorage) TeamExists(ctx context.Context, teamID uuid.UUID) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.TeamExists")
+	defer func() { tracing.End(span, err) }()
+	return s.next.TeamExists(ctx, teamID)
+}
+
+func (s *tracedStorage) InitRepository(ctx context.Context, teamID uuid.UUID, commitName string, code []byte, tree domain.Tree) (_ *domain.Commit, err error) {
+	ctx, span := tracing.Start(ctx, "storage.InitRepository")
+	defer func() { tracing.End(span, err) }()
+	return s.next.InitRepository(ctx, teamID, commitName, code, tree)
+}
+
+func (s *tracedStorage) GetCommit(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (_ *domain.Commit, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetCommit")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetCommit(ctx, teamID, rootCommit, commitID)
+}
+
+func (s *tracedStorage) GetCommitCode(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (_ []byte, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetCommitCode")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetCommitCode(ctx, teamID, rootCommit, commitID)
+}
+
+func (s *tracedStorage) CreateCommit(ctx context.Context, teamID, rootCommit, parentID uuid.UUID, commitName string, code []byte, tree domain.Tree) (_ *domain.Commit, err error) {
+	ctx, span := tracing.Start(ctx, "storage.CreateCommit")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateCommit(ctx, teamID, rootCommit, parentID, commitName, code, tree)
+}
+
+func (s *tracedStorage) MergeCommits(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID, code []byte, tree domain.Tree) (_ *domain.Commit, err error) {
+	ctx, span := tracing.Start(ctx, "storage.MergeCommits")
+	defer func() { tracing.End(span, err) }()
+	return s.next.MergeCommits(ctx, teamID, rootCommit, commitID1, commitID2, code, tree)
+}
+
+func (s *tracedStorage) GetCommitTree(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (_ domain.Tree, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetCommitTree")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetCommitTree(ctx, teamID, rootCommit, commitID)
+}
+
+func (s *tracedStorage) GetTreeFile(ctx context.Context, teamID, rootCommit, commitID uuid.UUID, path string) (_ []byte, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTreeFile")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTreeFile(ctx, teamID, rootCommit, commitID, path)
+}
+
+func (s *tracedStorage) IsLeafCommit(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.IsLeafCommit")
+	defer func() { tracing.End(span, err) }()
+	return s.next.IsLeafCommit(ctx, teamID, rootCommit, commitID)
+}
+
+func (s *tracedStorage) RootCommitExists(ctx context.Context, teamID, rootCommit uuid.UUID) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.RootCommitExists")
+	defer func() { tracing.End(span, err) }()
+	return s.next.RootCommitExists(ctx, teamID, rootCommit)
+}
+
+func (s *tracedStorage) ListCommits(ctx context.Context, teamID, rootCommit uuid.UUID) (_ []*domain.Commit, err error) {
+	ctx, span := tracing.Start(ctx, "storage.ListCommits")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ListCommits(ctx, teamID, rootCommit)
+}
+
+func (s *tracedStorage) MergeBase(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (_ uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "storage.MergeBase")
+	defer func() { tracing.End(span, err) }()
+	return s.next.MergeBase(ctx, teamID, rootCommit, commitID1, commitID2)
+}
+
+func (s *tracedStorage) IsAncestor(ctx context.Context, teamID, rootCommit, ancestorID, commitID uuid.UUID) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.IsAncestor")
+	defer func() { tracing.End(span, err) }()
+	return s.next.IsAncestor(ctx, teamID, rootCommit, ancestorID, commitID)
+}
+
+func (s *tracedStorage) CountAheadBehind(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (_ int, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.CountAheadBehind")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CountAheadBehind(ctx, teamID, rootCommit, commitID1, commitID2)
+}
+
+func (s *tracedStorage) GetCommitName(ctx context.Context, commitID uuid.UUID) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetCommitName")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetCommitName(ctx, commitID)
+}
+
+func (s *tracedStorage) GetCommitIDByName(ctx context.Context, teamID, rootCommit uuid.UUID, name string) (_ uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetCommitIDByName")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetCommitIDByName(ctx, teamID, rootCommit, name)
+}
+
+func (s *tracedStorage) SetCommitName(ctx context.Context, teamID, rootCommit, commitID uuid.UUID, name string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetCommitName")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetCommitName(ctx, teamID, rootCommit, commitID, name)
+}
+
+func (s *tracedStorage) GetRootCommitByRepoName(ctx context.Context, teamID uuid.UUID, repoName string) (_ uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetRootCommitByRepoName")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetRootCommitByRepoName(ctx, teamID, repoName)
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/Meldy183/code-storage-service/internal/domain"
 	"github.com/Meldy183/code-storage-service/internal/service"
 	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/problem"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
@@ -16,6 +19,9 @@ import (
 
 const maxUploadSize = 100 << 20 // 100MB
 
+// maxDiffContext is the most unchanged lines a diff may ask for around each change
+const maxDiffContext = 1000
+
 // Handler handles HTTP requests for the storage service
 type Handler struct {
 	service *service.Service
@@ -42,6 +48,10 @@ func (h *Handler) RegisterRoutes(router *mux.Router, log logger.Logger) {
 	router.HandleFunc("/storage/rootCommit", h.GetRootCommitByRepoName).Methods(http.MethodGet)
 	router.HandleFunc("/storage/commitName/{commit_id}", h.GetCommitName).Methods(http.MethodGet)
 	router.HandleFunc("/storage/commitID", h.GetCommitIDByName).Methods(http.MethodGet)
+	router.HandleFunc("/storage/mergeBase", h.MergeBase).Methods(http.MethodGet)
+	router.HandleFunc("/storage/isAncestor", h.IsAncestor).Methods(http.MethodGet)
+	router.HandleFunc("/storage/aheadBehind", h.AheadBehind).Methods(http.MethodGet)
+	router.HandleFunc("/storage/diff", h.Diff).Methods(http.MethodGet)
 }
 
 // LoggingMiddleware adds logging and request ID to each request
@@ -76,7 +86,7 @@ func (h *Handler) InitRepository(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form
 	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
 		log.Error(ctx, "failed to parse multipart form", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "failed to parse form data")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "failed to parse form data")
 		return
 	}
 
@@ -84,18 +94,24 @@ func (h *Handler) InitRepository(w http.ResponseWriter, r *http.Request) {
 	teamIDStr := r.FormValue("team_id")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
 		return
 	}
 
 	// Get commit_name (optional but recommended)
 	commitName := r.FormValue("commit_name")
 
+	format, ok := uploadFormat(r.FormValue("format"))
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "format must be file, tar or zip")
+		return
+	}
+
 	// Get code file
 	file, _, err := r.FormFile("code")
 	if err != nil {
 		log.Error(ctx, "failed to get code file", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "code file is required")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "code file is required")
 		return
 	}
 	defer file.Close()
@@ -103,14 +119,14 @@ func (h *Handler) InitRepository(w http.ResponseWriter, r *http.Request) {
 	code, err := io.ReadAll(file)
 	if err != nil {
 		log.Error(ctx, "failed to read code file", zap.Error(err))
-		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to read code file")
+		h.respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to read code file")
 		return
 	}
 
 	// Initialize repository
-	commit, err := h.service.InitRepository(ctx, teamID, commitName, code)
+	commit, err := h.service.InitRepository(ctx, teamID, commitName, code, format)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -125,7 +141,7 @@ func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form
 	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
 		log.Error(ctx, "failed to parse multipart form", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "failed to parse form data")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "failed to parse form data")
 		return
 	}
 
@@ -133,7 +149,7 @@ func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
 	teamIDStr := r.FormValue("team_id")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
 		return
 	}
 
@@ -141,7 +157,7 @@ func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
 	rootCommitStr := r.FormValue("root_commit")
 	rootCommit, err := uuid.Parse(rootCommitStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
 		return
 	}
 
@@ -149,18 +165,24 @@ func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
 	parentCommitIDStr := r.FormValue("commit_id")
 	parentCommitID, err := uuid.Parse(parentCommitIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid commit_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid commit_id format")
 		return
 	}
 
 	// Get commit_name (optional but recommended)
 	commitName := r.FormValue("commit_name")
 
+	format, ok := uploadFormat(r.FormValue("format"))
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "format must be file, tar or zip")
+		return
+	}
+
 	// Get code file
 	file, _, err := r.FormFile("code")
 	if err != nil {
 		log.Error(ctx, "failed to get code file", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "code file is required")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "code file is required")
 		return
 	}
 	defer file.Close()
@@ -168,14 +190,14 @@ func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
 	code, err := io.ReadAll(file)
 	if err != nil {
 		log.Error(ctx, "failed to read code file", zap.Error(err))
-		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to read code file")
+		h.respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to read code file")
 		return
 	}
 
 	// Create commit
-	commit, err := h.service.Push(ctx, teamID, rootCommit, parentCommitID, commitName, code)
+	commit, err := h.service.Push(ctx, teamID, rootCommit, parentCommitID, commitName, code, format)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -190,36 +212,60 @@ func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
 	teamIDStr := r.URL.Query().Get("team_id")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
 		return
 	}
 
 	rootCommitStr := r.URL.Query().Get("root_commit")
 	rootCommit, err := uuid.Parse(rootCommitStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
 		return
 	}
 
 	commitIDStr := r.URL.Query().Get("commit_id")
 	commitID, err := uuid.Parse(commitIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid commit_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid commit_id format")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != domain.ArchiveTar && format != domain.ArchiveZip {
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "format must be tar or zip")
 		return
 	}
 
 	// Get code
-	code, err := h.service.Checkout(ctx, teamID, rootCommit, commitID)
+	checkout, err := h.service.Checkout(ctx, teamID, rootCommit, commitID, r.URL.Query().Get("path"), format)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
-	// Return ZIP file
-	w.Header().Set("Content-Type", "application/zip")
-	w.Header().Set("Content-Disposition", "attachment; filename=code.zip")
+	// Return the archive, or the single file
+	contentType := "application/octet-stream"
+	switch checkout.Archive {
+	case domain.ArchiveZip:
+		contentType = "application/zip"
+	case domain.ArchiveTar:
+		contentType = "application/x-tar"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": checkout.Filename}))
 	w.WriteHeader(http.StatusOK)
-	w.Write(code)
+	w.Write(checkout.Content)
+}
+
+// uploadFormat maps the format form field to an archive format, or "" for single-file code
+func uploadFormat(field string) (string, bool) {
+	switch field {
+	case "", "file":
+		return "", true
+	case domain.ArchiveTar, domain.ArchiveZip:
+		return field, true
+	}
+	return "", false
 }
 
 // Merge handles POST /storage/merge
@@ -230,14 +276,28 @@ func (h *Handler) Merge(w http.ResponseWriter, r *http.Request) {
 	var req domain.MergeRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.DryRun {
+		mergeBase, err := h.service.CheckMerge(ctx, req.TeamID, req.RootCommit, req.CommitID1, req.CommitID2)
+		if err != nil {
+			h.handleServiceError(w, r, err)
+			return
+		}
+		h.respondJSON(w, http.StatusOK, domain.MergeCheckResponse{
+			CommitID1: req.CommitID1,
+			CommitID2: req.CommitID2,
+			MergeBase: mergeBase,
+		})
 		return
 	}
 
 	// Merge commits
 	commit, err := h.service.Merge(ctx, req.TeamID, req.RootCommit, req.CommitID1, req.CommitID2)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -251,20 +311,20 @@ func (h *Handler) ListCommits(w http.ResponseWriter, r *http.Request) {
 	teamIDStr := r.URL.Query().Get("team_id")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
 		return
 	}
 
 	rootCommitStr := r.URL.Query().Get("root_commit")
 	rootCommit, err := uuid.Parse(rootCommitStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
 		return
 	}
 
 	commits, err := h.service.ListCommits(ctx, teamID, rootCommit)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -285,13 +345,13 @@ func (h *Handler) GetCommitName(w http.ResponseWriter, r *http.Request) {
 	commitIDStr := vars["commit_id"]
 	commitID, err := uuid.Parse(commitIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid commit_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid commit_id format")
 		return
 	}
 
 	name, err := h.service.GetCommitName(ctx, commitID)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -308,19 +368,19 @@ func (h *Handler) GetRootCommitByRepoName(w http.ResponseWriter, r *http.Request
 	teamIDStr := r.URL.Query().Get("team_id")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
 		return
 	}
 
 	repoName := r.URL.Query().Get("repo_name")
 	if repoName == "" {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "repo_name is required")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "repo_name is required")
 		return
 	}
 
 	rootCommit, err := h.service.GetRootCommitByRepoName(ctx, teamID, repoName)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -338,32 +398,136 @@ func (h *Handler) GetCommitIDByName(w http.ResponseWriter, r *http.Request) {
 	teamIDStr := r.URL.Query().Get("team_id")
 	teamID, err := uuid.Parse(teamIDStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid team_id format")
 		return
 	}
 
 	rootCommitStr := r.URL.Query().Get("root_commit")
 	rootCommit, err := uuid.Parse(rootCommitStr)
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid root_commit format")
 		return
 	}
 
 	name := r.URL.Query().Get("name")
 	if name == "" {
-		h.respondError(w, http.StatusBadRequest, "INVALID_REQUEST", "name is required")
+		h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "name is required")
 		return
 	}
 
 	commitID, err := h.service.GetCommitIDByName(ctx, teamID, rootCommit, name)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusOK, domain.CommitIDResponse{CommitID: commitID})
 }
 
+// MergeBase handles GET /storage/mergeBase?team_id=...&root_commit=...&commit_id1=...&commit_id2=...
+func (h *Handler) MergeBase(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ids, ok := h.queryUUIDs(w, r, "team_id", "root_commit", "commit_id1", "commit_id2")
+	if !ok {
+		return
+	}
+
+	mergeBase, err := h.service.MergeBase(ctx, ids[0], ids[1], ids[2], ids[3])
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, domain.MergeBaseResponse{
+		CommitID1: ids[2],
+		CommitID2: ids[3],
+		MergeBase: mergeBase,
+	})
+}
+
+// IsAncestor handles GET /storage/isAncestor?team_id=...&root_commit=...&ancestor_id=...&commit_id=...
+func (h *Handler) IsAncestor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ids, ok := h.queryUUIDs(w, r, "team_id", "root_commit", "ancestor_id", "commit_id")
+	if !ok {
+		return
+	}
+
+	isAncestor, err := h.service.IsAncestor(ctx, ids[0], ids[1], ids[2], ids[3])
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, domain.IsAncestorResponse{
+		AncestorID: ids[2],
+		CommitID:   ids[3],
+		IsAncestor: isAncestor,
+	})
+}
+
+// AheadBehind handles GET /storage/aheadBehind?team_id=...&root_commit=...&commit_id1=...&commit_id2=...
+func (h *Handler) AheadBehind(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ids, ok := h.queryUUIDs(w, r, "team_id", "root_commit", "commit_id1", "commit_id2")
+	if !ok {
+		return
+	}
+
+	counts, err := h.service.AheadBehind(ctx, ids[0], ids[1], ids[2], ids[3])
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, counts)
+}
+
+// Diff handles GET /storage/diff?team_id=...&root_commit=...&from_commit_id=...&to_commit_id=...&context=...
+func (h *Handler) Diff(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	ids, ok := h.queryUUIDs(w, r, "team_id", "root_commit", "from_commit_id", "to_commit_id")
+	if !ok {
+		return
+	}
+
+	contextLines := service.DefaultDiffContext
+	if raw := r.URL.Query().Get("context"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxDiffContext {
+			h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "context must be between 0 and 1000")
+			return
+		}
+		contextLines = n
+	}
+
+	diff, err := h.service.Diff(ctx, ids[0], ids[1], ids[2], ids[3], contextLines)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, diff)
+}
+
+// queryUUIDs parses the named UUID query parameters in order, responding 400 on the first invalid one
+func (h *Handler) queryUUIDs(w http.ResponseWriter, r *http.Request, names ...string) ([]uuid.UUID, bool) {
+	ids := make([]uuid.UUID, len(names))
+	for i, name := range names {
+		id, err := uuid.Parse(r.URL.Query().Get(name))
+		if err != nil {
+			h.respondError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "invalid "+name+" format")
+			return nil, false
+		}
+		ids[i] = id
+	}
+	return ids, true
+}
+
 // respondJSON sends a JSON response
 func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
@@ -373,33 +537,67 @@ func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{
 	}
 }
 
-// respondError sends an error response
-func (h *Handler) respondError(w http.ResponseWriter, status int, code, message string) {
-	h.respondJSON(w, status, domain.NewErrorResponse(code, message))
+// respondError sends an application/problem+json error, or the legacy ErrorResponse
+// to clients that accept only application/json
+func (h *Handler) respondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	_ = problem.Write(w, r, status, code, message, domain.NewErrorResponse(code, message))
+}
+
+// respondMergeConflict sends 409 MERGE_CONFLICT with the conflicting hunks, in both the problem document
+// and the legacy ErrorResponse
+func (h *Handler) respondMergeConflict(w http.ResponseWriter, r *http.Request, err error) {
+	var conflictErr *domain.MergeConflictError
+	var conflicts []domain.ConflictHunk
+	if errors.As(err, &conflictErr) {
+		conflicts = conflictErr.Conflicts
+	}
+
+	if problem.WantsLegacy(r) {
+		legacy := domain.NewErrorResponse(domain.ErrCodeMergeConflict, err.Error())
+		legacy.Error.Conflicts = conflicts
+		h.respondJSON(w, http.StatusConflict, legacy)
+		return
+	}
+	details := struct {
+		problem.Details
+		Conflicts []domain.ConflictHunk `json:"conflicts,omitempty"`
+	}{
+		Details:   problem.New(r, http.StatusConflict, domain.ErrCodeMergeConflict, err.Error()),
+		Conflicts: conflicts,
+	}
+	w.Header().Set("Content-Type", problem.ContentType)
+	w.WriteHeader(http.StatusConflict)
+	_ = json.NewEncoder(w).Encode(details)
 }
 
 // handleServiceError maps service errors to HTTP responses
-func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
 	code := domain.MapErrorToCode(err)
 
 	switch {
 	case errors.Is(err, domain.ErrTeamNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrRootCommitNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrCommitNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrInvalidParent):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrCommitNotLeaf):
-		h.respondError(w, http.StatusConflict, code, err.Error())
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
 	case errors.Is(err, domain.ErrMergeConflict):
-		h.respondError(w, http.StatusConflict, code, err.Error())
+		h.respondMergeConflict(w, r, err)
 	case errors.Is(err, domain.ErrRepositoryAlreadyInit):
-		h.respondError(w, http.StatusConflict, code, err.Error())
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
 	case errors.Is(err, domain.ErrCommitNameExists):
-		h.respondError(w, http.StatusConflict, code, err.Error())
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrFileNotFound):
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
+	case errors.Is(err, domain.ErrInvalidArchive):
+		h.respondError(w, r, http.StatusBadRequest, code, err.Error())
+	case errors.Is(err, domain.ErrMixedContent):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
 	default:
-		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
+		h.respondError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "internal server error")
 	}
 }
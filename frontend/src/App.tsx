@@ -1,4 +1,4 @@
-import { useState, ChangeEvent } from 'react';
+import { useState, useEffect, ChangeEvent } from 'react';
 import { Button } from '@/components/ui/button';
 import { Input } from '@/components/ui/input';
 import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card';
@@ -54,6 +54,23 @@ function App() {
   const [error, setError] = useState<string | null>(null);
   const [loading, setLoading] = useState(false);
 
+  // Refresh PR lists when one of the user's PRs changes
+  useEffect(() => {
+    if (!isLoggedIn || !profile?.team_name) return;
+    return api.subscribeEvents(username, async () => {
+      try {
+        const [my, reviews] = await Promise.all([
+          api.getMyPRs(username),
+          api.getReviewPRs(username),
+        ]);
+        setMyPRs(my);
+        setReviewPRs(reviews);
+      } catch {
+        // keep the current lists; the next event retries
+      }
+    });
+  }, [isLoggedIn, profile?.team_name, username]);
+
   // Login handler
   const handleLogin = async () => {
     if (!username.trim()) {
@@ -64,6 +64,19 @@ export interface CreatePRRequest {
   target_commit_name: string;
 }
 
+export interface PRActivity {
+  event_id: number;
+  kind: 'assigned' | 'approved' | 'rejected' | 'merged';
+  type: string;
+  pull_request_id: string;
+  pull_request_name: string;
+  author_id: string;
+  actor_id?: string;
+  reviewers: string[];
+  reason?: string;
+  createdAt: string;
+}
+
 // Helper to get headers
 function getHeaders(username: string): HeadersInit {
   return {
@@ -90,7 +103,7 @@ export async function createTeam(username: string, req: CreateTeamRequest): Prom
   });
   if (!res.ok) {
     const err = await res.json();
-    throw new Error(err.error?.message || 'Failed to create team');
+    throw new Error(err.detail || err.error?.message || 'Failed to create team');
   }
   const data = await res.json();
   return data.team;
@@ -125,7 +138,7 @@ export async function initRepository(
   });
   if (!res.ok) {
     const err = await res.json();
-    throw new Error(err.error?.message || 'Failed to init repository');
+    throw new Error(err.detail || err.error?.message || 'Failed to init repository');
   }
   const data = await res.json();
   return data.commit;
@@ -153,7 +166,7 @@ export async function pushCommit(
   });
   if (!res.ok) {
     const err = await res.json();
-    throw new Error(err.error?.message || 'Failed to push commit');
+    throw new Error(err.detail || err.error?.message || 'Failed to push commit');
   }
   const data = await res.json();
   return data.commit;
@@ -204,7 +217,7 @@ export async function createPR(username: string, req: CreatePRRequest): Promise<
   });
   if (!res.ok) {
     const err = await res.json();
-    throw new Error(err.error?.message || 'Failed to create PR');
+    throw new Error(err.detail || err.error?.message || 'Failed to create PR');
   }
   const data = await res.json();
   return data.pull_request;
@@ -242,7 +255,7 @@ export async function approvePR(
   });
   if (!res.ok) {
     const err = await res.json();
-    throw new Error(err.error?.message || 'Failed to approve PR');
+    throw new Error(err.detail || err.error?.message || 'Failed to approve PR');
   }
   return res.json();
 }
@@ -261,7 +274,7 @@ export async function rejectPR(
   });
   if (!res.ok) {
     const err = await res.json();
-    throw new Error(err.error?.message || 'Failed to reject PR');
+    throw new Error(err.detail || err.error?.message || 'Failed to reject PR');
   }
   const data = await res.json();
   return data.pull_request;
@@ -280,3 +293,53 @@ export async function getPRCode(
   return res.blob();
 }
 
+// Streams PR activity of the user over SSE. fetch is used instead of EventSource because the
+// gateway needs the X-Username header; on disconnect it reconnects with Last-Event-ID, so no
+// event is missed. Returns a function that closes the stream.
+export function subscribeEvents(username: string, onEvent: (event: PRActivity) => void): () => void {
+  const controller = new AbortController();
+  let lastEventId = '';
+  let retryMs = 3000;
+
+  const connect = async () => {
+    const headers: Record<string, string> = { 'X-Username': username, Accept: 'text/event-stream' };
+    if (lastEventId) headers['Last-Event-ID'] = lastEventId;
+    const res = await fetch(`${API_BASE}/events`, { headers, signal: controller.signal });
+    if (!res.ok || !res.body) throw new Error('Failed to open event stream');
+
+    const reader = res.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = '';
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) return;
+      buffer += value;
+      let end: number;
+      while ((end = buffer.indexOf('\n\n')) >= 0) {
+        const block = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+        let data = '';
+        for (const line of block.split('\n')) {
+          if (line.startsWith('id: ')) lastEventId = line.slice(4);
+          else if (line.startsWith('data: ')) data += line.slice(6);
+          else if (line.startsWith('retry: ')) retryMs = Number(line.slice(7)) || retryMs;
+        }
+        if (data) onEvent(JSON.parse(data));
+      }
+    }
+  };
+
+  const run = async () => {
+    while (!controller.signal.aborted) {
+      try {
+        await connect();
+      } catch {
+        // reconnect below unless closed
+      }
+      if (controller.signal.aborted) return;
+      await new Promise((resolve) => setTimeout(resolve, retryMs));
+    }
+  };
+  run();
+
+  return () => controller.abort();
+}
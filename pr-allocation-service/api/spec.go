@@ -0,0 +1,9 @@
+// Package api embeds the service's OpenAPI spec.
+package api
+
+import _ "embed"
+
+// Spec is the OpenAPI document the service validates its traffic against.
+//
+//go:embed openapi.yml
+var Spec []byte
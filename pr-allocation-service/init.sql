@@ -1,17 +1,31 @@
--- Create teams table with UUID
-CREATE TABLE IF NOT EXISTS teams (
+-- Create organizations table (tenants owning teams; requests without an organization use 'default')
+CREATE TABLE IF NOT EXISTS organizations (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
-    team_name VARCHAR(255) UNIQUE NOT NULL,
+    org_name VARCHAR(255) UNIQUE NOT NULL,
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
+INSERT INTO organizations (org_name) VALUES ('default') ON CONFLICT (org_name) DO NOTHING;
+
+-- Create teams table with UUID (team names are unique within an organization)
+CREATE TABLE IF NOT EXISTS teams (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    organization_id UUID NOT NULL REFERENCES organizations(id),
+    team_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (organization_id, team_name)
+);
+
 -- Create users table
 CREATE TABLE IF NOT EXISTS users (
     user_id VARCHAR(255) PRIMARY KEY,
     username VARCHAR(255) NOT NULL,
     team_id UUID REFERENCES teams(id),
     is_active BOOLEAN NOT NULL DEFAULT true,
+    role VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('member', 'maintainer', 'admin')),
+    skills TEXT[] NOT NULL DEFAULT '{}',
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
@@ -20,19 +34,86 @@ CREATE TABLE IF NOT EXISTS users (
 CREATE TABLE IF NOT EXISTS pull_requests (
     pull_request_id VARCHAR(255) PRIMARY KEY,
     pull_request_name VARCHAR(500) NOT NULL,
+    organization_id UUID NOT NULL REFERENCES organizations(id),
     author_id VARCHAR(255) NOT NULL REFERENCES users(user_id),
     status VARCHAR(50) NOT NULL DEFAULT 'OPEN',
     assigned_reviewers TEXT[] NOT NULL DEFAULT '{}',
     approved_by TEXT[] NOT NULL DEFAULT '{}',
+    labels TEXT[] NOT NULL DEFAULT '{}',
+    required_skills TEXT[] NOT NULL DEFAULT '{}',
+    priority VARCHAR(2) NOT NULL DEFAULT 'P2',
+    due_at TIMESTAMP,
+    depends_on TEXT[] NOT NULL DEFAULT '{}',
+    reject_reason TEXT NOT NULL DEFAULT '',
+    search_vector TSVECTOR NOT NULL DEFAULT '',
     created_at TIMESTAMP NOT NULL DEFAULT NOW(),
     merged_at TIMESTAMP,
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
+-- Keep pull_requests.search_vector in sync: name ranks above labels, labels above the reject reason
+CREATE OR REPLACE FUNCTION pull_requests_search_vector() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', NEW.pull_request_name), 'A') ||
+        setweight(to_tsvector('english', array_to_string(NEW.labels, ' ')), 'B') ||
+        setweight(to_tsvector('english', NEW.reject_reason), 'C');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_pull_requests_search_vector ON pull_requests;
+CREATE TRIGGER trg_pull_requests_search_vector
+    BEFORE INSERT OR UPDATE OF pull_request_name, labels, reject_reason ON pull_requests
+    FOR EACH ROW EXECUTE FUNCTION pull_requests_search_vector();
+
+-- Create team_policies table (reviewer assignment settings, defaults apply when absent)
+CREATE TABLE IF NOT EXISTS team_policies (
+    team_id UUID PRIMARY KEY REFERENCES teams(id),
+    max_open_reviews INTEGER NOT NULL DEFAULT 0,
+    anti_affinity_days INTEGER NOT NULL DEFAULT 0,
+    dismiss_stale_approvals BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create team_routing_rules table (label -> required reviewer pool, tried by position)
+CREATE TABLE IF NOT EXISTS team_routing_rules (
+    team_id UUID NOT NULL REFERENCES teams(id),
+    position INTEGER NOT NULL,
+    label VARCHAR(50) NOT NULL,
+    reviewer_ids TEXT[] NOT NULL,
+    PRIMARY KEY (team_id, label)
+);
+
+-- Create team_rotations table (on-call reviewer roster, turns of period_days counted from handover_at)
+CREATE TABLE IF NOT EXISTS team_rotations (
+    team_id UUID PRIMARY KEY REFERENCES teams(id),
+    roster TEXT[] NOT NULL,
+    period_days INTEGER NOT NULL CHECK (period_days BETWEEN 1 AND 365),
+    handover_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Create pull_request_events table (assignment history)
+CREATE TABLE IF NOT EXISTS pull_request_events (
+    id BIGSERIAL PRIMARY KEY,
+    pull_request_id VARCHAR(255) NOT NULL REFERENCES pull_requests(pull_request_id),
+    event_type VARCHAR(50) NOT NULL,
+    actor_id VARCHAR(255) NOT NULL DEFAULT '',
+    reviewers TEXT[] NOT NULL DEFAULT '{}',
+    reason TEXT NOT NULL DEFAULT '',
+    decision JSONB,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
 -- Create indexes
 CREATE INDEX IF NOT EXISTS idx_users_team_id ON users(team_id);
 CREATE INDEX IF NOT EXISTS idx_users_is_active ON users(is_active);
 CREATE INDEX IF NOT EXISTS idx_pull_requests_author_id ON pull_requests(author_id);
 CREATE INDEX IF NOT EXISTS idx_pull_requests_status ON pull_requests(status);
 CREATE INDEX IF NOT EXISTS idx_pull_requests_assigned_reviewers ON pull_requests USING GIN(assigned_reviewers);
-CREATE INDEX IF NOT EXISTS idx_teams_team_name ON teams(team_name);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_labels ON pull_requests USING GIN(labels);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_depends_on ON pull_requests USING GIN(depends_on);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_search ON pull_requests USING GIN(search_vector);
+CREATE INDEX IF NOT EXISTS idx_pull_requests_organization_id ON pull_requests(organization_id);
+CREATE INDEX IF NOT EXISTS idx_pull_request_events_pr_id ON pull_request_events(pull_request_id, id);
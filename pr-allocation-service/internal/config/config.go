@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/Meldy183/shared/pkg/tracing"
 	"github.com/spf13/viper"
 )
 
@@ -19,6 +20,9 @@ func MustLoadConfig(path string) error {
 	viper.SetDefault("database.dbname", "pr_allocation")
 	viper.SetDefault("database.sslmode", "disable")
 	viper.SetDefault("env", "development")
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
 
 	// reading from YAML
 	viper.SetConfigName("config")
@@ -49,6 +53,9 @@ func MustLoadConfig(path string) error {
 	bindEnvWithDefault("database.dbname", "DB_NAME")
 	bindEnvWithDefault("database.sslmode", "DB_SSLMODE")
 	bindEnvWithDefault("env", "ENV")
+	bindEnvWithDefault("tracing.exporter", "TRACING_EXPORTER")
+	bindEnvWithDefault("tracing.endpoint", "TRACING_ENDPOINT")
+	bindEnvWithDefault("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
 
 	return nil
 }
@@ -63,6 +70,7 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Database DatabaseConfig `mapstructure:"database"`
 	ENV      string         `mapstructure:"env"`
+	Tracing  tracing.Config `mapstructure:"tracing"`
 }
 
 type ServerConfig struct {
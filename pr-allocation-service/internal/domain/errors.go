@@ -0,0 +1,66 @@
+package domain
+
+import "errors"
+
+// Error codes.
+const (
+	ErrCodeTeamExists     = "TEAM_EXISTS"
+	ErrCodeOrgExists      = "ORG_EXISTS"
+	ErrCodePRExists       = "PR_EXISTS"
+	ErrCodePRMerged       = "PR_MERGED"
+	ErrCodePRRejected     = "PR_REJECTED"
+	ErrCodePRNotOpen      = "PR_NOT_OPEN"
+	ErrCodeNotAssigned    = "NOT_ASSIGNED"
+	ErrCodeNoCandidate    = "NO_CANDIDATE"
+	ErrCodeNotFound       = "NOT_FOUND"
+	ErrCodeInvalidRequest = "INVALID_REQUEST"
+	ErrCodeNotAllApproved = "NOT_ALL_APPROVED"
+	ErrCodeDepsNotMerged  = "DEPENDENCIES_NOT_MERGED"
+	ErrCodePlanStale      = "PLAN_STALE"
+	ErrCodeUnauthorized   = "UNAUTHORIZED"
+	ErrCodeForbidden      = "FORBIDDEN"
+	ErrCodeInternal       = "INTERNAL_ERROR"
+)
+
+// Domain errors. Wrap them with fmt.Errorf("%w: detail", ...); the message is the code,
+// so wrapped errors read "CODE: detail".
+var (
+	ErrTeamExists     = errors.New(ErrCodeTeamExists)
+	ErrOrgExists      = errors.New(ErrCodeOrgExists)
+	ErrPRExists       = errors.New(ErrCodePRExists)
+	ErrPRMerged       = errors.New(ErrCodePRMerged)
+	ErrPRRejected     = errors.New(ErrCodePRRejected)
+	ErrPRNotOpen      = errors.New(ErrCodePRNotOpen)
+	ErrNotAssigned    = errors.New(ErrCodeNotAssigned)
+	ErrNoCandidate    = errors.New(ErrCodeNoCandidate)
+	ErrNotFound       = errors.New(ErrCodeNotFound)
+	ErrInvalidRequest = errors.New(ErrCodeInvalidRequest)
+	ErrNotAllApproved = errors.New(ErrCodeNotAllApproved)
+	ErrDepsNotMerged  = errors.New(ErrCodeDepsNotMerged)
+	ErrPlanStale      = errors.New(ErrCodePlanStale)
+	ErrUnauthorized   = errors.New(ErrCodeUnauthorized)
+	ErrForbidden      = errors.New(ErrCodeForbidden)
+)
+
+// ErrorResponse for API errors (legacy shape, served when the client accepts only application/json).
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// MapErrorToCode maps domain error to API error code.
+func MapErrorToCode(err error) string {
+	for _, known := range []error{
+		ErrTeamExists, ErrOrgExists, ErrPRExists, ErrPRMerged, ErrPRRejected, ErrPRNotOpen, ErrNotAssigned,
+		ErrNoCandidate, ErrNotFound, ErrInvalidRequest, ErrNotAllApproved, ErrDepsNotMerged, ErrPlanStale,
+		ErrUnauthorized, ErrForbidden,
+	} {
+		if errors.Is(err, known) {
+			return known.Error()
+		}
+	}
+	return ErrCodeInternal
+}
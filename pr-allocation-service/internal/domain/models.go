@@ -6,6 +6,51 @@ import (
 	"github.com/google/uuid"
 )
 
+// Organization scoping. Requests name their organization in OrganizationHeader;
+// without it they are scoped to DefaultOrganization.
+const (
+	OrganizationHeader  = "X-Organization"
+	DefaultOrganization = "default"
+)
+
+// Organization owns teams; team names are unique within it, and users, PRs and statistics are scoped to it.
+type Organization struct {
+	ID        uuid.UUID `json:"organization_id"`
+	OrgName   string    `json:"org_name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"-"`
+}
+
+// ActorHeader names the user on whose behalf a request is made; operations that need a
+// team role (merge, reassign, bulk deactivation, policy and role changes) check it.
+const ActorHeader = "X-Actor-ID"
+
+// Role is a user's role in their team. Each role has the permissions of the ones below it.
+type Role string
+
+const (
+	RoleMember     Role = "member"
+	RoleMaintainer Role = "maintainer"
+	RoleAdmin      Role = "admin"
+)
+
+var roleRanks = map[Role]int{RoleMember: 1, RoleMaintainer: 2, RoleAdmin: 3}
+
+// Valid reports whether r is member, maintainer or admin.
+func (r Role) Valid() bool {
+	return roleRanks[r] > 0
+}
+
+// AtLeast reports whether r grants everything required grants.
+func (r Role) AtLeast(required Role) bool {
+	return roleRanks[r] >= roleRanks[required]
+}
+
+// CreateOrganizationRequest - POST /org/add.
+type CreateOrganizationRequest struct {
+	OrgName string `json:"org_name"`
+}
+
 // User represents a team member.
 type User struct {
 	UserID    string    `json:"user_id"`
@@ -13,15 +58,21 @@ type User struct {
 	TeamID    uuid.UUID `json:"team_id,omitempty"`
 	TeamName  string    `json:"team_name,omitempty"`
 	IsActive  bool      `json:"is_active"`
+	Role      Role      `json:"role"`
+	Skills    []string  `json:"skills,omitempty"`
 	CreatedAt time.Time `json:"-"`
 	UpdatedAt time.Time `json:"-"`
+	// OrganizationID is the organization of the user's team
+	OrganizationID uuid.UUID `json:"-"`
 }
 
 // TeamMember for API response.
+// Role defaults to member; a team created without an admin makes its first member admin.
 type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	Role     Role   `json:"role,omitempty"`
 }
 
 // The Team represents a group of users.
@@ -31,6 +82,8 @@ type Team struct {
 	Members   []TeamMember `json:"members"`
 	CreatedAt time.Time    `json:"-"`
 	UpdatedAt time.Time    `json:"-"`
+	// OrganizationID is the organization owning the team
+	OrganizationID uuid.UUID `json:"-"`
 }
 
 // PRStatus represents PR status.
@@ -42,26 +95,438 @@ const (
 	StatusRejected PRStatus = "REJECTED"
 )
 
+// PRPriority orders review queues; P0 is the most urgent.
+type PRPriority string
+
+const (
+	PriorityP0 PRPriority = "P0"
+	PriorityP1 PRPriority = "P1"
+	PriorityP2 PRPriority = "P2"
+	PriorityP3 PRPriority = "P3"
+
+	DefaultPriority = PriorityP2
+)
+
+// Valid reports whether p is one of P0-P3.
+func (p PRPriority) Valid() bool {
+	switch p {
+	case PriorityP0, PriorityP1, PriorityP2, PriorityP3:
+		return true
+	}
+	return false
+}
+
 // PullRequest represents a PR.
 type PullRequest struct {
 	PullRequestID     string     `json:"pull_request_id"`
 	PullRequestName   string     `json:"pull_request_name"`
+	OrganizationID    uuid.UUID  `json:"-"`
 	AuthorID          string     `json:"author_id"`
 	Status            PRStatus   `json:"status"`
 	AssignedReviewers []string   `json:"assigned_reviewers"`
 	ApprovedBy        []string   `json:"approved_by,omitempty"`
+	Labels            []string   `json:"labels,omitempty"`
+	RequiredSkills    []string   `json:"required_skills,omitempty"`
+	Priority          PRPriority `json:"priority"`
+	DueAt             *time.Time `json:"dueAt,omitempty"`
+	DependsOn         []string   `json:"depends_on,omitempty"`
+	RejectReason      string     `json:"reject_reason,omitempty"`
 	CreatedAt         *time.Time `json:"createdAt,omitempty"`
 	MergedAt          *time.Time `json:"mergedAt,omitempty"`
 }
 
+// Reviewer selection strategies.
+const (
+	StrategyRandom = "random"
+	// StrategyWeighted draws without replacement with weight 1/(1+n), where n is the candidate's
+	// recent reviews for the same author (anti-affinity).
+	StrategyWeighted = "weighted"
+	// StrategyLightestQueue takes the candidates with the fewest open reviews, used for urgent PRs.
+	// Ties are broken by a seeded shuffle.
+	StrategyLightestQueue = "lightest_queue"
+)
+
+// ExclusionReason says why a team member was not a reviewer candidate.
+type ExclusionReason string
+
+const (
+	ExcludedAuthor          ExclusionReason = "AUTHOR"
+	ExcludedInactive        ExclusionReason = "INACTIVE"
+	ExcludedAlreadyAssigned ExclusionReason = "ALREADY_ASSIGNED"
+	ExcludedOverCap         ExclusionReason = "OVER_CAP"
+	ExcludedNotInRoute      ExclusionReason = "NOT_IN_ROUTE"
+	ExcludedSkillGap        ExclusionReason = "SKILL_GAP"
+	// ExcludedNotRebalanceTarget marks members a rebalance can't move a slot to: not reactivated,
+	// or without a load gap of two or more to the reviewer being relieved
+	ExcludedNotRebalanceTarget ExclusionReason = "NOT_REBALANCE_TARGET"
+)
+
+// AssignmentDecision records how reviewers were chosen, so the choice can be replayed exactly.
+// Candidates are kept in the order the strategy saw them; Excluded holds the rest of the team pool.
+// Preselected reviewers (best skill match) take their slots before the strategy fills the rest from Candidates.
+type AssignmentDecision struct {
+	Strategy       string                     `json:"strategy"`
+	Seed           uint64                     `json:"seed,string"`
+	Slots          int                        `json:"slots"`
+	Candidates     []string                   `json:"candidates"`
+	Selected       []string                   `json:"selected"`
+	TeamID         uuid.UUID                  `json:"team_id,omitempty"`
+	MaxOpenReviews int                        `json:"max_open_reviews,omitempty"`
+	Excluded       map[string]ExclusionReason `json:"excluded,omitempty"`
+	RecentReviews  map[string]int             `json:"recent_reviews,omitempty"`
+	// RoutedBy is the label whose routing rule narrowed the candidates to its reviewer pool
+	RoutedBy       string              `json:"routed_by,omitempty"`
+	RequiredSkills []string            `json:"required_skills,omitempty"`
+	SkillMatches   map[string][]string `json:"skill_matches,omitempty"`
+	Preselected    []string            `json:"preselected,omitempty"`
+	QueueLoads     map[string]int      `json:"queue_loads,omitempty"`
+	// OnCall is the team rotation's current on-call reviewer; they come first in Preselected
+	OnCall string `json:"on_call,omitempty"`
+}
+
+// ReplaceUser renames userID to anonID in the fields that hold user IDs. Strategy, labels and skills
+// are left alone even when they equal userID. It reports whether anything changed.
+func (d *AssignmentDecision) ReplaceUser(userID, anonID string) bool {
+	changed := false
+	for _, ids := range [][]string{d.Candidates, d.Selected, d.Preselected} {
+		for i, id := range ids {
+			if id == userID {
+				ids[i] = anonID
+				changed = true
+			}
+		}
+	}
+	if d.OnCall == userID {
+		d.OnCall = anonID
+		changed = true
+	}
+	changed = renameKey(d.Excluded, userID, anonID) || changed
+	changed = renameKey(d.RecentReviews, userID, anonID) || changed
+	changed = renameKey(d.SkillMatches, userID, anonID) || changed
+	changed = renameKey(d.QueueLoads, userID, anonID) || changed
+	return changed
+}
+
+// renameKey moves m[from] to m[to] and reports whether from was there.
+func renameKey[V any](m map[string]V, from, to string) bool {
+	v, ok := m[from]
+	if !ok {
+		return false
+	}
+	delete(m, from)
+	m[to] = v
+	return true
+}
+
+// RoutingRule requires a reviewer from ReviewerIDs on PRs labeled Label.
+type RoutingRule struct {
+	Label       string   `json:"label"`
+	ReviewerIDs []string `json:"reviewer_ids"`
+}
+
+// SetRoutingRulesRequest - POST /team/setRoutingRules. Rules replace the current ones and are tried in order.
+type SetRoutingRulesRequest struct {
+	TeamName string        `json:"team_name"`
+	Rules    []RoutingRule `json:"rules"`
+}
+
+// RotationSchedule is a team's on-call reviewer rotation: roster members take turns of PeriodDays days in order.
+// The roster's first member is on call from HandoverAt; turns are counted from it in both directions.
+type RotationSchedule struct {
+	TeamName   string    `json:"team_name"`
+	Roster     []string  `json:"roster"`
+	PeriodDays int       `json:"period_days"`
+	HandoverAt time.Time `json:"handover_at"`
+}
+
+// SetRotationRequest - POST /team/setRotation. An empty roster removes the rotation;
+// HandoverAt defaults to now.
+type SetRotationRequest struct {
+	TeamName   string     `json:"team_name"`
+	Roster     []string   `json:"roster"`
+	PeriodDays int        `json:"period_days"`
+	HandoverAt *time.Time `json:"handover_at,omitempty"`
+}
+
+// MaxRotationPeriodDays bounds a rotation turn, keeping shift arithmetic far from time.Duration overflow.
+const MaxRotationPeriodDays = 365
+
+// OnCallShift is one roster member's turn in a rotation.
+type OnCallShift struct {
+	UserID   string    `json:"user_id"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+}
+
+// OnCallResponse - GET /team/onCall.
+type OnCallResponse struct {
+	TeamName string      `json:"team_name"`
+	Current  OnCallShift `json:"current"`
+	Next     OnCallShift `json:"next"`
+}
+
+// SetMemberRoleRequest - POST /team/setMemberRole.
+type SetMemberRoleRequest struct {
+	TeamName string `json:"team_name"`
+	UserID   string `json:"user_id"`
+	Role     Role   `json:"role"`
+}
+
+// PRLabelsRequest - POST /pullRequest/addLabels, /pullRequest/removeLabels and /pullRequest/setLabels.
+type PRLabelsRequest struct {
+	PullRequestID string   `json:"pull_request_id"`
+	Labels        []string `json:"labels"`
+}
+
+// TeamPolicy holds per-team reviewer assignment settings.
+// MaxOpenReviews of 0 means no cap; AntiAffinityDays of 0 turns anti-affinity off.
+// DismissStaleApprovals, on unless turned off, drops a PR's approvals when its source is updated.
+type TeamPolicy struct {
+	TeamName              string `json:"team_name"`
+	MaxOpenReviews        int    `json:"max_open_reviews"`
+	AntiAffinityDays      int    `json:"anti_affinity_days"`
+	DismissStaleApprovals bool   `json:"dismiss_stale_approvals"`
+}
+
+// SetTeamPolicyRequest - POST /team/setPolicy. Omitted fields keep their current value.
+type SetTeamPolicyRequest struct {
+	TeamName              string `json:"team_name"`
+	MaxOpenReviews        *int   `json:"max_open_reviews,omitempty"`
+	AntiAffinityDays      *int   `json:"anti_affinity_days,omitempty"`
+	DismissStaleApprovals *bool  `json:"dismiss_stale_approvals,omitempty"`
+}
+
+// Candidate statuses in an assignment explanation.
+const (
+	CandidateSelected   = "SELECTED"
+	CandidateEligible   = "ELIGIBLE"
+	CandidateExcluded   = "EXCLUDED"
+	CandidateJoinedLate = "JOINED_LATER"
+)
+
+// CandidateExplanation is one team member as seen by an assignment.
+// LeftTeam marks members of the recorded pool who are no longer in the team.
+type CandidateExplanation struct {
+	UserID   string          `json:"user_id"`
+	Username string          `json:"username,omitempty"`
+	Status   string          `json:"status"`
+	Reason   ExclusionReason `json:"reason,omitempty"`
+	LeftTeam bool            `json:"left_team,omitempty"`
+	// RecentReviews is how often the candidate reviewed the author within the anti-affinity window
+	RecentReviews int      `json:"recent_reviews,omitempty"`
+	MatchedSkills []string `json:"matched_skills,omitempty"`
+	OpenReviews   int      `json:"open_reviews,omitempty"`
+}
+
+// PRPriorityRequest - POST /pullRequest/setPriority. A null DueAt clears the due date.
+type PRPriorityRequest struct {
+	PullRequestID string     `json:"pull_request_id"`
+	Priority      PRPriority `json:"priority"`
+	DueAt         *time.Time `json:"dueAt"`
+}
+
+// AnonymizedUsername replaces the username of an anonymized user.
+const AnonymizedUsername = "deleted user"
+
+// AnonymizeUserRequest - POST /users/anonymize.
+type AnonymizeUserRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// AnonymizationReport says what was rewritten when a user was anonymized; counts are rows touched.
+type AnonymizationReport struct {
+	UserID            string `json:"user_id"`
+	AnonymizedID      string `json:"anonymized_id"`
+	AuthoredPRs       int    `json:"authored_prs"`
+	ReviewAssignments int    `json:"review_assignments"`
+	Approvals         int    `json:"approvals"`
+	Events            int    `json:"events"`
+	RoutingRules      int    `json:"routing_rules"`
+	Rotations         int    `json:"rotations"`
+}
+
+// UserSkillsRequest - POST /users/addSkills, /users/removeSkills and /users/setSkills.
+type UserSkillsRequest struct {
+	UserID string   `json:"user_id"`
+	Skills []string `json:"skills"`
+}
+
+// UpdatePRSourceRequest - POST /pullRequest/updateSource. SourceCommit names the new source for the history.
+type UpdatePRSourceRequest struct {
+	PullRequestID string `json:"pull_request_id"`
+	SourceCommit  string `json:"source_commit"`
+}
+
+// PRDependenciesRequest - POST /pullRequest/setDependencies. DependsOn replaces the current dependencies.
+type PRDependenciesRequest struct {
+	PullRequestID string   `json:"pull_request_id"`
+	DependsOn     []string `json:"depends_on"`
+}
+
+// PRDependencyNode is one PR of a dependency graph.
+type PRDependencyNode struct {
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	Status          PRStatus `json:"status"`
+}
+
+// PRDependencyEdge says that PullRequestID depends on DependsOn.
+type PRDependencyEdge struct {
+	PullRequestID string `json:"pull_request_id"`
+	DependsOn     string `json:"depends_on"`
+}
+
+// PRDependencyGraph - GET /pullRequest/dependencies. It holds the PR, everything it transitively
+// depends on and everything that transitively depends on it.
+type PRDependencyGraph struct {
+	PullRequestID string             `json:"pull_request_id"`
+	Nodes         []PRDependencyNode `json:"nodes"`
+	Edges         []PRDependencyEdge `json:"edges"`
+}
+
+// PRSkillsRequest - POST /pullRequest/setRequiredSkills.
+type PRSkillsRequest struct {
+	PullRequestID string   `json:"pull_request_id"`
+	Skills        []string `json:"skills"`
+}
+
+// ExplainResponse - GET /pullRequest/explain.
+type ExplainResponse struct {
+	PullRequestID string                 `json:"pull_request_id"`
+	EventID       int64                  `json:"event_id"`
+	EventType     PREventType            `json:"event_type"`
+	AssignedAt    time.Time              `json:"assignedAt"`
+	Decision      *AssignmentDecision    `json:"decision"`
+	Candidates    []CandidateExplanation `json:"candidates"`
+	Rule          string                 `json:"rule"`
+}
+
+// PREventType is the kind of change recorded in PR history.
+type PREventType string
+
+const (
+	EventCreated        PREventType = "CREATED"
+	EventReassigned     PREventType = "REASSIGNED"
+	EventBulkReassigned PREventType = "BULK_REASSIGNED"
+	EventRebalanced     PREventType = "REBALANCED"
+	EventApproved       PREventType = "APPROVED"
+	EventRejected       PREventType = "REJECTED"
+	EventMerged         PREventType = "MERGED"
+	EventLabelsChanged  PREventType = "LABELS_CHANGED"
+	// EventRequiredSkillsChanged records a change of the skills a PR asks its reviewers to cover
+	EventRequiredSkillsChanged PREventType = "REQUIRED_SKILLS_CHANGED"
+	EventPriorityChanged       PREventType = "PRIORITY_CHANGED"
+	EventDependenciesChanged   PREventType = "DEPENDENCIES_CHANGED"
+	EventSourceUpdated         PREventType = "SOURCE_UPDATED"
+)
+
+// PREvent is one entry of a PR's history.
+// Reviewers is the reviewer list after the event; Decision is set for events that assigned reviewers.
+type PREvent struct {
+	EventID       int64               `json:"event_id"`
+	PullRequestID string              `json:"pull_request_id"`
+	Type          PREventType         `json:"type"`
+	ActorID       string              `json:"actor_id,omitempty"`
+	Reviewers     []string            `json:"reviewers"`
+	Reason        string              `json:"reason,omitempty"`
+	Decision      *AssignmentDecision `json:"decision,omitempty"`
+	CreatedAt     time.Time           `json:"createdAt"`
+}
+
+// ActivityKind is how a PR event is presented to the users it concerns (GET /events/stream).
+type ActivityKind string
+
+const (
+	ActivityAssigned ActivityKind = "assigned"
+	ActivityApproved ActivityKind = "approved"
+	ActivityRejected ActivityKind = "rejected"
+	ActivityMerged   ActivityKind = "merged"
+)
+
+// ActivityEventTypes maps the PR event types streamed to users onto their activity kind.
+var ActivityEventTypes = map[PREventType]ActivityKind{
+	EventCreated:        ActivityAssigned,
+	EventReassigned:     ActivityAssigned,
+	EventBulkReassigned: ActivityAssigned,
+	EventRebalanced:     ActivityAssigned,
+	EventApproved:       ActivityApproved,
+	EventRejected:       ActivityRejected,
+	EventMerged:         ActivityMerged,
+}
+
+// MaxActivityBatch bounds how many events one poll of the activity stream reads.
+const MaxActivityBatch = 100
+
+// PRActivity is a PR event of a PR the user authored or reviews.
+type PRActivity struct {
+	EventID         int64        `json:"event_id"`
+	Kind            ActivityKind `json:"kind"`
+	Type            PREventType  `json:"type"`
+	PullRequestID   string       `json:"pull_request_id"`
+	PullRequestName string       `json:"pull_request_name"`
+	AuthorID        string       `json:"author_id"`
+	ActorID         string       `json:"actor_id,omitempty"`
+	Reviewers       []string     `json:"reviewers"`
+	Reason          string       `json:"reason,omitempty"`
+	CreatedAt       time.Time    `json:"createdAt"`
+}
+
+// ReplayResponse - GET /pullRequest/replay.
+type ReplayResponse struct {
+	PullRequestID string              `json:"pull_request_id"`
+	EventID       int64               `json:"event_id"`
+	Decision      *AssignmentDecision `json:"decision"`
+	Replayed      []string            `json:"replayed"`
+	Matches       bool                `json:"matches"`
+}
+
 // PullRequestShort for list responses.
 type PullRequestShort struct {
-	PullRequestID     string   `json:"pull_request_id"`
-	PullRequestName   string   `json:"pull_request_name"`
-	AuthorID          string   `json:"author_id"`
-	Status            PRStatus `json:"status"`
-	AssignedReviewers []string `json:"assigned_reviewers"`
-	ApprovedBy        []string `json:"approved_by,omitempty"`
+	PullRequestID     string     `json:"pull_request_id"`
+	PullRequestName   string     `json:"pull_request_name"`
+	AuthorID          string     `json:"author_id"`
+	Status            PRStatus   `json:"status"`
+	AssignedReviewers []string   `json:"assigned_reviewers"`
+	ApprovedBy        []string   `json:"approved_by,omitempty"`
+	Labels            []string   `json:"labels,omitempty"`
+	Priority          PRPriority `json:"priority"`
+	DueAt             *time.Time `json:"dueAt,omitempty"`
+	CreatedAt         *time.Time `json:"createdAt,omitempty"`
+}
+
+// Search page size limits.
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+// PRSearchQuery - GET /pullRequest/search. Every filter is optional; an empty Query
+// lists matching PRs newest first instead of by rank.
+type PRSearchQuery struct {
+	Query      string
+	TeamName   string
+	Status     PRStatus
+	AuthorID   string
+	ReviewerID string
+	Limit      int
+	Offset     int
+	// OrganizationID scopes the search; it is always set from the request
+	OrganizationID uuid.UUID
+}
+
+// PRSearchHit is one ranked search result.
+type PRSearchHit struct {
+	PR   *PullRequest `json:"pr"`
+	Rank float64      `json:"rank"`
+}
+
+// PRSearchResponse is a page of search results; Total counts all matches.
+type PRSearchResponse struct {
+	Results []PRSearchHit `json:"results"`
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
 }
 
 // CreateTeamRequest - POST /team/add.
@@ -78,14 +543,21 @@ type SetUserActiveRequest struct {
 
 // CreatePRRequest - POST /pullRequest/create.
 type CreatePRRequest struct {
-	PullRequestID   string `json:"pull_request_id"`
-	PullRequestName string `json:"pull_request_name"`
-	AuthorID        string `json:"author_id"`
+	PullRequestID   string   `json:"pull_request_id"`
+	PullRequestName string   `json:"pull_request_name"`
+	AuthorID        string   `json:"author_id"`
+	Labels          []string `json:"labels,omitempty"`
+	RequiredSkills  []string `json:"required_skills,omitempty"`
+	// Priority defaults to P2
+	Priority  PRPriority `json:"priority,omitempty"`
+	DueAt     *time.Time `json:"dueAt,omitempty"`
+	DependsOn []string   `json:"depends_on,omitempty"`
 }
 
-// MergePRRequest - POST /pullRequest/merge.
+// MergePRRequest - POST /pullRequest/merge. DryRun runs the checks without merging.
 type MergePRRequest struct {
 	PullRequestID string `json:"pull_request_id"`
+	DryRun        bool   `json:"dry_run"`
 }
 
 // ApprovePRRequest - POST /pullRequest/approve.
@@ -108,17 +580,39 @@ type ReassignRequest struct {
 }
 
 // BulkDeactivateRequest - POST /team/deactivateUsers.
+// DryRun only computes the plan; PlanToken executes a previously previewed plan.
 type BulkDeactivateRequest struct {
-	TeamName string `json:"team_name"`
+	TeamName  string `json:"team_name"`
+	DryRun    bool   `json:"dry_run,omitempty"`
+	PlanToken string `json:"plan_token,omitempty"`
 }
 
 // BulkDeactivateResponse - response for bulk deactivation.
 type BulkDeactivateResponse struct {
 	DeactivatedCount int                     `json:"deactivated_count"`
 	ReassignedPRs    []PRReassignmentSummary `json:"reassigned_prs"`
+	DryRun           bool                    `json:"dry_run,omitempty"`
+	PlanToken        string                  `json:"plan_token,omitempty"`
+}
+
+// BulkActivateRequest - POST /team/activateUsers.
+// Empty UserIDs reactivates the whole team; Rebalance moves open reviews onto the reactivated users.
+type BulkActivateRequest struct {
+	TeamName  string   `json:"team_name"`
+	UserIDs   []string `json:"user_ids,omitempty"`
+	Rebalance bool     `json:"rebalance,omitempty"`
 }
 
-// PRReassignmentSummary - summary of PR reassignments during bulk deactivation.
+// BulkActivateResponse - response for bulk activation.
+// SkippedPRs are the PRs whose rebalance was dropped because they changed while it was planned.
+type BulkActivateResponse struct {
+	ActivatedCount   int                     `json:"activated_count"`
+	ActivatedUserIDs []string                `json:"activated_user_ids"`
+	RebalancedPRs    []PRReassignmentSummary `json:"rebalanced_prs"`
+	SkippedPRs       []string                `json:"skipped_prs"`
+}
+
+// PRReassignmentSummary - summary of PR reassignments during bulk deactivation or rebalancing.
 type PRReassignmentSummary struct {
 	PullRequestID string   `json:"pull_request_id"`
 	OldReviewers  []string `json:"old_reviewers"`
@@ -146,26 +640,3 @@ type UserAssignmentStats struct {
 	OpenPRsCount     int    `json:"open_prs_count"`
 	MergedPRsCount   int    `json:"merged_prs_count"`
 }
-
-// ErrorResponse for API errors.
-type ErrorResponse struct {
-	Error ErrorDetail `json:"error"`
-}
-type ErrorDetail struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-}
-
-// Error codes.
-const (
-	ErrTeamExists     = "TEAM_EXISTS"
-	ErrPRExists       = "PR_EXISTS"
-	ErrPRMerged       = "PR_MERGED"
-	ErrPRRejected     = "PR_REJECTED"
-	ErrPRNotOpen      = "PR_NOT_OPEN"
-	ErrNotAssigned    = "NOT_ASSIGNED"
-	ErrNoCandidate    = "NO_CANDIDATE"
-	ErrNotFound       = "NOT_FOUND"
-	ErrInvalidRequest = "INVALID_REQUEST"
-	ErrNotAllApproved = "NOT_ALL_APPROVED"
-)
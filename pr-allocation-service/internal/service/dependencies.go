@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// checkDependencies sorts and de-duplicates the PRs prID is to depend on. Each must be another
+// not rejected PR of the organization that doesn't itself depend on prID.
+func (s *Service) checkDependencies(ctx context.Context, prID string, dependsOn []string) ([]string, error) {
+	deps := slices.Clone(dependsOn)
+	slices.Sort(deps)
+	deps = slices.Compact(deps)
+	for _, depID := range deps {
+		if depID == "" || depID == prID {
+			return nil, fmt.Errorf("%w: a PR cannot depend on itself", domain.ErrInvalidRequest)
+		}
+		dep, err := s.getPR(ctx, depID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: dependency %s not found", domain.ErrInvalidRequest, depID)
+		}
+		if dep.Status == domain.StatusRejected {
+			return nil, fmt.Errorf("%w: dependency %s was rejected", domain.ErrInvalidRequest, depID)
+		}
+		cycle, err := s.dependsOn(ctx, dep, prID)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			return nil, fmt.Errorf("%w: %s already depends on %s", domain.ErrInvalidRequest, depID, prID)
+		}
+	}
+	return deps, nil
+}
+
+// dependsOn reports whether pr transitively depends on targetID.
+func (s *Service) dependsOn(ctx context.Context, pr *domain.PullRequest, targetID string) (bool, error) {
+	seen := make(map[string]bool)
+	queue := slices.Clone(pr.DependsOn)
+	for len(queue) > 0 {
+		prID := queue[0]
+		queue = queue[1:]
+		if prID == targetID {
+			return true, nil
+		}
+		if seen[prID] {
+			continue
+		}
+		seen[prID] = true
+		dep, err := s.storage.GetPR(ctx, prID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get dependency %s: %w", prID, err)
+		}
+		queue = append(queue, dep.DependsOn...)
+	}
+	return false, nil
+}
+
+// unmergedDependencies returns the PR's dependencies that are not MERGED yet.
+func (s *Service) unmergedDependencies(ctx context.Context, pr *domain.PullRequest) ([]string, error) {
+	var pending []string
+	for _, depID := range pr.DependsOn {
+		dep, err := s.storage.GetPR(ctx, depID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dependency %s: %w", depID, err)
+		}
+		if dep.Status != domain.StatusMerged {
+			pending = append(pending, depID)
+		}
+	}
+	return pending, nil
+}
+
+// SetPRDependencies replaces the PRs an open PR depends on (POST /pullRequest/setDependencies).
+// Only the author or a maintainer of the author's team may do it. Storage repeats the checks while
+// writing, so concurrent changes can't close a cycle or stack the PR on one rejected meanwhile.
+func (s *Service) SetPRDependencies(ctx context.Context, req *domain.PRDependenciesRequest) (*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	pr, err := s.getPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	if actorID(ctx) != pr.AuthorID {
+		if err := s.authorizePR(ctx, pr, domain.RoleMaintainer); err != nil {
+			return nil, err
+		}
+	}
+	if pr.Status != domain.StatusOpen {
+		return nil, fmt.Errorf("%w: PR is not open", domain.ErrPRNotOpen)
+	}
+	deps, err := s.checkDependencies(ctx, pr.PullRequestID, req.DependsOn)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Equal(deps, pr.DependsOn) {
+		return pr, nil
+	}
+	if err := s.storage.SetPRDependencies(ctx, pr.PullRequestID, deps); err != nil {
+		log.Error(ctx, "failed to update PR dependencies", zap.Error(err))
+		return nil, err
+	}
+	pr.DependsOn = deps
+	s.recordEvent(ctx, pr, domain.EventDependenciesChanged, "", "depends on: "+strings.Join(deps, ","), nil)
+	log.Info(ctx, "PR dependencies changed", zap.String("pr_id", pr.PullRequestID), zap.Strings("depends_on", deps))
+	return pr, nil
+}
+
+// GetPRDependencyGraph returns the PR with everything it transitively depends on
+// and everything transitively depending on it (GET /pullRequest/dependencies).
+func (s *Service) GetPRDependencyGraph(ctx context.Context, prID string) (*domain.PRDependencyGraph, error) {
+	root, err := s.getPR(ctx, prID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	nodes := map[string]*domain.PullRequest{root.PullRequestID: root}
+	// Dependencies, walking up the stack
+	queue := slices.Clone(root.DependsOn)
+	for len(queue) > 0 {
+		depID := queue[0]
+		queue = queue[1:]
+		if nodes[depID] != nil {
+			continue
+		}
+		dep, err := s.storage.GetPR(ctx, depID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get dependency %s: %w", depID, err)
+		}
+		nodes[depID] = dep
+		queue = append(queue, dep.DependsOn...)
+	}
+	// Dependents, walking down the stack
+	down := []string{root.PullRequestID}
+	for len(down) > 0 {
+		current := down[0]
+		down = down[1:]
+		dependents, err := s.storage.GetPRDependents(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+		for _, dependent := range dependents {
+			if nodes[dependent.PullRequestID] == nil {
+				nodes[dependent.PullRequestID] = dependent
+				down = append(down, dependent.PullRequestID)
+			}
+		}
+	}
+
+	graph := &domain.PRDependencyGraph{
+		PullRequestID: root.PullRequestID,
+		Nodes:         make([]domain.PRDependencyNode, 0, len(nodes)),
+		Edges:         make([]domain.PRDependencyEdge, 0),
+	}
+	for _, id := range slices.Sorted(maps.Keys(nodes)) {
+		pr := nodes[id]
+		graph.Nodes = append(graph.Nodes, domain.PRDependencyNode{
+			PullRequestID:   pr.PullRequestID,
+			PullRequestName: pr.PullRequestName,
+			AuthorID:        pr.AuthorID,
+			Status:          pr.Status,
+		})
+		for _, depID := range pr.DependsOn {
+			if nodes[depID] != nil {
+				graph.Edges = append(graph.Edges, domain.PRDependencyEdge{PullRequestID: id, DependsOn: depID})
+			}
+		}
+	}
+	return graph, nil
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+// stackedPRs returns a storage with pr-1 <- pr-2 <- pr-3 stacked on each other, pr-4 on its own
+// and pr-5 already rejected, all authored by a1 and reviewed by u1
+func stackedPRs() *fakeStorage {
+	f := newFakeStorage("a1", "u1")
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-1", AuthorID: "a1", AssignedReviewers: []string{"u1"}})
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-2", AuthorID: "a1", AssignedReviewers: []string{"u1"}, DependsOn: []string{"pr-1"}})
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-3", AuthorID: "a1", AssignedReviewers: []string{"u1"}, DependsOn: []string{"pr-2"}})
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-4", AuthorID: "a1", AssignedReviewers: []string{"u1"}})
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-5", AuthorID: "a1", Status: domain.StatusRejected})
+	return f
+}
+
+func TestSetPRDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		prID      string
+		dependsOn []string
+		// meanwhile runs between the service's checks and the write, as a concurrent request would
+		meanwhile func(f *fakeStorage)
+		want      []string
+		wantErr   error
+	}{
+		{name: "sorted without duplicates", prID: "pr-4", dependsOn: []string{"pr-3", "pr-1", "pr-3"}, want: []string{"pr-1", "pr-3"}},
+		{name: "cleared", prID: "pr-2", dependsOn: []string{}, want: []string{}},
+		{name: "itself", prID: "pr-4", dependsOn: []string{"pr-4"}, wantErr: domain.ErrInvalidRequest},
+		{name: "direct cycle", prID: "pr-2", dependsOn: []string{"pr-3"}, wantErr: domain.ErrInvalidRequest},
+		{name: "transitive cycle", prID: "pr-1", dependsOn: []string{"pr-3"}, wantErr: domain.ErrInvalidRequest},
+		{name: "rejected dependency", prID: "pr-4", dependsOn: []string{"pr-5"}, wantErr: domain.ErrInvalidRequest},
+		{name: "unknown dependency", prID: "pr-4", dependsOn: []string{"pr-9"}, wantErr: domain.ErrInvalidRequest},
+		{name: "closed PR", prID: "pr-5", dependsOn: []string{"pr-1"}, wantErr: domain.ErrPRNotOpen},
+		{
+			name: "cycle closed by a concurrent request", prID: "pr-4", dependsOn: []string{"pr-3"},
+			meanwhile: func(f *fakeStorage) { f.prs["pr-1"].DependsOn = []string{"pr-4"} },
+			wantErr:   domain.ErrInvalidRequest,
+		},
+		{
+			name: "dependency rejected concurrently", prID: "pr-4", dependsOn: []string{"pr-1"},
+			meanwhile: func(f *fakeStorage) { f.prs["pr-1"].Status = domain.StatusRejected },
+			wantErr:   domain.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := stackedPRs()
+			if tt.meanwhile != nil {
+				f.beforeApply = func() { tt.meanwhile(f) }
+			}
+			before := slices.Clone(f.prs[tt.prID].DependsOn)
+			ctx := WithActor(context.Background(), "a1")
+			pr, err := NewService(f).SetPRDependencies(ctx, &domain.PRDependenciesRequest{PullRequestID: tt.prID, DependsOn: tt.dependsOn})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if !slices.Equal(f.prs[tt.prID].DependsOn, before) {
+					t.Fatalf("dependencies changed to %v despite the error", f.prs[tt.prID].DependsOn)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("set dependencies: %v", err)
+			}
+			if !slices.Equal(pr.DependsOn, tt.want) || !slices.Equal(f.prs[tt.prID].DependsOn, tt.want) {
+				t.Fatalf("dependencies %v, stored %v; want %v", pr.DependsOn, f.prs[tt.prID].DependsOn, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergePRWaitsForDependencies(t *testing.T) {
+	f := stackedPRs()
+	f.users["u1"].Role = domain.RoleMaintainer
+	for _, pr := range f.prs {
+		pr.ApprovedBy = slices.Clone(pr.AssignedReviewers)
+	}
+	svc := NewService(f)
+	ctx := WithActor(context.Background(), "u1")
+
+	if _, err := svc.MergePR(ctx, &domain.MergePRRequest{PullRequestID: "pr-2"}); !errors.Is(err, domain.ErrDepsNotMerged) {
+		t.Fatalf("merging pr-2 before pr-1: error = %v, want ErrDepsNotMerged", err)
+	}
+	if f.prs["pr-2"].Status != domain.StatusOpen {
+		t.Fatalf("pr-2 is %s, want it still open", f.prs["pr-2"].Status)
+	}
+	for _, prID := range []string{"pr-1", "pr-2", "pr-3"} {
+		pr, err := svc.MergePR(ctx, &domain.MergePRRequest{PullRequestID: prID})
+		if err != nil || pr.Status != domain.StatusMerged {
+			t.Fatalf("merge %s in stack order = %+v, %v; want it merged", prID, pr, err)
+		}
+	}
+}
+
+func TestRejectPRCascades(t *testing.T) {
+	f := stackedPRs()
+	f.prs["pr-4"].DependsOn = []string{"pr-5"}
+	ctx := context.Background()
+
+	pr, err := NewService(f).RejectPR(ctx, &domain.RejectPRRequest{PullRequestID: "pr-1", ReviewerID: "u1", Reason: "wrong approach"})
+	if err != nil || pr.Status != domain.StatusRejected {
+		t.Fatalf("reject = %+v, %v", pr, err)
+	}
+	wantReasons := map[string]string{
+		"pr-1": "wrong approach",
+		"pr-2": "dependency pr-1 was rejected",
+		"pr-3": "dependency pr-2 was rejected",
+	}
+	for prID, reason := range wantReasons {
+		stored := f.prs[prID]
+		if stored.Status != domain.StatusRejected || stored.RejectReason != reason {
+			t.Errorf("%s is %s (%q), want rejected with %q", prID, stored.Status, stored.RejectReason, reason)
+		}
+		events := f.events[prID]
+		if len(events) != 1 || events[0].Type != domain.EventRejected || events[0].Reason != reason {
+			t.Errorf("%s events %+v, want one REJECTED event with %q", prID, events, reason)
+		}
+	}
+	if f.prs["pr-4"].Status != domain.StatusOpen || len(f.events["pr-4"]) > 0 || len(f.events["pr-5"]) > 0 {
+		t.Error("PRs outside the stack were touched")
+	}
+
+	if _, err := NewService(f).RejectPR(ctx, &domain.RejectPRRequest{PullRequestID: "pr-1", ReviewerID: "u1"}); !errors.Is(err, domain.ErrPRNotOpen) {
+		t.Fatalf("rejecting again: error = %v, want ErrPRNotOpen", err)
+	}
+}
@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+// activityEventTypes are the PR event types streamed to users, in a stable order.
+var activityEventTypes = func() []domain.PREventType {
+	types := make([]domain.PREventType, 0, len(domain.ActivityEventTypes))
+	for t := range domain.ActivityEventTypes {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}()
+
+// ActivityCursor returns where a new activity stream starts: after the newest event, so only
+// events from now on are sent. A resumed stream passes its Last-Event-ID instead.
+func (s *Service) ActivityCursor(ctx context.Context, userID string) (int64, error) {
+	if _, err := s.getUser(ctx, userID); err != nil {
+		return 0, fmt.Errorf("%w: user not found", domain.ErrNotFound)
+	}
+	return s.storage.GetLatestPREventID(ctx)
+}
+
+// GetUserActivity returns up to MaxActivityBatch assignment, approval, rejection and merge events
+// after afterID on PRs the user authored or reviews (GET /events/stream).
+func (s *Service) GetUserActivity(ctx context.Context, userID string, afterID int64) ([]*domain.PRActivity, error) {
+	activity, err := s.storage.GetUserActivity(ctx, userID, afterID, activityEventTypes, domain.MaxActivityBatch)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range activity {
+		item.Kind = domain.ActivityEventTypes[item.Type]
+	}
+	return activity, nil
+}
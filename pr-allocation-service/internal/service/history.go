@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// recordEvent appends an event to the PR's history.
+// History is best-effort: a failure is logged and never fails the operation itself.
+func (s *Service) recordEvent(
+	ctx context.Context,
+	pr *domain.PullRequest,
+	eventType domain.PREventType,
+	actorID, reason string,
+	decision *domain.AssignmentDecision,
+) {
+	event := &domain.PREvent{
+		PullRequestID: pr.PullRequestID,
+		Type:          eventType,
+		ActorID:       actorID,
+		Reviewers:     slices.Clone(pr.AssignedReviewers),
+		Reason:        reason,
+		Decision:      decision,
+		CreatedAt:     s.now(),
+	}
+	if err := s.storage.AddPREvent(ctx, event); err != nil {
+		logger.FromContext(ctx).Error(ctx, "failed to record PR event", zap.Error(err),
+			zap.String("pr_id", pr.PullRequestID), zap.String("type", string(eventType)))
+	}
+}
+
+// GetPRHistory returns all recorded events of a PR (GET /pullRequest/history).
+func (s *Service) GetPRHistory(ctx context.Context, prID string) ([]*domain.PREvent, error) {
+	if _, err := s.getPR(ctx, prID); err != nil {
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	return s.storage.GetPREvents(ctx, prID)
+}
+
+// ReplayAssignment re-runs the recorded decision of a history event (GET /pullRequest/replay).
+// An eventID of 0 replays the latest event that carries a decision.
+func (s *Service) ReplayAssignment(ctx context.Context, prID string, eventID int64) (*domain.ReplayResponse, error) {
+	log := logger.FromContext(ctx)
+	events, err := s.GetPRHistory(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	var target *domain.PREvent
+	for _, event := range events {
+		if event.Decision == nil {
+			continue
+		}
+		if eventID == 0 || event.EventID == eventID {
+			target = event
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("%w: no assignment decision recorded for this event", domain.ErrNotFound)
+	}
+	replayed, err := runStrategy(target.Decision)
+	if err != nil {
+		return nil, err
+	}
+	response := &domain.ReplayResponse{
+		PullRequestID: prID,
+		EventID:       target.EventID,
+		Decision:      target.Decision,
+		Replayed:      replayed,
+		Matches:       slices.Equal(replayed, target.Decision.Selected),
+	}
+	if !response.Matches {
+		log.Warn(ctx, "replayed assignment differs from recorded one",
+			zap.String("pr_id", prID), zap.Int64("event_id", target.EventID))
+	}
+	return response, nil
+}
+
+// ExplainAssignment shows how the latest assignment of a PR was made (GET /pullRequest/explain):
+// every member of the team pool with the reason they were or weren't a candidate, and the rule that picked the winner.
+func (s *Service) ExplainAssignment(ctx context.Context, prID string) (*domain.ExplainResponse, error) {
+	events, err := s.GetPRHistory(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	var latest *domain.PREvent
+	for _, event := range events {
+		if event.Decision != nil {
+			latest = event
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("%w: no assignment decision recorded for this PR", domain.ErrNotFound)
+	}
+	decision := latest.Decision
+	// The pool as recorded, enriched with the team as it is now
+	var members []*domain.User
+	if decision.TeamID != uuid.Nil {
+		if members, err = s.storage.GetUsersByTeamID(ctx, decision.TeamID); err != nil {
+			return nil, fmt.Errorf("failed to get team members: %w", err)
+		}
+	}
+	usernames := make(map[string]string, len(members))
+	for _, member := range members {
+		usernames[member.UserID] = member.Username
+	}
+	candidates := make([]domain.CandidateExplanation, 0,
+		len(decision.Preselected)+len(decision.Candidates)+len(decision.Excluded))
+	for _, userID := range decision.Preselected {
+		candidates = append(candidates, domain.CandidateExplanation{UserID: userID, Status: domain.CandidateSelected})
+	}
+	for _, userID := range decision.Candidates {
+		status := domain.CandidateEligible
+		if slices.Contains(decision.Selected, userID) {
+			status = domain.CandidateSelected
+		}
+		candidates = append(candidates, domain.CandidateExplanation{
+			UserID:        userID,
+			Status:        status,
+			RecentReviews: decision.RecentReviews[userID],
+		})
+	}
+	for userID, reason := range decision.Excluded {
+		candidates = append(candidates, domain.CandidateExplanation{
+			UserID: userID,
+			Status: domain.CandidateExcluded,
+			Reason: reason,
+		})
+	}
+	for i := range candidates {
+		username, ok := usernames[candidates[i].UserID]
+		candidates[i].Username = username
+		candidates[i].LeftTeam = !ok && decision.TeamID != uuid.Nil
+		candidates[i].MatchedSkills = decision.SkillMatches[candidates[i].UserID]
+		candidates[i].OpenReviews = decision.QueueLoads[candidates[i].UserID]
+		delete(usernames, candidates[i].UserID)
+	}
+	for _, member := range members {
+		if _, ok := usernames[member.UserID]; ok {
+			candidates = append(candidates, domain.CandidateExplanation{
+				UserID:   member.UserID,
+				Username: member.Username,
+				Status:   domain.CandidateJoinedLate,
+			})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b domain.CandidateExplanation) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	return &domain.ExplainResponse{
+		PullRequestID: prID,
+		EventID:       latest.EventID,
+		EventType:     latest.Type,
+		AssignedAt:    latest.CreatedAt,
+		Decision:      decision,
+		Candidates:    candidates,
+		Rule:          describeRule(decision),
+	}, nil
+}
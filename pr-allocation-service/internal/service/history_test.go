@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+func TestExplainAssignment(t *testing.T) {
+	f := newFakeStorage("a1", "u1", "u2", "u3", "gone")
+	f.users["u3"].IsActive = false
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-1", AuthorID: "a1"})
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-2", AuthorID: "a1"})
+	ctx := context.Background()
+	svc := NewService(f)
+	members, _ := f.GetUsersByTeamID(ctx, f.teamID)
+	decision := screenCandidates(f.teamID, members, "a1", nil, &domain.TeamPolicy{}, nil).decide(5, 1)
+	svc.recordEvent(ctx, &domain.PullRequest{PullRequestID: "pr-1", AssignedReviewers: decision.Selected},
+		domain.EventCreated, "a1", "", decision)
+	svc.recordEvent(ctx, &domain.PullRequest{PullRequestID: "pr-1"}, domain.EventApproved, "u1", "", nil)
+	// The team changes after the assignment
+	delete(f.users, "gone")
+	f.addTeam("backend", "late")
+
+	explain, err := svc.ExplainAssignment(ctx, "pr-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if explain.EventID != 1 || explain.EventType != domain.EventCreated {
+		t.Fatalf("explained event %d (%s), want the creation", explain.EventID, explain.EventType)
+	}
+	statuses := make(map[string]domain.CandidateExplanation)
+	for _, candidate := range explain.Candidates {
+		statuses[candidate.UserID] = candidate
+	}
+	selected := decision.Selected[0]
+	tests := []struct {
+		userID   string
+		status   string
+		reason   domain.ExclusionReason
+		leftTeam bool
+	}{
+		{userID: "a1", status: domain.CandidateExcluded, reason: domain.ExcludedAuthor},
+		{userID: "u3", status: domain.CandidateExcluded, reason: domain.ExcludedInactive},
+		{userID: selected, status: domain.CandidateSelected, leftTeam: selected == "gone"},
+		{userID: "late", status: domain.CandidateJoinedLate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.userID, func(t *testing.T) {
+			got, ok := statuses[tt.userID]
+			if !ok {
+				t.Fatalf("%s missing from %+v", tt.userID, explain.Candidates)
+			}
+			if got.Status != tt.status || got.Reason != tt.reason || got.LeftTeam != tt.leftTeam {
+				t.Fatalf("%s explained as %+v, want status %s reason %q", tt.userID, got, tt.status, tt.reason)
+			}
+		})
+	}
+	if selected != "gone" {
+		if got := statuses["gone"]; got.Status != domain.CandidateEligible || !got.LeftTeam {
+			t.Errorf("gone explained as %+v, want an eligible candidate who left the team", got)
+		}
+	}
+	if _, err := svc.ExplainAssignment(ctx, "pr-2"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("explain without a decision: error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDescribeRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision domain.AssignmentDecision
+		contains []string
+	}{
+		{
+			name:     "random",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Seed: 42, Slots: 2, Candidates: []string{"u1", "u2", "u3"}},
+			contains: []string{"random: 3 eligible candidates", "seed 42", "first 2 taken"},
+		},
+		{
+			name:     "no candidates",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Slots: 2},
+			contains: []string{"no eligible candidates"},
+		},
+		{
+			name: "routed",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Slots: 1, Candidates: []string{"u1"},
+				RoutedBy: "db"},
+			contains: []string{`routed by label "db"`, "random:"},
+		},
+		{
+			name: "on-call fills every slot",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Slots: 1, OnCall: "u1",
+				Preselected: []string{"u1"}},
+			contains: []string{"on-call reviewer u1"},
+		},
+		{
+			name: "skill fallback",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Slots: 1, Candidates: []string{"u1"},
+				RequiredSkills: []string{"go"}},
+			contains: []string{"nobody covers the required skills (go)", "random:"},
+		},
+		{
+			name: "lightest queue",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyLightestQueue, Seed: 1, Slots: 1,
+				Candidates: []string{"u1", "u2"}},
+			contains: []string{"lightest queue (urgent PR): 1 of 2"},
+		},
+		{
+			name: "weighted",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyWeighted, Seed: 1, Slots: 1,
+				Candidates: []string{"u1", "u2"}},
+			contains: []string{"weighted (anti-affinity): 1 drawn from 2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := describeRule(&tt.decision)
+			for _, part := range tt.contains {
+				if !strings.Contains(rule, part) {
+					t.Errorf("rule %q does not mention %q", rule, part)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// tagPattern is the accepted format of labels and skills after normalization.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._/:-]{0,49}$`)
+
+// normalizeTags lowercases, validates, sorts and de-duplicates labels or skills; kind names them in errors.
+func normalizeTags(kind string, tags []string) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if !tagPattern.MatchString(tag) {
+			return nil, fmt.Errorf("%w: invalid %s %q", domain.ErrInvalidRequest, kind, tag)
+		}
+		normalized = append(normalized, tag)
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized), nil
+}
+
+// mergeTags returns the sorted union of two normalized tag lists.
+func mergeTags(current, tags []string) []string {
+	merged := append(slices.Clone(current), tags...)
+	slices.Sort(merged)
+	return slices.Compact(merged)
+}
+
+// subtractTags returns current without the given tags.
+func subtractTags(current, tags []string) []string {
+	return slices.DeleteFunc(slices.Clone(current), func(tag string) bool {
+		return slices.Contains(tags, tag)
+	})
+}
+
+// AddPRLabels adds labels to a PR (POST /pullRequest/addLabels).
+func (s *Service) AddPRLabels(ctx context.Context, req *domain.PRLabelsRequest) (*domain.PullRequest, error) {
+	return s.changePRLabels(ctx, req, mergeTags)
+}
+
+// RemovePRLabels removes labels from a PR (POST /pullRequest/removeLabels).
+func (s *Service) RemovePRLabels(ctx context.Context, req *domain.PRLabelsRequest) (*domain.PullRequest, error) {
+	return s.changePRLabels(ctx, req, subtractTags)
+}
+
+// SetPRLabels replaces all labels of a PR (POST /pullRequest/setLabels).
+func (s *Service) SetPRLabels(ctx context.Context, req *domain.PRLabelsRequest) (*domain.PullRequest, error) {
+	return s.changePRLabels(ctx, req, func(_, labels []string) []string {
+		return labels
+	})
+}
+
+// changePRLabels applies a label change and records it in the PR history when something changed.
+// Reviewers are not re-routed: rules apply to the next assignment.
+func (s *Service) changePRLabels(
+	ctx context.Context,
+	req *domain.PRLabelsRequest,
+	change func(current, labels []string) []string,
+) (*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	labels, err := normalizeTags("label", req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := s.getPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	updated := change(pr.Labels, labels)
+	if slices.Equal(updated, pr.Labels) {
+		return pr, nil
+	}
+	pr.Labels = updated
+	if err := s.storage.UpdatePR(ctx, pr); err != nil {
+		log.Error(ctx, "failed to update PR labels", zap.Error(err))
+		return nil, err
+	}
+	s.recordEvent(ctx, pr, domain.EventLabelsChanged, "", "labels: "+strings.Join(pr.Labels, ","), nil)
+	log.Info(ctx, "PR labels changed", zap.String("pr_id", pr.PullRequestID), zap.Strings("labels", pr.Labels))
+	return pr, nil
+}
+
+// GetRoutingRules returns the team's label routing rules (GET /team/getRoutingRules).
+func (s *Service) GetRoutingRules(ctx context.Context, teamName string) ([]domain.RoutingRule, error) {
+	teamID, err := s.storage.GetTeamIDByName(ctx, organizationID(ctx), teamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	return s.storage.GetRoutingRules(ctx, teamID)
+}
+
+// SetRoutingRules replaces the team's label routing rules (POST /team/setRoutingRules).
+// Every rule needs a distinct label and a non-empty pool of team members.
+func (s *Service) SetRoutingRules(ctx context.Context, req *domain.SetRoutingRulesRequest) ([]domain.RoutingRule, error) {
+	log := logger.FromContext(ctx)
+	team, err := s.storage.GetTeam(ctx, organizationID(ctx), req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, team.ID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	members := make(map[string]bool, len(team.Members))
+	for _, member := range team.Members {
+		members[member.UserID] = true
+	}
+	rules := make([]domain.RoutingRule, 0, len(req.Rules))
+	seen := make(map[string]bool, len(req.Rules))
+	for _, rule := range req.Rules {
+		labels, err := normalizeTags("label", []string{rule.Label})
+		if err != nil {
+			return nil, err
+		}
+		label := labels[0]
+		if seen[label] {
+			return nil, fmt.Errorf("%w: duplicate rule for label %q", domain.ErrInvalidRequest, label)
+		}
+		seen[label] = true
+		if len(rule.ReviewerIDs) == 0 {
+			return nil, fmt.Errorf("%w: rule for label %q has no reviewers", domain.ErrInvalidRequest, label)
+		}
+		for _, userID := range rule.ReviewerIDs {
+			if !members[userID] {
+				return nil, fmt.Errorf("%w: user %s is not a member of team %s", domain.ErrInvalidRequest, userID, req.TeamName)
+			}
+		}
+		reviewerIDs := slices.Clone(rule.ReviewerIDs)
+		slices.Sort(reviewerIDs)
+		rules = append(rules, domain.RoutingRule{Label: label, ReviewerIDs: slices.Compact(reviewerIDs)})
+	}
+	if err := s.storage.SetRoutingRules(ctx, team.ID, rules); err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "routing rules set", zap.String("team_name", req.TeamName), zap.Int("rules", len(rules)))
+	return rules, nil
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type organizationKey struct{}
+
+// WithOrganization scopes ctx to an organization: team names, users, PRs and statistics
+// are looked up within it only.
+func WithOrganization(ctx context.Context, org *domain.Organization) context.Context {
+	return context.WithValue(ctx, organizationKey{}, org)
+}
+
+// organizationID returns the organization ctx is scoped to. Unscoped contexts get uuid.Nil,
+// which owns nothing, so they never see another organization's data.
+func organizationID(ctx context.Context) uuid.UUID {
+	if org, ok := ctx.Value(organizationKey{}).(*domain.Organization); ok {
+		return org.ID
+	}
+	return uuid.Nil
+}
+
+// CreateOrganization creates an empty organization (POST /org/add).
+func (s *Service) CreateOrganization(ctx context.Context, req *domain.CreateOrganizationRequest) (*domain.Organization, error) {
+	log := logger.FromContext(ctx)
+	log.Info(ctx, "creating organization", zap.String("org_name", req.OrgName))
+	if _, err := s.storage.GetOrganization(ctx, req.OrgName); err == nil {
+		return nil, fmt.Errorf("%w: organization already exists", domain.ErrOrgExists)
+	}
+	org := &domain.Organization{OrgName: req.OrgName}
+	if err := s.storage.CreateOrganization(ctx, org); err != nil {
+		log.Error(ctx, "failed to create organization", zap.Error(err))
+		return nil, err
+	}
+	return org, nil
+}
+
+// GetOrganization resolves an organization by name.
+func (s *Service) GetOrganization(ctx context.Context, orgName string) (*domain.Organization, error) {
+	org, err := s.storage.GetOrganization(ctx, orgName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: organization not found", domain.ErrNotFound)
+	}
+	return org, nil
+}
+
+// ListOrganizations returns all organizations (GET /org/list).
+func (s *Service) ListOrganizations(ctx context.Context) ([]*domain.Organization, error) {
+	return s.storage.ListOrganizations(ctx)
+}
+
+// getUser returns the user if they belong to a team of the request's organization.
+func (s *Service) getUser(ctx context.Context, userID string) (*domain.User, error) {
+	user, err := s.storage.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.OrganizationID != organizationID(ctx) {
+		return nil, errors.New("user not found")
+	}
+	return user, nil
+}
+
+// getPR returns the PR if it was opened in the request's organization.
+func (s *Service) getPR(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	pr, err := s.storage.GetPR(ctx, prID)
+	if err != nil {
+		return nil, err
+	}
+	if pr.OrganizationID != organizationID(ctx) {
+		return nil, errors.New("PR not found")
+	}
+	return pr, nil
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// GetTeamPolicy returns the team's reviewer assignment policy (GET /team/getPolicy).
+func (s *Service) GetTeamPolicy(ctx context.Context, teamName string) (*domain.TeamPolicy, error) {
+	teamID, err := s.storage.GetTeamIDByName(ctx, organizationID(ctx), teamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	policy, err := s.storage.GetTeamPolicy(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	policy.TeamName = teamName
+	return policy, nil
+}
+
+// SetTeamPolicy updates the fields present in the request (POST /team/setPolicy).
+func (s *Service) SetTeamPolicy(ctx context.Context, req *domain.SetTeamPolicyRequest) (*domain.TeamPolicy, error) {
+	log := logger.FromContext(ctx)
+	teamID, err := s.storage.GetTeamIDByName(ctx, organizationID(ctx), req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, teamID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	policy, err := s.storage.GetTeamPolicy(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if req.MaxOpenReviews != nil {
+		if *req.MaxOpenReviews < 0 {
+			return nil, fmt.Errorf("%w: max_open_reviews must not be negative", domain.ErrInvalidRequest)
+		}
+		policy.MaxOpenReviews = *req.MaxOpenReviews
+	}
+	if req.AntiAffinityDays != nil {
+		if *req.AntiAffinityDays < 0 {
+			return nil, fmt.Errorf("%w: anti_affinity_days must not be negative", domain.ErrInvalidRequest)
+		}
+		policy.AntiAffinityDays = *req.AntiAffinityDays
+	}
+	if req.DismissStaleApprovals != nil {
+		policy.DismissStaleApprovals = *req.DismissStaleApprovals
+	}
+	if err := s.storage.SetTeamPolicy(ctx, teamID, policy); err != nil {
+		return nil, err
+	}
+	policy.TeamName = req.TeamName
+	log.Info(ctx, "team policy set", zap.String("team_name", req.TeamName),
+		zap.Int("max_open_reviews", policy.MaxOpenReviews), zap.Int("anti_affinity_days", policy.AntiAffinityDays),
+		zap.Bool("dismiss_stale_approvals", policy.DismissStaleApprovals))
+	return policy, nil
+}
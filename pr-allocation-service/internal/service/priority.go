@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// SetPRPriority changes a PR's priority and due date (POST /pullRequest/setPriority).
+// Current reviewers are kept; urgency only affects the next assignment.
+func (s *Service) SetPRPriority(ctx context.Context, req *domain.PRPriorityRequest) (*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	if !req.Priority.Valid() {
+		return nil, fmt.Errorf("%w: priority must be one of P0, P1, P2, P3", domain.ErrInvalidRequest)
+	}
+	pr, err := s.getPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	pr.Priority = req.Priority
+	pr.DueAt = req.DueAt
+	if err := s.storage.UpdatePR(ctx, pr); err != nil {
+		log.Error(ctx, "failed to update PR priority", zap.Error(err))
+		return nil, err
+	}
+	reason := "priority: " + string(pr.Priority)
+	if pr.DueAt != nil {
+		reason += ", due " + pr.DueAt.UTC().Format(time.RFC3339)
+	}
+	s.recordEvent(ctx, pr, domain.EventPriorityChanged, "", reason, nil)
+	log.Info(ctx, "PR priority changed", zap.String("pr_id", pr.PullRequestID), zap.String("priority", string(pr.Priority)))
+	return pr, nil
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// AnonymizeUser removes a departed user (POST /users/anonymize): their ID is replaced by a random
+// anonymous ID in the users table, PRs, history, routing rules and rotation rosters, so statistics keep their totals.
+// The user must be deactivated first, which hands their open reviews over to others.
+// Only an admin of the user's team may do it.
+func (s *Service) AnonymizeUser(ctx context.Context, req *domain.AnonymizeUserRequest) (*domain.AnonymizationReport, error) {
+	log := logger.FromContext(ctx)
+	user, err := s.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, user.TeamID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if user.IsActive {
+		return nil, fmt.Errorf("%w: user is active, deactivate them first", domain.ErrInvalidRequest)
+	}
+
+	report, err := s.storage.AnonymizeUser(ctx, user.UserID, "anon-"+uuid.NewString())
+	if err != nil {
+		log.Error(ctx, "failed to anonymize user", zap.Error(err))
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+func TestAnonymizeUserRequiresAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string
+		wantErr error
+	}{
+		{name: "no actor", wantErr: domain.ErrUnauthorized},
+		{name: "member", actor: "u1", wantErr: domain.ErrForbidden},
+		{name: "maintainer", actor: "m1", wantErr: domain.ErrForbidden},
+		{name: "admin of another team", actor: "other", wantErr: domain.ErrForbidden},
+		{name: "team admin", actor: "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeStorage("admin", "m1", "u1", "gone")
+			f.users["admin"].Role = domain.RoleAdmin
+			f.users["m1"].Role = domain.RoleMaintainer
+			f.users["gone"].IsActive = false
+			f.addTeam("frontend", "other")
+			f.users["other"].Role = domain.RoleAdmin
+			ctx := context.Background()
+			if tt.actor != "" {
+				ctx = WithActor(ctx, tt.actor)
+			}
+			report, err := NewService(f).AnonymizeUser(ctx, &domain.AnonymizeUserRequest{UserID: "gone"})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if _, ok := f.users["gone"]; !ok {
+					t.Fatal("user anonymized despite the error")
+				}
+				return
+			}
+			if err != nil || report.UserID != "gone" {
+				t.Fatalf("report = %+v, %v", report, err)
+			}
+		})
+	}
+}
+
+func TestAssignmentDecisionReplaceUser(t *testing.T) {
+	// The user's ID is also a skill and a routing label; only the fields holding user IDs may change
+	decision := &domain.AssignmentDecision{
+		Strategy:       "anti_affinity",
+		Slots:          2,
+		Candidates:     []string{"u2", "go"},
+		Selected:       []string{"go", "u2"},
+		Excluded:       map[string]domain.ExclusionReason{"go": domain.ExcludedOverCap, "u3": domain.ExcludedSkillGap},
+		RecentReviews:  map[string]int{"go": 3, "u2": 1},
+		RoutedBy:       "go",
+		RequiredSkills: []string{"go"},
+		SkillMatches:   map[string][]string{"go": {"go"}, "u2": {"go"}},
+		Preselected:    []string{"go"},
+		QueueLoads:     map[string]int{"go": 2},
+		OnCall:         "go",
+	}
+	want := &domain.AssignmentDecision{
+		Strategy:       "anti_affinity",
+		Slots:          2,
+		Candidates:     []string{"u2", "anon-1"},
+		Selected:       []string{"anon-1", "u2"},
+		Excluded:       map[string]domain.ExclusionReason{"anon-1": domain.ExcludedOverCap, "u3": domain.ExcludedSkillGap},
+		RecentReviews:  map[string]int{"anon-1": 3, "u2": 1},
+		RoutedBy:       "go",
+		RequiredSkills: []string{"go"},
+		SkillMatches:   map[string][]string{"anon-1": {"go"}, "u2": {"go"}},
+		Preselected:    []string{"anon-1"},
+		QueueLoads:     map[string]int{"anon-1": 2},
+		OnCall:         "anon-1",
+	}
+	if !decision.ReplaceUser("go", "anon-1") {
+		t.Fatal("ReplaceUser() = false, want the decision changed")
+	}
+	got, _ := json.Marshal(decision)
+	wantJSON, _ := json.Marshal(want)
+	if string(got) != string(wantJSON) {
+		t.Fatalf("decision\n%s\nwant\n%s", got, wantJSON)
+	}
+
+	unrelated := &domain.AssignmentDecision{Strategy: "round_robin", Candidates: []string{"u2"}, Selected: []string{"u2"},
+		RoutedBy: "gone", RequiredSkills: []string{"gone"}, SkillMatches: map[string][]string{"u2": {"gone"}}}
+	before, _ := json.Marshal(unrelated)
+	if unrelated.ReplaceUser("gone", "anon-2") {
+		t.Fatal("ReplaceUser() = true for a decision that only mentions the ID as a label and a skill")
+	}
+	if after, _ := json.Marshal(unrelated); string(after) != string(before) {
+		t.Fatalf("decision changed to %s, want %s", after, before)
+	}
+}
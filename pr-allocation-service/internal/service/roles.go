@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+type actorKey struct{}
+
+// WithActor records the user on whose behalf ctx's request is made.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+func actorID(ctx context.Context) string {
+	userID, _ := ctx.Value(actorKey{}).(string)
+	return userID
+}
+
+// authorize checks that the request's actor is an active member of the team with at least the required role.
+func (s *Service) authorize(ctx context.Context, teamID uuid.UUID, required domain.Role) error {
+	userID := actorID(ctx)
+	if userID == "" {
+		return fmt.Errorf("%w: %s header is required", domain.ErrUnauthorized, domain.ActorHeader)
+	}
+	actor, err := s.getUser(ctx, userID)
+	if err != nil || actor.TeamID != teamID {
+		return fmt.Errorf("%w: %s is not a member of the team", domain.ErrForbidden, userID)
+	}
+	if !actor.IsActive {
+		return fmt.Errorf("%w: %s is inactive", domain.ErrForbidden, userID)
+	}
+	if !actor.Role.AtLeast(required) {
+		return fmt.Errorf("%w: %s role required", domain.ErrForbidden, required)
+	}
+	return nil
+}
+
+// authorizePR checks the actor's role in the team of the PR's author.
+func (s *Service) authorizePR(ctx context.Context, pr *domain.PullRequest, required domain.Role) error {
+	author, err := s.storage.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return fmt.Errorf("%w: PR author not found", domain.ErrNotFound)
+	}
+	return s.authorize(ctx, author.TeamID, required)
+}
+
+// assignRoles validates the requested member roles, defaulting to member,
+// and makes the first member admin when nobody else is.
+func assignRoles(members []domain.TeamMember) error {
+	hasAdmin := false
+	for i := range members {
+		if members[i].Role == "" {
+			members[i].Role = domain.RoleMember
+		}
+		if !members[i].Role.Valid() {
+			return fmt.Errorf("%w: unknown role %q", domain.ErrInvalidRequest, members[i].Role)
+		}
+		hasAdmin = hasAdmin || members[i].Role == domain.RoleAdmin
+	}
+	if !hasAdmin && len(members) > 0 {
+		members[0].Role = domain.RoleAdmin
+	}
+	return nil
+}
+
+// SetMemberRole changes a team member's role (POST /team/setMemberRole). Only admins may do it,
+// and the team always keeps at least one admin.
+func (s *Service) SetMemberRole(ctx context.Context, req *domain.SetMemberRoleRequest) (*domain.User, error) {
+	log := logger.FromContext(ctx)
+	if !req.Role.Valid() {
+		return nil, fmt.Errorf("%w: unknown role %q", domain.ErrInvalidRequest, req.Role)
+	}
+	team, err := s.storage.GetTeam(ctx, organizationID(ctx), req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, team.ID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	user, err := s.getUser(ctx, req.UserID)
+	if err != nil || user.TeamID != team.ID {
+		return nil, fmt.Errorf("%w: user %s is not a member of team %s", domain.ErrNotFound, req.UserID, req.TeamName)
+	}
+	if user.Role == domain.RoleAdmin && req.Role != domain.RoleAdmin {
+		admins := 0
+		for _, member := range team.Members {
+			if member.Role == domain.RoleAdmin {
+				admins++
+			}
+		}
+		if admins == 1 {
+			return nil, fmt.Errorf("%w: team must keep at least one admin", domain.ErrInvalidRequest)
+		}
+	}
+	if err := s.storage.SetUserRole(ctx, user.UserID, req.Role); err != nil {
+		log.Error(ctx, "failed to set member role", zap.Error(err))
+		return nil, err
+	}
+	user.Role = req.Role
+	log.Info(ctx, "member role set", zap.String("team_name", req.TeamName),
+		zap.String("user_id", user.UserID), zap.String("role", string(req.Role)), zap.String("actor", actorID(ctx)))
+	return user, nil
+}
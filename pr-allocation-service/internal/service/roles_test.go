@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+func TestTeamOperationsRequireRole(t *testing.T) {
+	operations := []struct {
+		name string
+		run  func(ctx context.Context, svc *Service) error
+		// allowed are the actors that may run the operation
+		allowed []string
+	}{
+		{
+			name: "set user active",
+			run: func(ctx context.Context, svc *Service) error {
+				_, err := svc.SetUserActive(ctx, &domain.SetUserActiveRequest{UserID: "u1", IsActive: false})
+				return err
+			},
+			allowed: []string{"m1", "admin"},
+		},
+		{
+			name: "bulk activate",
+			run: func(ctx context.Context, svc *Service) error {
+				_, err := svc.BulkActivateTeamUsers(ctx, &domain.BulkActivateRequest{TeamName: "backend"})
+				return err
+			},
+			allowed: []string{"admin"},
+		},
+		{
+			name: "set dependencies",
+			run: func(ctx context.Context, svc *Service) error {
+				_, err := svc.SetPRDependencies(ctx, &domain.PRDependenciesRequest{PullRequestID: "pr-2", DependsOn: []string{"pr-1"}})
+				return err
+			},
+			allowed: []string{"a1", "m1", "admin"},
+		},
+	}
+	actors := []struct {
+		actor   string
+		wantErr error
+	}{
+		{actor: "", wantErr: domain.ErrUnauthorized},
+		{actor: "u1", wantErr: domain.ErrForbidden},
+		{actor: "a1", wantErr: domain.ErrForbidden},
+		{actor: "m1", wantErr: domain.ErrForbidden},
+		{actor: "admin", wantErr: domain.ErrForbidden},
+		{actor: "other", wantErr: domain.ErrForbidden},
+	}
+	for _, op := range operations {
+		for _, tt := range actors {
+			t.Run(op.name+"/"+tt.actor, func(t *testing.T) {
+				f := newFakeStorage("admin", "m1", "u1", "a1")
+				f.users["admin"].Role = domain.RoleAdmin
+				f.users["m1"].Role = domain.RoleMaintainer
+				f.addTeam("frontend", "other")
+				f.users["other"].Role = domain.RoleAdmin
+				f.addPR(&domain.PullRequest{PullRequestID: "pr-1", AuthorID: "a1"})
+				f.addPR(&domain.PullRequest{PullRequestID: "pr-2", AuthorID: "a1"})
+				ctx := context.Background()
+				if tt.actor != "" {
+					ctx = WithActor(ctx, tt.actor)
+				}
+				err := op.run(ctx, NewService(f))
+				if slices.Contains(op.allowed, tt.actor) {
+					if err != nil {
+						t.Fatalf("error = %v, want the operation to succeed", err)
+					}
+					return
+				}
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if !f.users["u1"].IsActive || len(f.prs["pr-2"].DependsOn) > 0 {
+					t.Fatal("state changed despite the error")
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetRotation returns the team's on-call rotation (GET /team/getRotation).
+func (s *Service) GetRotation(ctx context.Context, teamName string) (*domain.RotationSchedule, error) {
+	teamID, err := s.storage.GetTeamIDByName(ctx, organizationID(ctx), teamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	rotation, err := s.storage.GetRotation(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	if rotation == nil {
+		return nil, fmt.Errorf("%w: team %s has no rotation", domain.ErrNotFound, teamName)
+	}
+	rotation.TeamName = teamName
+	return rotation, nil
+}
+
+// SetRotation replaces the team's on-call rotation, or removes it when the roster is empty (POST /team/setRotation).
+// The roster must list distinct team members; only admins may change it. A removed rotation is returned as nil.
+func (s *Service) SetRotation(ctx context.Context, req *domain.SetRotationRequest) (*domain.RotationSchedule, error) {
+	log := logger.FromContext(ctx)
+	team, err := s.storage.GetTeam(ctx, organizationID(ctx), req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, team.ID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	if len(req.Roster) == 0 {
+		if err := s.storage.DeleteRotation(ctx, team.ID); err != nil {
+			return nil, err
+		}
+		log.Info(ctx, "rotation removed", zap.String("team_name", req.TeamName))
+		return nil, nil
+	}
+	if req.PeriodDays <= 0 || req.PeriodDays > domain.MaxRotationPeriodDays {
+		return nil, fmt.Errorf("%w: period_days must be between 1 and %d", domain.ErrInvalidRequest, domain.MaxRotationPeriodDays)
+	}
+	for i, userID := range req.Roster {
+		if slices.Contains(req.Roster[:i], userID) {
+			return nil, fmt.Errorf("%w: %s appears in the roster more than once", domain.ErrInvalidRequest, userID)
+		}
+		if !slices.ContainsFunc(team.Members, func(member domain.TeamMember) bool { return member.UserID == userID }) {
+			return nil, fmt.Errorf("%w: %s is not a member of team %s", domain.ErrInvalidRequest, userID, req.TeamName)
+		}
+	}
+	rotation := &domain.RotationSchedule{
+		TeamName:   req.TeamName,
+		Roster:     req.Roster,
+		PeriodDays: req.PeriodDays,
+		HandoverAt: s.now(),
+	}
+	if req.HandoverAt != nil {
+		rotation.HandoverAt = *req.HandoverAt
+	}
+	rotation.HandoverAt = rotation.HandoverAt.UTC()
+	if err := s.storage.SetRotation(ctx, team.ID, rotation); err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "rotation set", zap.String("team_name", req.TeamName), zap.Strings("roster", rotation.Roster),
+		zap.Int("period_days", rotation.PeriodDays), zap.Time("handover_at", rotation.HandoverAt))
+	return rotation, nil
+}
+
+// GetOnCall returns the team's current and next on-call reviewers (GET /team/onCall).
+func (s *Service) GetOnCall(ctx context.Context, teamName string) (*domain.OnCallResponse, error) {
+	rotation, err := s.GetRotation(ctx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	return &domain.OnCallResponse{
+		TeamName: teamName,
+		Current:  rotationShift(rotation, now, 0),
+		Next:     rotationShift(rotation, now, 1),
+	}, nil
+}
+
+// rotationShift returns the shift offset turns after the one covering at. Turns before the handover
+// count backwards from it, so a handover in the future still has a current shift.
+func rotationShift(rotation *domain.RotationSchedule, at time.Time, offset int) domain.OnCallShift {
+	period := time.Duration(rotation.PeriodDays) * 24 * time.Hour
+	elapsed := at.Sub(rotation.HandoverAt)
+	turn := int(elapsed / period)
+	if elapsed%period < 0 {
+		turn--
+	}
+	turn += offset
+	n := len(rotation.Roster)
+	start := rotation.HandoverAt.Add(time.Duration(turn) * period)
+	return domain.OnCallShift{
+		UserID:   rotation.Roster[(turn%n+n)%n],
+		StartsAt: start,
+		EndsAt:   start.Add(period),
+	}
+}
+
+// currentOnCall returns who is on call in the team's rotation now, or "" if the team has no rotation.
+func (s *Service) currentOnCall(ctx context.Context, teamID uuid.UUID) (string, error) {
+	rotation, err := s.storage.GetRotation(ctx, teamID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get rotation: %w", err)
+	}
+	if rotation == nil || len(rotation.Roster) == 0 {
+		return "", nil
+	}
+	return rotationShift(rotation, s.now(), 0).UserID, nil
+}
+
+// takeOnCall reserves a reviewer slot for the on-call reviewer if they are still a candidate.
+// An on-call reviewer who was screened out (author, inactive, over the cap, outside the routed pool
+// or already assigned) is skipped, and the strategy fills every slot.
+func (p *reviewerPool) takeOnCall(userID string) {
+	if slices.ContainsFunc(p.candidates, func(candidate *domain.User) bool { return candidate.UserID == userID }) {
+		p.onCall = userID
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+func TestRotationShift(t *testing.T) {
+	handover := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	weekly := &domain.RotationSchedule{Roster: []string{"u1", "u2", "u3"}, PeriodDays: 7, HandoverAt: handover}
+	longest := &domain.RotationSchedule{Roster: []string{"u1", "u2"}, PeriodDays: domain.MaxRotationPeriodDays, HandoverAt: handover}
+	tests := []struct {
+		name      string
+		rotation  *domain.RotationSchedule
+		at        time.Time
+		offset    int
+		wantUser  string
+		wantStart time.Time
+	}{
+		{name: "at the handover", rotation: weekly, at: handover, wantUser: "u1", wantStart: handover},
+		{name: "last moment of the first turn", rotation: weekly, at: handover.Add(7*day - time.Nanosecond), wantUser: "u1", wantStart: handover},
+		{name: "second turn", rotation: weekly, at: handover.Add(7 * day), wantUser: "u2", wantStart: handover.Add(7 * day)},
+		{name: "roster wraps around", rotation: weekly, at: handover.Add(22 * day), wantUser: "u1", wantStart: handover.Add(21 * day)},
+		{name: "next shift", rotation: weekly, at: handover.Add(8 * day), offset: 1, wantUser: "u3", wantStart: handover.Add(14 * day)},
+		{name: "handover a moment away", rotation: weekly, at: handover.Add(-time.Nanosecond), wantUser: "u3", wantStart: handover.Add(-7 * day)},
+		{name: "handover weeks away", rotation: weekly, at: handover.Add(-15 * day), wantUser: "u1", wantStart: handover.Add(-21 * day)},
+		{name: "next shift before the handover", rotation: weekly, at: handover.Add(-15 * day), offset: 1, wantUser: "u2", wantStart: handover.Add(-14 * day)},
+		{name: "longest period", rotation: longest, at: handover.Add(400 * day), wantUser: "u2", wantStart: handover.Add(365 * day)},
+		{name: "longest period, handover decades away", rotation: longest, at: handover.AddDate(-50, 0, 0), wantUser: "u2", wantStart: handover.Add(-51 * 365 * day)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shift := rotationShift(tt.rotation, tt.at, tt.offset)
+			period := time.Duration(tt.rotation.PeriodDays) * day
+			if shift.UserID != tt.wantUser || !shift.StartsAt.Equal(tt.wantStart) || !shift.EndsAt.Equal(tt.wantStart.Add(period)) {
+				t.Fatalf("shift = %s from %s to %s, want %s from %s", shift.UserID, shift.StartsAt, shift.EndsAt, tt.wantUser, tt.wantStart)
+			}
+		})
+	}
+}
+
+func TestSetRotationPeriodBounds(t *testing.T) {
+	tests := []struct {
+		periodDays int
+		wantErr    bool
+	}{
+		{periodDays: 0, wantErr: true},
+		{periodDays: 1},
+		{periodDays: domain.MaxRotationPeriodDays},
+		{periodDays: domain.MaxRotationPeriodDays + 1, wantErr: true},
+		{periodDays: 200_000, wantErr: true},
+	}
+	for _, tt := range tests {
+		f := newFakeStorage("admin", "u1")
+		f.users["admin"].Role = domain.RoleAdmin
+		ctx := WithActor(context.Background(), "admin")
+		_, err := NewService(f).SetRotation(ctx, &domain.SetRotationRequest{TeamName: "backend", Roster: []string{"u1"}, PeriodDays: tt.periodDays})
+		if tt.wantErr {
+			if !errors.Is(err, domain.ErrInvalidRequest) || f.rotation != nil {
+				t.Errorf("period_days %d: error = %v, rotation %+v; want ErrInvalidRequest and nothing stored", tt.periodDays, err, f.rotation)
+			}
+		} else if err != nil || f.rotation == nil {
+			t.Errorf("period_days %d: error = %v, want the rotation stored", tt.periodDays, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// SearchPRs runs a ranked full-text search over pull requests (GET /pullRequest/search).
+func (s *Service) SearchPRs(ctx context.Context, q *domain.PRSearchQuery) (*domain.PRSearchResponse, error) {
+	log := logger.FromContext(ctx)
+	q.Query = strings.TrimSpace(q.Query)
+	switch {
+	case q.Limit == 0:
+		q.Limit = domain.DefaultSearchLimit
+	case q.Limit < 0 || q.Limit > domain.MaxSearchLimit:
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", domain.ErrInvalidRequest, domain.MaxSearchLimit)
+	}
+	if q.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", domain.ErrInvalidRequest)
+	}
+	switch q.Status {
+	case "", domain.StatusOpen, domain.StatusMerged, domain.StatusRejected:
+	default:
+		return nil, fmt.Errorf("%w: status must be one of OPEN, MERGED, REJECTED", domain.ErrInvalidRequest)
+	}
+	q.OrganizationID = organizationID(ctx)
+	if q.TeamName != "" {
+		exists, err := s.storage.TeamExists(ctx, q.OrganizationID, q.TeamName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check team: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+		}
+	}
+
+	hits, total, err := s.storage.SearchPRs(ctx, q)
+	if err != nil {
+		log.Error(ctx, "failed to search PRs", zap.Error(err))
+		return nil, err
+	}
+	return &domain.PRSearchResponse{
+		Results: hits,
+		Total:   total,
+		Limit:   q.Limit,
+		Offset:  q.Offset,
+	}, nil
+}
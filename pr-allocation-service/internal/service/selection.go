@@ -0,0 +1,469 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// Clock returns the current time.
+type Clock func() time.Time
+
+// SeedSource returns a fresh seed for one reviewer selection.
+type SeedSource func() uint64
+
+// Option configures a Service.
+type Option func(*Service)
+
+// WithClock replaces the wall clock used for timestamps.
+func WithClock(clock Clock) Option {
+	return func(s *Service) {
+		s.now = clock
+	}
+}
+
+// WithSeedSource replaces the source of selection seeds, e.g. with a fixed sequence in tests.
+func WithSeedSource(seeds SeedSource) Option {
+	return func(s *Service) {
+		s.seeds = seeds
+	}
+}
+
+// reviewerPool is a team screened for one assignment.
+type reviewerPool struct {
+	teamID         uuid.UUID
+	maxOpenReviews int
+	strategy       string
+	candidates     []*domain.User
+	excluded       map[string]domain.ExclusionReason
+	recentReviews  map[string]int
+	routedBy       string
+	requiredSkills []string
+	loads          map[string]int
+	// onCall is the rotation's on-call reviewer, given the first slot ahead of the strategy
+	onCall string
+}
+
+// slotRequest describes the PR whose reviewer slots are being filled.
+type slotRequest struct {
+	authorID string
+	// assigned reviewers can't be picked again; kept ones stay on the PR
+	assigned       []string
+	kept           []string
+	labels         []string
+	requiredSkills []string
+	urgent         bool
+}
+
+// screenCandidates splits team members into reviewer candidates and excluded members with the reason.
+// loads is only consulted when the policy caps open reviews or the PR is urgent.
+func screenCandidates(
+	teamID uuid.UUID,
+	members []*domain.User,
+	authorID string,
+	assigned []string,
+	policy *domain.TeamPolicy,
+	loads map[string]int,
+) *reviewerPool {
+	pool := &reviewerPool{
+		teamID:         teamID,
+		maxOpenReviews: policy.MaxOpenReviews,
+		strategy:       domain.StrategyRandom,
+		candidates:     make([]*domain.User, 0, len(members)),
+		excluded:       make(map[string]domain.ExclusionReason),
+		loads:          loads,
+	}
+	for _, member := range members {
+		switch {
+		case member.UserID == authorID:
+			pool.excluded[member.UserID] = domain.ExcludedAuthor
+		case slices.Contains(assigned, member.UserID):
+			pool.excluded[member.UserID] = domain.ExcludedAlreadyAssigned
+		case !member.IsActive:
+			pool.excluded[member.UserID] = domain.ExcludedInactive
+		case policy.MaxOpenReviews > 0 && loads[member.UserID] >= policy.MaxOpenReviews:
+			pool.excluded[member.UserID] = domain.ExcludedOverCap
+		default:
+			pool.candidates = append(pool.candidates, member)
+		}
+	}
+	return pool
+}
+
+// screenTeam loads the team's policy (and review loads if capped or urgent) and screens its members,
+// then applies label routing, the on-call rotation and either the lightest-queue preference (urgent PRs)
+// or anti-affinity.
+func (s *Service) screenTeam(
+	ctx context.Context,
+	teamID uuid.UUID,
+	members []*domain.User,
+	req slotRequest,
+) (*reviewerPool, error) {
+	policy, err := s.storage.GetTeamPolicy(ctx, teamID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team policy: %w", err)
+	}
+	var loads map[string]int
+	if policy.MaxOpenReviews > 0 || req.urgent {
+		if loads, err = s.openReviewLoads(ctx, members); err != nil {
+			return nil, err
+		}
+	}
+	pool := screenCandidates(teamID, members, req.authorID, req.assigned, policy, loads)
+	pool.requiredSkills = req.requiredSkills
+	if err := s.applyRouting(ctx, pool, req.labels, req.kept); err != nil {
+		return nil, err
+	}
+	onCall, err := s.currentOnCall(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	pool.takeOnCall(onCall)
+	if req.urgent {
+		pool.preferLightestQueue()
+		return pool, nil
+	}
+	if err := s.applyAntiAffinity(ctx, pool, req.authorID, policy); err != nil {
+		return nil, err
+	}
+	return pool, nil
+}
+
+// urgentDueWithin is how close a due date makes a PR urgent.
+const urgentDueWithin = 24 * time.Hour
+
+// isUrgent reports whether a PR is P0/P1 or due within urgentDueWithin.
+func (s *Service) isUrgent(pr *domain.PullRequest) bool {
+	if pr.Priority == domain.PriorityP0 || pr.Priority == domain.PriorityP1 {
+		return true
+	}
+	return pr.DueAt != nil && pr.DueAt.Before(s.now().Add(urgentDueWithin))
+}
+
+// preferLightestQueue switches the pool to the lightest-queue strategy; the pool must have loads.
+func (p *reviewerPool) preferLightestQueue() {
+	p.strategy = domain.StrategyLightestQueue
+}
+
+// applyRouting narrows the pool by the team's routing rules, see routeByLabels.
+func (s *Service) applyRouting(ctx context.Context, pool *reviewerPool, labels, kept []string) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	rules, err := s.storage.GetRoutingRules(ctx, pool.teamID)
+	if err != nil {
+		return err
+	}
+	pool.routeByLabels(rules, labels, kept)
+	return nil
+}
+
+// routeByLabels narrows the pool to the reviewer pool of the first routing rule that applies to the PR.
+// A rule applies when the PR carries its label, no kept reviewer already comes from its pool
+// and at least one of its reviewers is a candidate. Without such a rule the pool is left as is.
+func (p *reviewerPool) routeByLabels(rules []domain.RoutingRule, labels, kept []string) {
+	for _, rule := range rules {
+		if !slices.Contains(labels, rule.Label) {
+			continue
+		}
+		if slices.ContainsFunc(kept, func(userID string) bool { return slices.Contains(rule.ReviewerIDs, userID) }) {
+			continue
+		}
+		routed := make([]*domain.User, 0, len(p.candidates))
+		for _, candidate := range p.candidates {
+			if slices.Contains(rule.ReviewerIDs, candidate.UserID) {
+				routed = append(routed, candidate)
+			}
+		}
+		if len(routed) == 0 {
+			continue
+		}
+		for _, candidate := range p.candidates {
+			if !slices.Contains(rule.ReviewerIDs, candidate.UserID) {
+				p.excluded[candidate.UserID] = domain.ExcludedNotInRoute
+			}
+		}
+		p.candidates = routed
+		p.routedBy = rule.Label
+		return
+	}
+}
+
+// keepOnly narrows the candidates to the listed users; the others are excluded with the given reason.
+func (p *reviewerPool) keepOnly(userIDs []string, reason domain.ExclusionReason) {
+	p.candidates = slices.DeleteFunc(p.candidates, func(candidate *domain.User) bool {
+		if slices.Contains(userIDs, candidate.UserID) {
+			return false
+		}
+		p.excluded[candidate.UserID] = reason
+		return true
+	})
+}
+
+// applyAntiAffinity switches the pool to the weighted strategy when the team has an anti-affinity window,
+// down-weighting candidates who reviewed the same author within it.
+func (s *Service) applyAntiAffinity(ctx context.Context, pool *reviewerPool, authorID string, policy *domain.TeamPolicy) error {
+	if policy.AntiAffinityDays <= 0 {
+		return nil
+	}
+	since := s.now().AddDate(0, 0, -policy.AntiAffinityDays)
+	counts, err := s.storage.GetRecentReviewCounts(ctx, authorID, since)
+	if err != nil {
+		return err
+	}
+	pool.strategy = domain.StrategyWeighted
+	pool.recentReviews = make(map[string]int)
+	for _, candidate := range pool.candidates {
+		if n := counts[candidate.UserID]; n > 0 {
+			pool.recentReviews[candidate.UserID] = n
+		}
+	}
+	return nil
+}
+
+// openReviewLoads counts open PRs each member is assigned to review.
+func (s *Service) openReviewLoads(ctx context.Context, members []*domain.User) (map[string]int, error) {
+	userIDs := make([]string, len(members))
+	for i, member := range members {
+		userIDs[i] = member.UserID
+	}
+	openPRs, err := s.storage.GetOpenPRsByReviewers(ctx, userIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open PRs: %w", err)
+	}
+	loads := make(map[string]int, len(members))
+	for _, pr := range openPRs {
+		for _, reviewerID := range pr.AssignedReviewers {
+			loads[reviewerID]++
+		}
+	}
+	return loads, nil
+}
+
+// pickReviewers chooses up to slots reviewers from the pool with a fresh seed.
+// The returned decision holds everything needed to replay and explain the choice.
+func (s *Service) pickReviewers(pool *reviewerPool, slots int) *domain.AssignmentDecision {
+	return pool.decide(s.seeds(), slots)
+}
+
+// decide runs the pool's strategy with the given seed.
+// The on-call reviewer, if any, takes the first slot; skill matching and the strategy fill the rest.
+func (p *reviewerPool) decide(seed uint64, slots int) *domain.AssignmentDecision {
+	excluded := maps.Clone(p.excluded)
+	rest, onCall, skillSlots := p, "", slots
+	if p.onCall != "" && slots > 0 {
+		onCall, skillSlots = p.onCall, slots-1
+		rest = &reviewerPool{
+			candidates: slices.DeleteFunc(slices.Clone(p.candidates), func(candidate *domain.User) bool {
+				return candidate.UserID == onCall
+			}),
+			requiredSkills: p.requiredSkills,
+		}
+	}
+	candidates, preselected, matches := rest.matchSkills(skillSlots, excluded)
+	if onCall != "" {
+		preselected = append([]string{onCall}, preselected...)
+	}
+	decision := newDecision(p.strategy, seed, candidates, slots)
+	decision.TeamID = p.teamID
+	decision.MaxOpenReviews = p.maxOpenReviews
+	decision.Excluded = excluded
+	if len(p.recentReviews) > 0 {
+		decision.RecentReviews = p.recentReviews
+	}
+	decision.RoutedBy = p.routedBy
+	decision.RequiredSkills = p.requiredSkills
+	decision.SkillMatches = matches
+	decision.Preselected = preselected
+	decision.OnCall = onCall
+	if p.strategy == domain.StrategyLightestQueue {
+		decision.QueueLoads = make(map[string]int, len(decision.Candidates))
+		for _, userID := range decision.Candidates {
+			decision.QueueLoads[userID] = p.loads[userID]
+		}
+	}
+	// Strategies used here are always known, so the error can't happen
+	decision.Selected, _ = runStrategy(decision)
+	return decision
+}
+
+// matchSkills prefers candidates covering more of the required skills. Candidates are grouped by how many
+// they cover; whole groups of the best matches are preselected while they fit in the slots, and the first group
+// that doesn't fit is left to the strategy. Candidates below it are excluded as SKILL_GAP.
+// When nobody covers any required skill, the candidates are returned unchanged (fallback to the team pool).
+func (p *reviewerPool) matchSkills(
+	slots int,
+	excluded map[string]domain.ExclusionReason,
+) ([]*domain.User, []string, map[string][]string) {
+	if len(p.requiredSkills) == 0 || slots <= 0 {
+		return p.candidates, nil, nil
+	}
+	matches := make(map[string][]string)
+	byCoverage := make(map[int][]*domain.User)
+	for _, candidate := range p.candidates {
+		var matched []string
+		for _, skill := range p.requiredSkills {
+			if slices.Contains(candidate.Skills, skill) {
+				matched = append(matched, skill)
+			}
+		}
+		if len(matched) > 0 {
+			matches[candidate.UserID] = matched
+		}
+		byCoverage[len(matched)] = append(byCoverage[len(matched)], candidate)
+	}
+	if len(matches) == 0 {
+		return p.candidates, nil, nil
+	}
+	var preselected []string
+	var candidates []*domain.User
+	for coverage := len(p.requiredSkills); coverage >= 0; coverage-- {
+		group := byCoverage[coverage]
+		switch {
+		case candidates != nil || len(preselected) == slots:
+			for _, member := range group {
+				excluded[member.UserID] = domain.ExcludedSkillGap
+			}
+		case len(preselected)+len(group) <= slots && coverage > 0:
+			ids := make([]string, len(group))
+			for i, member := range group {
+				ids[i] = member.UserID
+			}
+			slices.Sort(ids)
+			preselected = append(preselected, ids...)
+		case len(group) > 0:
+			candidates = group
+		}
+	}
+	return candidates, preselected, matches
+}
+
+// newDecision records the strategy, seed and candidates of a selection.
+// Candidates are sorted by ID, so the outcome depends only on the recorded inputs.
+func newDecision(strategy string, seed uint64, candidates []*domain.User, slots int) *domain.AssignmentDecision {
+	ids := make([]string, len(candidates))
+	for i, candidate := range candidates {
+		ids[i] = candidate.UserID
+	}
+	slices.Sort(ids)
+	return &domain.AssignmentDecision{
+		Strategy:   strategy,
+		Seed:       seed,
+		Slots:      slots,
+		Candidates: ids,
+	}
+}
+
+// runStrategy computes the selection described by a decision: the preselected reviewers,
+// then the strategy's picks from the candidates for the remaining slots.
+func runStrategy(decision *domain.AssignmentDecision) ([]string, error) {
+	rng := rand.New(rand.NewPCG(decision.Seed, decision.Seed))
+	slots := decision.Slots - len(decision.Preselected)
+	selected := slices.Clone(decision.Preselected)
+	switch decision.Strategy {
+	case domain.StrategyRandom:
+		shuffled := slices.Clone(decision.Candidates)
+		rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		return append(selected, shuffled[:min(len(shuffled), slots)]...), nil
+	case domain.StrategyLightestQueue:
+		shuffled := slices.Clone(decision.Candidates)
+		rng.Shuffle(len(shuffled), func(i, j int) {
+			shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+		})
+		slices.SortStableFunc(shuffled, func(a, b string) int {
+			return decision.QueueLoads[a] - decision.QueueLoads[b]
+		})
+		return append(selected, shuffled[:min(len(shuffled), slots)]...), nil
+	case domain.StrategyWeighted:
+		remaining := slices.Clone(decision.Candidates)
+		for len(selected) < decision.Slots && len(remaining) > 0 {
+			weights := make([]float64, len(remaining))
+			total := 0.0
+			for i, userID := range remaining {
+				weights[i] = 1 / float64(1+decision.RecentReviews[userID])
+				total += weights[i]
+			}
+			pick, target := len(remaining)-1, rng.Float64()*total
+			for i, weight := range weights {
+				if target < weight {
+					pick = i
+					break
+				}
+				target -= weight
+			}
+			selected = append(selected, remaining[pick])
+			remaining = slices.Delete(remaining, pick, pick+1)
+		}
+		return selected, nil
+	default:
+		return nil, fmt.Errorf("%w: strategy %q cannot be replayed", domain.ErrInvalidRequest, decision.Strategy)
+	}
+}
+
+// describeRule explains in words how a decision picked its reviewers.
+func describeRule(decision *domain.AssignmentDecision) string {
+	var rule strings.Builder
+	if decision.RoutedBy != "" {
+		fmt.Fprintf(&rule, "routed by label %q to its reviewer pool; ", decision.RoutedBy)
+	}
+	skillPicks := decision.Preselected
+	if decision.OnCall != "" {
+		fmt.Fprintf(&rule, "on-call reviewer %s from the team rotation took the first slot; ", decision.OnCall)
+		skillPicks = skillPicks[1:]
+	}
+	if len(decision.RequiredSkills) > 0 {
+		skills := strings.Join(decision.RequiredSkills, ", ")
+		switch {
+		case len(decision.SkillMatches) == 0:
+			fmt.Fprintf(&rule, "nobody covers the required skills (%s), fell back to the team pool; ", skills)
+		case len(skillPicks) > 0:
+			fmt.Fprintf(&rule, "best skill matches for %s preselected: %s; ", skills, strings.Join(skillPicks, ", "))
+		default:
+			fmt.Fprintf(&rule, "candidates narrowed to the best skill matches for %s; ", skills)
+		}
+	}
+	if (len(decision.RequiredSkills) > 0 || decision.OnCall != "") && len(decision.Preselected) == decision.Slots {
+		return strings.TrimSuffix(rule.String(), "; ")
+	}
+	rule.WriteString(describeStrategy(decision))
+	return rule.String()
+}
+
+// describeStrategy explains the strategy part of a decision.
+func describeStrategy(decision *domain.AssignmentDecision) string {
+	switch {
+	case decision.Slots == 0:
+		return "no reviewer slots were open"
+	case len(decision.Candidates) == 0:
+		return "no eligible candidates; the slots were left empty"
+	case decision.Strategy == domain.StrategyRandom:
+		return fmt.Sprintf(
+			"random: %d eligible candidates sorted by user_id were shuffled with seed %d and the first %d taken",
+			len(decision.Candidates), decision.Seed, min(decision.Slots-len(decision.Preselected), len(decision.Candidates)),
+		)
+	case decision.Strategy == domain.StrategyLightestQueue:
+		return fmt.Sprintf(
+			"lightest queue (urgent PR): %d of %d eligible candidates with the fewest open reviews, "+
+				"ties broken by a shuffle with seed %d",
+			min(decision.Slots-len(decision.Preselected), len(decision.Candidates)), len(decision.Candidates), decision.Seed,
+		)
+	case decision.Strategy == domain.StrategyWeighted:
+		return fmt.Sprintf(
+			"weighted (anti-affinity): %d drawn from %d eligible candidates with seed %d, "+
+				"each weighted 1/(1+n) where n is their recent reviews of the author",
+			min(decision.Slots-len(decision.Preselected), len(decision.Candidates)), len(decision.Candidates), decision.Seed,
+		)
+	default:
+		return "strategy " + decision.Strategy
+	}
+}
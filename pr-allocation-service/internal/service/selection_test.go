@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// teamUUID is the team of pools built without a storage.
+var teamUUID = uuid.NewSHA1(uuid.Nil, []byte("pool"))
+
+func users(ids ...string) []*domain.User {
+	members := make([]*domain.User, len(ids))
+	for i, id := range ids {
+		members[i] = &domain.User{UserID: id, IsActive: true}
+	}
+	return members
+}
+
+func TestRunStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		decision domain.AssignmentDecision
+		// want is checked when set; otherwise the selection only has to come from the candidates
+		want    []string
+		wantLen int
+		wantErr error
+	}{
+		{
+			name:     "random takes the slots",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Seed: 1, Slots: 2, Candidates: []string{"u1", "u2", "u3"}},
+			wantLen:  2,
+		},
+		{
+			name:     "random with fewer candidates than slots",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Seed: 1, Slots: 2, Candidates: []string{"u1"}},
+			want:     []string{"u1"},
+		},
+		{
+			name: "preselected come first",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Seed: 1, Slots: 2,
+				Candidates: []string{"u2", "u3"}, Preselected: []string{"u1"}},
+			wantLen: 2,
+		},
+		{
+			name: "lightest queue",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyLightestQueue, Seed: 9, Slots: 2,
+				Candidates: []string{"u1", "u2", "u3"}, QueueLoads: map[string]int{"u1": 5, "u2": 1, "u3": 0}},
+			want: []string{"u3", "u2"},
+		},
+		{
+			name: "weighted draws distinct reviewers",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyWeighted, Seed: 3, Slots: 3,
+				Candidates: []string{"u1", "u2", "u3"}, RecentReviews: map[string]int{"u1": 4}},
+			wantLen: 3,
+		},
+		{
+			name:     "no slots",
+			decision: domain.AssignmentDecision{Strategy: domain.StrategyRandom, Seed: 1, Candidates: []string{"u1"}},
+			want:     []string{},
+		},
+		{
+			name:     "unknown strategy",
+			decision: domain.AssignmentDecision{Strategy: "round_robin", Slots: 1, Candidates: []string{"u1"}},
+			wantErr:  domain.ErrInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runStrategy(&tt.decision)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, _ := runStrategy(&tt.decision)
+			if !slices.Equal(got, again) {
+				t.Fatalf("same decision selected %v, then %v", got, again)
+			}
+			if tt.want != nil && !slices.Equal(got, tt.want) {
+				t.Fatalf("selected %v, want %v", got, tt.want)
+			}
+			if tt.want == nil && len(got) != tt.wantLen {
+				t.Fatalf("selected %v, want %d reviewers", got, tt.wantLen)
+			}
+			pool := append(slices.Clone(tt.decision.Preselected), tt.decision.Candidates...)
+			for i, userID := range got {
+				if !slices.Contains(pool, userID) || slices.Contains(got[:i], userID) {
+					t.Fatalf("selected %v: %s is not a candidate or picked twice", got, userID)
+				}
+			}
+			if n := len(tt.decision.Preselected); !slices.Equal(got[:n], tt.decision.Preselected) {
+				t.Fatalf("selected %v, want preselected %v first", got, tt.decision.Preselected)
+			}
+		})
+	}
+}
+
+func TestRunStrategyWeightedPrefersFreshReviewers(t *testing.T) {
+	counts := make(map[string]int)
+	for seed := range uint64(400) {
+		selected, err := runStrategy(&domain.AssignmentDecision{Strategy: domain.StrategyWeighted, Seed: seed, Slots: 1,
+			Candidates: []string{"fresh", "tired"}, RecentReviews: map[string]int{"tired": 9}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[selected[0]]++
+	}
+	// Weights are 1 and 1/10, so tired should win about 1 draw in 11
+	if counts["tired"] == 0 || counts["tired"] > 80 {
+		t.Fatalf("weighted draws %v, want tired picked about 1 in 11 times", counts)
+	}
+}
+
+func TestDecide(t *testing.T) {
+	tests := []struct {
+		name     string
+		pool     func() *reviewerPool
+		slots    int
+		preFirst []string
+	}{
+		{
+			name: "random",
+			pool: func() *reviewerPool {
+				return screenCandidates(teamUUID, users("a1", "u1", "u2", "u3"), "a1", nil, &domain.TeamPolicy{}, nil)
+			},
+			slots: 2,
+		},
+		{
+			name: "on-call takes the first slot",
+			pool: func() *reviewerPool {
+				pool := screenCandidates(teamUUID, users("a1", "u1", "u2", "u3"), "a1", nil, &domain.TeamPolicy{}, nil)
+				pool.takeOnCall("u3")
+				return pool
+			},
+			slots:    2,
+			preFirst: []string{"u3"},
+		},
+		{
+			name: "skill matches preselected",
+			pool: func() *reviewerPool {
+				members := users("a1", "u1", "u2", "u3")
+				members[2].Skills = []string{"go", "sql"}
+				members[3].Skills = []string{"go"}
+				pool := screenCandidates(teamUUID, members, "a1", nil, &domain.TeamPolicy{}, nil)
+				pool.requiredSkills = []string{"go", "sql"}
+				return pool
+			},
+			slots:    2,
+			preFirst: []string{"u2", "u3"},
+		},
+		{
+			name: "on-call outside the pool is skipped",
+			pool: func() *reviewerPool {
+				pool := screenCandidates(teamUUID, users("a1", "u1", "u2"), "a1", []string{"u2"}, &domain.TeamPolicy{}, nil)
+				pool.takeOnCall("u2")
+				return pool
+			},
+			slots:    1,
+			preFirst: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for seed := range uint64(10) {
+				decision := tt.pool().decide(seed, tt.slots)
+				if again := tt.pool().decide(seed, tt.slots); !slices.Equal(decision.Selected, again.Selected) {
+					t.Fatalf("seed %d selected %v, then %v", seed, decision.Selected, again.Selected)
+				}
+				replayed, err := runStrategy(decision)
+				if err != nil || !slices.Equal(replayed, decision.Selected) {
+					t.Fatalf("seed %d: replay = %v, %v; want %v", seed, replayed, err, decision.Selected)
+				}
+				if tt.preFirst != nil && !slices.Equal(decision.Preselected, tt.preFirst) {
+					t.Fatalf("seed %d: preselected %v, want %v", seed, decision.Preselected, tt.preFirst)
+				}
+				if slices.Contains(decision.Selected, "a1") {
+					t.Fatalf("seed %d: author selected", seed)
+				}
+				if decision.Excluded["a1"] != domain.ExcludedAuthor {
+					t.Fatalf("seed %d: author excluded as %q", seed, decision.Excluded["a1"])
+				}
+			}
+		})
+	}
+}
+
+func TestReplayAssignment(t *testing.T) {
+	f := newFakeStorage("a1", "u1", "u2", "u3")
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-1", AuthorID: "a1"})
+	ctx := context.Background()
+	svc := NewService(f)
+	pool := screenCandidates(f.teamID, users("a1", "u1", "u2", "u3"), "a1", nil, &domain.TeamPolicy{}, nil)
+	created := pool.decide(11, 2)
+	svc.recordEvent(ctx, &domain.PullRequest{PullRequestID: "pr-1", AssignedReviewers: created.Selected},
+		domain.EventCreated, "a1", "", created)
+	svc.recordEvent(ctx, &domain.PullRequest{PullRequestID: "pr-1"}, domain.EventApproved, "u1", "", nil)
+	tampered := *pool.decide(12, 1)
+	tampered.Selected = []string{"nobody"}
+	svc.recordEvent(ctx, &domain.PullRequest{PullRequestID: "pr-1"}, domain.EventReassigned, "a1", "", &tampered)
+
+	tests := []struct {
+		name      string
+		eventID   int64
+		wantEvent int64
+		matches   bool
+		wantErr   error
+	}{
+		{name: "latest decision", eventID: 0, wantEvent: 3},
+		{name: "creation", eventID: 1, wantEvent: 1, matches: true},
+		{name: "event without decision", eventID: 2, wantErr: domain.ErrNotFound},
+		{name: "unknown event", eventID: 42, wantErr: domain.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replay, err := svc.ReplayAssignment(ctx, "pr-1", tt.eventID)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if replay.EventID != tt.wantEvent || replay.Matches != tt.matches {
+				t.Fatalf("replayed event %d matches=%t, want event %d matches=%t",
+					replay.EventID, replay.Matches, tt.wantEvent, tt.matches)
+			}
+		})
+	}
+}
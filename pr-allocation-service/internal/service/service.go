@@ -2,8 +2,14 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"math/rand"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Meldy183/pr-allocation-service/internal/domain"
@@ -16,28 +22,48 @@ import (
 
 type Service struct {
 	storage storage.Storage
+	now     Clock
+	seeds   SeedSource
 }
 
-func NewService(storage storage.Storage) *Service {
-	return &Service{
+func NewService(storage storage.Storage, opts ...Option) *Service {
+	s := &Service{
 		storage: storage,
+		now:     time.Now,
+		seeds:   rand.Uint64,
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 // CreateTeam creates a team with members (POST /team/add).
 func (s *Service) CreateTeam(ctx context.Context, req *domain.CreateTeamRequest) (*domain.Team, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "creating team", zap.String("team_name", req.TeamName))
-	exists, err := s.storage.TeamExists(ctx, req.TeamName)
+	orgID := organizationID(ctx)
+	exists, err := s.storage.TeamExists(ctx, orgID, req.TeamName)
 	if err != nil {
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("%s: team already exists", domain.ErrTeamExists)
+		return nil, fmt.Errorf("%w: team already exists", domain.ErrTeamExists)
+	}
+	if err := assignRoles(req.Members); err != nil {
+		return nil, err
+	}
+	// Users move between teams of one organization only
+	for _, member := range req.Members {
+		user, err := s.storage.GetUser(ctx, member.UserID)
+		if err == nil && user.TeamID != uuid.Nil && user.OrganizationID != orgID {
+			return nil, fmt.Errorf("%w: user %s belongs to another organization", domain.ErrInvalidRequest, member.UserID)
+		}
 	}
 	team := &domain.Team{
-		TeamName: req.TeamName,
-		Members:  req.Members,
+		TeamName:       req.TeamName,
+		Members:        req.Members,
+		OrganizationID: orgID,
 	}
 	if err := s.storage.CreateTeam(ctx, team); err != nil {
 		log.Error(ctx, "failed to create team", zap.Error(err))
@@ -48,17 +74,21 @@ func (s *Service) CreateTeam(ctx context.Context, req *domain.CreateTeamRequest)
 
 // GetTeam returns team with members (GET /team/get).
 func (s *Service) GetTeam(ctx context.Context, teamName string) (*domain.Team, error) {
-	return s.storage.GetTeam(ctx, teamName)
+	return s.storage.GetTeam(ctx, organizationID(ctx), teamName)
 }
 
 // SetUserActive updates user active status (POST /users/setIsActive).
+// Only maintainers and admins of the user's team may do it.
 func (s *Service) SetUserActive(ctx context.Context, req *domain.SetUserActiveRequest) (*domain.User, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "setting user active status", zap.String("user_id", req.UserID), zap.Bool("is_active", req.IsActive))
-	user, err := s.storage.GetUser(ctx, req.UserID)
+	user, err := s.getUser(ctx, req.UserID)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.authorize(ctx, user.TeamID, domain.RoleMaintainer); err != nil {
+		return nil, err
+	}
 	user.IsActive = req.IsActive
 	if err := s.storage.UpdateUser(ctx, user); err != nil {
 		log.Error(ctx, "failed to update user", zap.Error(err))
@@ -69,7 +99,7 @@ func (s *Service) SetUserActive(ctx context.Context, req *domain.SetUserActiveRe
 
 // GetPR returns a PR by ID.
 func (s *Service) GetPR(ctx context.Context, prID string) (*domain.PullRequest, error) {
-	return s.storage.GetPR(ctx, prID)
+	return s.getPR(ctx, prID)
 }
 
 // CreatePR creates PR and auto-assigns 1 reviewer (POST /pullRequest/create).
@@ -81,30 +111,67 @@ func (s *Service) CreatePR(ctx context.Context, req *domain.CreatePRRequest) (*d
 		return nil, err
 	}
 	if exists {
-		return nil, fmt.Errorf("%s: PR already exists", domain.ErrPRExists)
+		return nil, fmt.Errorf("%w: PR already exists", domain.ErrPRExists)
 	}
-	author, err := s.storage.GetUser(ctx, req.AuthorID)
+	author, err := s.getUser(ctx, req.AuthorID)
 	if err != nil {
-		return nil, fmt.Errorf("%s: author not found", domain.ErrNotFound)
+		return nil, fmt.Errorf("%w: author not found", domain.ErrNotFound)
 	}
 	if author.TeamID == uuid.Nil {
-		return nil, fmt.Errorf("%s: author has no team", domain.ErrNotFound)
+		return nil, fmt.Errorf("%w: author has no team", domain.ErrNotFound)
 	}
 	teamMembers, err := s.storage.GetUsersByTeamID(ctx, author.TeamID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get team members: %w", err)
 	}
-	reviewers := s.selectReviewers(teamMembers, author.UserID, 1)
+	labels, err := normalizeTags("label", req.Labels)
+	if err != nil {
+		return nil, err
+	}
+	requiredSkills, err := normalizeTags("skill", req.RequiredSkills)
+	if err != nil {
+		return nil, err
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = domain.DefaultPriority
+	}
+	if !priority.Valid() {
+		return nil, fmt.Errorf("%w: priority must be one of P0, P1, P2, P3", domain.ErrInvalidRequest)
+	}
+	dependsOn, err := s.checkDependencies(ctx, req.PullRequestID, req.DependsOn)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
 	pr := &domain.PullRequest{
-		PullRequestID:     req.PullRequestID,
-		PullRequestName:   req.PullRequestName,
-		AuthorID:          req.AuthorID,
-		AssignedReviewers: reviewers,
+		PullRequestID:   req.PullRequestID,
+		PullRequestName: req.PullRequestName,
+		OrganizationID:  author.OrganizationID,
+		AuthorID:        req.AuthorID,
+		Labels:          labels,
+		RequiredSkills:  requiredSkills,
+		Priority:        priority,
+		DueAt:           req.DueAt,
+		DependsOn:       dependsOn,
+		CreatedAt:       &now,
+	}
+	pool, err := s.screenTeam(ctx, author.TeamID, teamMembers, slotRequest{
+		authorID:       author.UserID,
+		labels:         labels,
+		requiredSkills: requiredSkills,
+		urgent:         s.isUrgent(pr),
+	})
+	if err != nil {
+		return nil, err
 	}
+	decision := s.pickReviewers(pool, 1)
+	pr.AssignedReviewers = decision.Selected
 	if err := s.storage.CreatePR(ctx, pr); err != nil {
 		log.Error(ctx, "failed to create PR", zap.Error(err))
 		return nil, err
 	}
+	s.recordEvent(ctx, pr, domain.EventCreated, pr.AuthorID, "", decision)
 	log.Info(
 		ctx,
 		"PR created with reviewers",
@@ -114,34 +181,49 @@ func (s *Service) CreatePR(ctx context.Context, req *domain.CreatePRRequest) (*d
 	return pr, nil
 }
 
-// MergePR marks PR as MERGED (POST /pullRequest/merge) - only if all reviewers approved.
+// MergePR marks PR as MERGED (POST /pullRequest/merge) - only if all reviewers approved
+// and every PR it depends on is merged. A dry run returns the PR unchanged once the checks pass.
 func (s *Service) MergePR(ctx context.Context, req *domain.MergePRRequest) (*domain.PullRequest, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "merging PR", zap.String("pr_id", req.PullRequestID))
-	pr, err := s.storage.GetPR(ctx, req.PullRequestID)
+	pr, err := s.getPR(ctx, req.PullRequestID)
 	if err != nil {
-		return nil, fmt.Errorf("%s: PR not found", domain.ErrNotFound)
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	if err := s.authorizePR(ctx, pr, domain.RoleMaintainer); err != nil {
+		return nil, err
 	}
 	if pr.Status == domain.StatusMerged {
 		log.Info(ctx, "PR already merged", zap.String("pr_id", req.PullRequestID))
 		return pr, nil
 	}
 	if pr.Status == domain.StatusRejected {
-		return nil, fmt.Errorf("%s: PR was rejected", domain.ErrPRRejected)
+		return nil, fmt.Errorf("%w: PR was rejected", domain.ErrPRRejected)
 	}
 	// Check if all reviewers approved
 	if !s.allReviewersApproved(pr) {
-		return nil, fmt.Errorf("%s: not all reviewers have approved", domain.ErrNotAllApproved)
+		return nil, fmt.Errorf("%w: not all reviewers have approved", domain.ErrNotAllApproved)
+	}
+	pending, err := s.unmergedDependencies(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) > 0 {
+		return nil, fmt.Errorf("%w: waiting for %s", domain.ErrDepsNotMerged, strings.Join(pending, ", "))
+	}
+	if req.DryRun {
+		return pr, nil
 	}
 	pr.Status = domain.StatusMerged
 	if pr.MergedAt == nil {
-		now := time.Now()
+		now := s.now()
 		pr.MergedAt = &now
 	}
 	if err := s.storage.UpdatePR(ctx, pr); err != nil {
 		log.Error(ctx, "failed to merge PR", zap.Error(err))
 		return nil, err
 	}
+	s.recordEvent(ctx, pr, domain.EventMerged, "", "", nil)
 	return pr, nil
 }
 
@@ -150,13 +232,13 @@ func (s *Service) ApprovePR(ctx context.Context, req *domain.ApprovePRRequest) (
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "approving PR", zap.String("pr_id", req.PullRequestID), zap.String("reviewer_id", req.ReviewerID))
 
-	pr, err := s.storage.GetPR(ctx, req.PullRequestID)
+	pr, err := s.getPR(ctx, req.PullRequestID)
 	if err != nil {
-		return nil, false, fmt.Errorf("%s: PR not found", domain.ErrNotFound)
+		return nil, false, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
 	}
 
 	if pr.Status != domain.StatusOpen {
-		return nil, false, fmt.Errorf("%s: PR is not open", domain.ErrPRNotOpen)
+		return nil, false, fmt.Errorf("%w: PR is not open", domain.ErrPRNotOpen)
 	}
 
 	// Check if reviewer is assigned
@@ -168,7 +250,7 @@ func (s *Service) ApprovePR(ctx context.Context, req *domain.ApprovePRRequest) (
 		}
 	}
 	if !isAssigned {
-		return nil, false, fmt.Errorf("%s: reviewer is not assigned to this PR", domain.ErrNotAssigned)
+		return nil, false, fmt.Errorf("%w: reviewer is not assigned to this PR", domain.ErrNotAssigned)
 	}
 
 	// Check if already approved
@@ -186,6 +268,7 @@ func (s *Service) ApprovePR(ctx context.Context, req *domain.ApprovePRRequest) (
 		log.Error(ctx, "failed to approve PR", zap.Error(err))
 		return nil, false, err
 	}
+	s.recordEvent(ctx, pr, domain.EventApproved, req.ReviewerID, "", nil)
 
 	allApproved := s.allReviewersApproved(pr)
 	log.Info(ctx, "PR approved by reviewer",
@@ -196,18 +279,18 @@ func (s *Service) ApprovePR(ctx context.Context, req *domain.ApprovePRRequest) (
 	return pr, allApproved, nil
 }
 
-// RejectPR marks PR as rejected.
+// RejectPR marks PR as rejected, along with the open PRs stacked on it.
 func (s *Service) RejectPR(ctx context.Context, req *domain.RejectPRRequest) (*domain.PullRequest, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "rejecting PR", zap.String("pr_id", req.PullRequestID), zap.String("reviewer_id", req.ReviewerID))
 
-	pr, err := s.storage.GetPR(ctx, req.PullRequestID)
+	pr, err := s.getPR(ctx, req.PullRequestID)
 	if err != nil {
-		return nil, fmt.Errorf("%s: PR not found", domain.ErrNotFound)
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
 	}
 
 	if pr.Status != domain.StatusOpen {
-		return nil, fmt.Errorf("%s: PR is not open", domain.ErrPRNotOpen)
+		return nil, fmt.Errorf("%w: PR is not open", domain.ErrPRNotOpen)
 	}
 
 	// Check if reviewer is assigned
@@ -219,17 +302,27 @@ func (s *Service) RejectPR(ctx context.Context, req *domain.RejectPRRequest) (*d
 		}
 	}
 	if !isAssigned {
-		return nil, fmt.Errorf("%s: reviewer is not assigned to this PR", domain.ErrNotAssigned)
+		return nil, fmt.Errorf("%w: reviewer is not assigned to this PR", domain.ErrNotAssigned)
 	}
 
-	pr.Status = domain.StatusRejected
-
-	if err := s.storage.UpdatePR(ctx, pr); err != nil {
+	// The open PRs stacked on it, directly or not, are rejected in the same transaction
+	dependents, err := s.storage.RejectPR(ctx, pr.PullRequestID, req.Reason)
+	if err != nil {
 		log.Error(ctx, "failed to reject PR", zap.Error(err))
 		return nil, err
 	}
+	pr.Status = domain.StatusRejected
+	pr.RejectReason = req.Reason
+	s.recordEvent(ctx, pr, domain.EventRejected, req.ReviewerID, req.Reason, nil)
+	// Each dependent is recorded as REJECTED naming the PR it was stacked on, which notifies its author and reviewers
+	cascaded := make([]string, 0, len(dependents))
+	for _, dependent := range dependents {
+		s.recordEvent(ctx, dependent, domain.EventRejected, "", dependent.RejectReason, nil)
+		cascaded = append(cascaded, dependent.PullRequestID)
+	}
 
-	log.Info(ctx, "PR rejected", zap.String("pr_id", req.PullRequestID), zap.String("reviewer_id", req.ReviewerID))
+	log.Info(ctx, "PR rejected", zap.String("pr_id", req.PullRequestID), zap.String("reviewer_id", req.ReviewerID),
+		zap.Strings("rejected_dependents", cascaded))
 	return pr, nil
 }
 
@@ -262,12 +355,15 @@ func (s *Service) ReassignReviewer(
 		zap.String("pr_id", req.PullRequestID),
 		zap.String("old_user_id", req.OldUserID),
 	)
-	pr, err := s.storage.GetPR(ctx, req.PullRequestID)
+	pr, err := s.getPR(ctx, req.PullRequestID)
 	if err != nil {
-		return "", nil, fmt.Errorf("%s: PR not found", domain.ErrNotFound)
+		return "", nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	if err := s.authorizePR(ctx, pr, domain.RoleMaintainer); err != nil {
+		return "", nil, err
 	}
 	if pr.Status == domain.StatusMerged {
-		return "", nil, fmt.Errorf("%s: cannot reassign reviewers for merged PR", domain.ErrPRMerged)
+		return "", nil, fmt.Errorf("%w: cannot reassign reviewers for merged PR", domain.ErrPRMerged)
 	}
 	found := false
 	oldIndex := -1
@@ -279,44 +375,46 @@ func (s *Service) ReassignReviewer(
 		}
 	}
 	if !found {
-		return "", nil, fmt.Errorf("%s: reviewer not assigned to this PR", domain.ErrNotAssigned)
+		return "", nil, fmt.Errorf("%w: reviewer not assigned to this PR", domain.ErrNotAssigned)
 	}
-	oldReviewer, err := s.storage.GetUser(ctx, req.OldUserID)
+	oldReviewer, err := s.getUser(ctx, req.OldUserID)
 	if err != nil {
-		return "", nil, fmt.Errorf("%s: old reviewer not found", domain.ErrNotFound)
+		return "", nil, fmt.Errorf("%w: old reviewer not found", domain.ErrNotFound)
 	}
 	teamMembers, err := s.storage.GetUsersByTeamID(ctx, oldReviewer.TeamID)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to get team members: %w", err)
 	}
-	excludeIDs := make(map[string]bool)
-	excludeIDs[pr.AuthorID] = true
-	for _, rid := range pr.AssignedReviewers {
-		excludeIDs[rid] = true
-	}
-	var candidates []*domain.User
-	for _, member := range teamMembers {
-		if member.IsActive && !excludeIDs[member.UserID] {
-			candidates = append(candidates, member)
-		}
+	pool, err := s.screenTeam(ctx, oldReviewer.TeamID, teamMembers, slotRequest{
+		authorID:       pr.AuthorID,
+		assigned:       pr.AssignedReviewers,
+		kept:           slices.Delete(slices.Clone(pr.AssignedReviewers), oldIndex, oldIndex+1),
+		labels:         pr.Labels,
+		requiredSkills: pr.RequiredSkills,
+		urgent:         s.isUrgent(pr),
+	})
+	if err != nil {
+		return "", nil, err
 	}
-	if len(candidates) == 0 {
-		return "", nil, fmt.Errorf("%s: no active replacement candidate in team", domain.ErrNoCandidate)
+	if len(pool.candidates) == 0 {
+		return "", nil, fmt.Errorf("%w: no active replacement candidate in team", domain.ErrNoCandidate)
 	}
-	newReviewer := candidates[rand.Intn(len(candidates))]
-	pr.AssignedReviewers[oldIndex] = newReviewer.UserID
+	decision := s.pickReviewers(pool, 1)
+	newReviewerID := decision.Selected[0]
+	pr.AssignedReviewers[oldIndex] = newReviewerID
 	if err := s.storage.UpdatePR(ctx, pr); err != nil {
 		log.Error(ctx, "failed to reassign reviewer", zap.Error(err))
 		return "", nil, err
 	}
+	s.recordEvent(ctx, pr, domain.EventReassigned, req.OldUserID, "replaced "+req.OldUserID, decision)
 	log.Info(ctx, "reviewer reassigned", zap.String("pr_id", req.PullRequestID),
-		zap.String("old", req.OldUserID), zap.String("new", newReviewer.UserID))
-	return newReviewer.UserID, pr, nil
+		zap.String("old", req.OldUserID), zap.String("new", newReviewerID))
+	return newReviewerID, pr, nil
 }
 
 // GetPRsByReviewer returns PRs where user is assigned reviewer.
 func (s *Service) GetPRsByReviewer(ctx context.Context, userID string) ([]*domain.PullRequestShort, error) {
-	prs, err := s.storage.GetPRsByReviewer(ctx, userID)
+	prs, err := s.storage.GetPRsByReviewer(ctx, organizationID(ctx), userID)
 	if err != nil {
 		return nil, err
 	}
@@ -327,6 +425,10 @@ func (s *Service) GetPRsByReviewer(ctx context.Context, userID string) ([]*domai
 			PullRequestName: pr.PullRequestName,
 			AuthorID:        pr.AuthorID,
 			Status:          pr.Status,
+			Labels:          pr.Labels,
+			Priority:        pr.Priority,
+			DueAt:           pr.DueAt,
+			CreatedAt:       pr.CreatedAt,
 		}
 	}
 	return shorts, nil
@@ -334,7 +436,7 @@ func (s *Service) GetPRsByReviewer(ctx context.Context, userID string) ([]*domai
 
 // GetPRsByAuthor returns PRs authored by user.
 func (s *Service) GetPRsByAuthor(ctx context.Context, authorID string) ([]*domain.PullRequestShort, error) {
-	prs, err := s.storage.GetPRsByAuthor(ctx, authorID)
+	prs, err := s.storage.GetPRsByAuthor(ctx, organizationID(ctx), authorID)
 	if err != nil {
 		return nil, err
 	}
@@ -345,77 +447,62 @@ func (s *Service) GetPRsByAuthor(ctx context.Context, authorID string) ([]*domai
 			PullRequestName: pr.PullRequestName,
 			AuthorID:        pr.AuthorID,
 			Status:          pr.Status,
+			Labels:          pr.Labels,
+			Priority:        pr.Priority,
+			DueAt:           pr.DueAt,
+			CreatedAt:       pr.CreatedAt,
 		}
 	}
 	return shorts, nil
 }
-func (s *Service) selectReviewers(teamMembers []*domain.User, authorID string, maxCount int) []string {
-	candidates := make([]*domain.User, 0)
-	for _, member := range teamMembers {
-		if member.IsActive && member.UserID != authorID {
-			candidates = append(candidates, member)
-		}
-	}
-	if len(candidates) == 0 {
-		return []string{}
-	}
-	rand.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
-	})
-	count := min(len(candidates), maxCount)
-	reviewers := make([]string, count)
-	for i := range count {
-		reviewers[i] = candidates[i].UserID
-	}
-	return reviewers
-}
 
-// GetStatistics returns various statistics about the system.
+// GetStatistics returns various statistics about the request's organization.
 func (s *Service) GetStatistics(ctx context.Context) (*domain.StatisticsResponse, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "fetching statistics")
+	orgID := organizationID(ctx)
 	stats := &domain.StatisticsResponse{
 		PRsByStatus: make(map[string]int),
 	}
 	// Get counts
-	totalPRs, err := s.storage.GetTotalPRsCount(ctx)
+	totalPRs, err := s.storage.GetTotalPRsCount(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total PRs count: %w", err)
 	}
 	stats.TotalPRs = totalPRs
-	openPRs, err := s.storage.GetPRsCountByStatus(ctx, domain.StatusOpen)
+	openPRs, err := s.storage.GetPRsCountByStatus(ctx, orgID, domain.StatusOpen)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get open PRs count: %w", err)
 	}
 	stats.OpenPRs = openPRs
 	stats.PRsByStatus["OPEN"] = openPRs
-	mergedPRs, err := s.storage.GetPRsCountByStatus(ctx, domain.StatusMerged)
+	mergedPRs, err := s.storage.GetPRsCountByStatus(ctx, orgID, domain.StatusMerged)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get merged PRs count: %w", err)
 	}
 	stats.MergedPRs = mergedPRs
 	stats.PRsByStatus["MERGED"] = mergedPRs
-	totalTeams, err := s.storage.GetTotalTeamsCount(ctx)
+	totalTeams, err := s.storage.GetTotalTeamsCount(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total teams count: %w", err)
 	}
 	stats.TotalTeams = totalTeams
-	totalUsers, err := s.storage.GetTotalUsersCount(ctx)
+	totalUsers, err := s.storage.GetTotalUsersCount(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total users count: %w", err)
 	}
 	stats.TotalUsers = totalUsers
-	activeUsers, err := s.storage.GetActiveUsersCount(ctx)
+	activeUsers, err := s.storage.GetActiveUsersCount(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get active users count: %w", err)
 	}
 	stats.ActiveUsers = activeUsers
 	// Get user assignment statistics
-	users, err := s.storage.GetAllUsers(ctx)
+	users, err := s.storage.GetAllUsers(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all users: %w", err)
 	}
-	allPRs, err := s.storage.GetAllPRs(ctx)
+	allPRs, err := s.storage.GetAllPRs(ctx, orgID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all PRs: %w", err)
 	}
@@ -457,138 +544,557 @@ func (s *Service) GetStatistics(ctx context.Context) (*domain.StatisticsResponse
 }
 
 // BulkDeactivateTeamUsers deactivates all users in a team and reassigns their open PRs.
+// With DryRun set nothing is written: the computed plan is returned together with a plan token.
+// Submitting that token executes exactly the previewed plan, unless the team or its PRs changed meanwhile.
 func (s *Service) BulkDeactivateTeamUsers(ctx context.Context, req *domain.BulkDeactivateRequest) (*domain.BulkDeactivateResponse, error) {
 	log := logger.FromContext(ctx)
-	log.Info(ctx, "bulk deactivating team users", zap.String("team_name", req.TeamName))
+	log.Info(ctx, "bulk deactivating team users",
+		zap.String("team_name", req.TeamName),
+		zap.Bool("dry_run", req.DryRun),
+		zap.Bool("with_plan_token", req.PlanToken != ""),
+	)
+	teamID, err := s.storage.GetTeamIDByName(ctx, organizationID(ctx), req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, teamID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	seed := s.seeds()
+	if req.PlanToken != "" {
+		parsedSeed, err := parsePlanToken(req.PlanToken)
+		if err != nil {
+			return nil, err
+		}
+		seed = parsedSeed
+	}
+	plan, err := s.planBulkDeactivation(ctx, req.TeamName, seed)
+	if err != nil {
+		return nil, err
+	}
+	if req.PlanToken != "" && req.PlanToken != plan.token {
+		log.Warn(ctx, "bulk deactivation plan is stale", zap.String("team_name", req.TeamName))
+		return nil, fmt.Errorf("%w: team or its pull requests changed since the plan was made", domain.ErrPlanStale)
+	}
+	response := &domain.BulkDeactivateResponse{
+		DeactivatedCount: len(plan.userIDs),
+		ReassignedPRs:    plan.reassignments,
+		DryRun:           req.DryRun,
+	}
+	if req.DryRun {
+		response.PlanToken = plan.token
+		log.Info(ctx, "bulk deactivation planned",
+			zap.Int("deactivated_count", len(plan.userIDs)),
+			zap.Int("reassigned_prs", len(plan.reassignments)),
+		)
+		return response, nil
+	}
+	if len(plan.userIDs) == 0 {
+		log.Info(ctx, "no active users to deactivate", zap.String("team_name", req.TeamName))
+		return response, nil
+	}
+	// Apply PR updates and deactivate the team in one transaction; it aborts if anything planned has changed
+	changes := make([]domain.PRReassignmentSummary, 0, len(plan.updates))
+	for _, update := range plan.updates {
+		changes = append(changes, domain.PRReassignmentSummary{
+			PullRequestID: update.pr.PullRequestID,
+			OldReviewers:  update.oldReviewers,
+			NewReviewers:  update.pr.AssignedReviewers,
+		})
+	}
+	if err := s.storage.ApplyBulkDeactivation(ctx, changes, plan.userIDs); err != nil {
+		if errors.Is(err, domain.ErrPlanStale) {
+			log.Warn(ctx, "bulk deactivation plan is stale", zap.String("team_name", req.TeamName), zap.Error(err))
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to apply bulk deactivation: %w", err)
+	}
+	for _, update := range plan.updates {
+		s.recordEvent(ctx, update.pr, domain.EventBulkReassigned, "", "team "+req.TeamName+" deactivated", update.decision)
+		if update.summary == nil {
+			continue
+		}
+		log.Info(ctx, "PR reviewers reassigned",
+			zap.String("pr_id", update.pr.PullRequestID),
+			zap.Strings("old_reviewers", update.summary.OldReviewers),
+			zap.Strings("new_reviewers", update.summary.NewReviewers),
+		)
+	}
+	log.Info(ctx, "bulk deactivation completed",
+		zap.Int("deactivated_count", len(plan.userIDs)),
+		zap.Int("reassigned_prs", len(response.ReassignedPRs)),
+	)
+	return response, nil
+}
+
+// bulkDeactivationPlan is the full set of changes a bulk deactivation would make.
+type bulkDeactivationPlan struct {
+	userIDs       []string
+	updates       []bulkPRUpdate
+	reassignments []domain.PRReassignmentSummary
+	token         string
+}
+
+// bulkPRUpdate is a PR with its new reviewers; summary and decision are nil when no replacement could be looked up.
+type bulkPRUpdate struct {
+	pr           *domain.PullRequest
+	oldReviewers []string
+	summary      *domain.PRReassignmentSummary
+	decision     *domain.AssignmentDecision
+}
+
+// planBulkDeactivation computes the deactivation plan for a team without writing anything.
+// Inputs are read in a stable order and every PR gets its own selection seed drawn from the plan seed,
+// so the same seed over the same state always yields the same plan. The plan token fingerprints the seed
+// and every input read.
+func (s *Service) planBulkDeactivation(ctx context.Context, teamName string, seed uint64) (*bulkDeactivationPlan, error) {
+	log := logger.FromContext(ctx)
+	rng := rand.New(rand.NewPCG(seed, seed))
+	fingerprint := sha256.New()
+	fmt.Fprintf(fingerprint, "seed=%d\nteam=%s\n", seed, teamName)
 	// Get team members
-	team, err := s.storage.GetTeam(ctx, req.TeamName)
+	team, err := s.storage.GetTeam(ctx, organizationID(ctx), teamName)
 	if err != nil {
-		return nil, fmt.Errorf("%s: team not found", domain.ErrNotFound)
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
 	}
+	members := slices.Clone(team.Members)
+	slices.SortFunc(members, func(a, b domain.TeamMember) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
 	// Extract user IDs from team
-	userIDs := make([]string, 0, len(team.Members))
-	for _, member := range team.Members {
+	userIDs := make([]string, 0, len(members))
+	deactivating := make(map[string]bool, len(members))
+	for _, member := range members {
+		fmt.Fprintf(fingerprint, "member=%s:%t\n", member.UserID, member.IsActive)
 		if member.IsActive {
 			userIDs = append(userIDs, member.UserID)
+			deactivating[member.UserID] = true
 		}
 	}
+	plan := &bulkDeactivationPlan{
+		userIDs:       userIDs,
+		updates:       make([]bulkPRUpdate, 0),
+		reassignments: make([]domain.PRReassignmentSummary, 0),
+	}
 	if len(userIDs) == 0 {
-		log.Info(ctx, "no active users to deactivate", zap.String("team_name", req.TeamName))
-		return &domain.BulkDeactivateResponse{
-			DeactivatedCount: 0,
-			ReassignedPRs:    []domain.PRReassignmentSummary{},
-		}, nil
+		plan.token = formatPlanToken(seed, fingerprint.Sum(nil))
+		return plan, nil
 	}
 	// Get all open PRs assigned to these users
 	openPRs, err := s.storage.GetOpenPRsByReviewers(ctx, userIDs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get open PRs: %w", err)
 	}
+	slices.SortFunc(openPRs, func(a, b *domain.PullRequest) int {
+		return strings.Compare(a.PullRequestID, b.PullRequestID)
+	})
 	log.Info(ctx, "found open PRs to reassign",
 		zap.Int("count", len(openPRs)),
 		zap.Strings("deactivating_users", userIDs),
 	)
-	// Track reassignments
-	reassignments := make([]domain.PRReassignmentSummary, 0)
+	// Policies, routing rules, on-call reviewers and review loads per author team;
+	// loads include the plan's own assignments
+	policies := make(map[uuid.UUID]*domain.TeamPolicy)
+	routes := make(map[uuid.UUID][]domain.RoutingRule)
+	onCalls := make(map[uuid.UUID]string)
+	teamLoads := make(map[uuid.UUID]map[string]int)
 	// Process each PR
 	for _, pr := range openPRs {
-		oldReviewers := make([]string, len(pr.AssignedReviewers))
-		copy(oldReviewers, pr.AssignedReviewers)
+		fmt.Fprintf(fingerprint, "pr=%s:%s:%s:%s:%s\n", pr.PullRequestID, pr.AuthorID,
+			strings.Join(pr.AssignedReviewers, ","), strings.Join(pr.RequiredSkills, ","), strings.Join(pr.Labels, ","))
+		oldReviewers := slices.Clone(pr.AssignedReviewers)
 		newReviewers := make([]string, 0, len(pr.AssignedReviewers))
-		needsReassignment := false
 		// Check which reviewers need to be replaced
 		for _, reviewerID := range pr.AssignedReviewers {
-			isDeactivating := false
-			for _, uid := range userIDs {
-				if reviewerID == uid {
-					isDeactivating = true
-					needsReassignment = true
-					break
-				}
-			}
-			if !isDeactivating {
+			if !deactivating[reviewerID] {
 				newReviewers = append(newReviewers, reviewerID)
 			}
 		}
-		if !needsReassignment {
+		if len(newReviewers) == len(oldReviewers) {
 			continue
 		}
 		// Try to find replacement reviewers from author's team
 		author, err := s.storage.GetUser(ctx, pr.AuthorID)
 		if err != nil {
 			log.Warn(ctx, "failed to get author for PR", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
+			fmt.Fprintf(fingerprint, "author=%s:missing\n", pr.AuthorID)
 			pr.AssignedReviewers = newReviewers
-			if err := s.storage.UpdatePR(ctx, pr); err != nil {
-				log.Error(ctx, "failed to update PR reviewers", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
-			}
+			plan.updates = append(plan.updates, bulkPRUpdate{pr: pr, oldReviewers: oldReviewers})
 			continue
 		}
 		// Get potential reviewers from author's team (excluding deactivating users and author)
 		teamMembers, err := s.storage.GetUsersByTeamID(ctx, author.TeamID)
 		if err != nil {
 			log.Warn(ctx, "failed to get team members", zap.String("team_id", author.TeamID.String()), zap.Error(err))
+			fmt.Fprintf(fingerprint, "author=%s:%s:unavailable\n", author.UserID, author.TeamID)
 			pr.AssignedReviewers = newReviewers
-			if err := s.storage.UpdatePR(ctx, pr); err != nil {
-				log.Error(ctx, "failed to update PR reviewers", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
-			}
+			plan.updates = append(plan.updates, bulkPRUpdate{pr: pr, oldReviewers: oldReviewers})
 			continue
 		}
-		// Find available candidates (active, not author, not already assigned, not being deactivated)
-		excludeMap := make(map[string]bool)
-		excludeMap[pr.AuthorID] = true
-		for _, rid := range newReviewers {
-			excludeMap[rid] = true
+		slices.SortFunc(teamMembers, func(a, b *domain.User) int {
+			return strings.Compare(a.UserID, b.UserID)
+		})
+		policy, ok := policies[author.TeamID]
+		if !ok {
+			if policy, err = s.storage.GetTeamPolicy(ctx, author.TeamID); err != nil {
+				return nil, fmt.Errorf("failed to get team policy: %w", err)
+			}
+			policies[author.TeamID] = policy
 		}
-		for _, uid := range userIDs {
-			excludeMap[uid] = true
+		urgent := s.isUrgent(pr)
+		loads, ok := teamLoads[author.TeamID]
+		if !ok && (policy.MaxOpenReviews > 0 || urgent) {
+			if loads, err = s.openReviewLoads(ctx, teamMembers); err != nil {
+				return nil, err
+			}
+			teamLoads[author.TeamID] = loads
 		}
-		var candidates []*domain.User
+		fmt.Fprintf(fingerprint, "author=%s:%s:cap=%d:window=%d:urgent=%t\n",
+			author.UserID, author.TeamID, policy.MaxOpenReviews, policy.AntiAffinityDays, urgent)
+		// Members being deactivated count as inactive candidates
+		screened := make([]*domain.User, 0, len(teamMembers))
 		for _, member := range teamMembers {
-			if member.IsActive && !excludeMap[member.UserID] {
-				candidates = append(candidates, member)
+			fmt.Fprintf(fingerprint, "candidate=%s:%t:%d:%s\n",
+				member.UserID, member.IsActive, loads[member.UserID], strings.Join(member.Skills, ","))
+			if deactivating[member.UserID] {
+				inactive := *member
+				inactive.IsActive = false
+				member = &inactive
+			}
+			screened = append(screened, member)
+		}
+		pool := screenCandidates(author.TeamID, screened, pr.AuthorID, newReviewers, policy, loads)
+		pool.requiredSkills = pr.RequiredSkills
+		if len(pr.Labels) > 0 {
+			rules, ok := routes[author.TeamID]
+			if !ok {
+				if rules, err = s.storage.GetRoutingRules(ctx, author.TeamID); err != nil {
+					return nil, err
+				}
+				routes[author.TeamID] = rules
+			}
+			for _, rule := range rules {
+				fmt.Fprintf(fingerprint, "route=%s:%s\n", rule.Label, strings.Join(rule.ReviewerIDs, ","))
 			}
+			pool.routeByLabels(rules, pr.Labels, newReviewers)
+		}
+		onCall, ok := onCalls[author.TeamID]
+		if !ok {
+			if onCall, err = s.currentOnCall(ctx, author.TeamID); err != nil {
+				return nil, err
+			}
+			onCalls[author.TeamID] = onCall
+		}
+		fmt.Fprintf(fingerprint, "oncall=%s\n", onCall)
+		pool.takeOnCall(onCall)
+		if urgent {
+			pool.preferLightestQueue()
+		} else if err := s.applyAntiAffinity(ctx, pool, pr.AuthorID, policy); err != nil {
+			return nil, err
+		}
+		for _, candidate := range pool.candidates {
+			fmt.Fprintf(fingerprint, "recent=%s:%d\n", candidate.UserID, pool.recentReviews[candidate.UserID])
 		}
 		// Assign new reviewers up to 2 total
-		neededReviewers := 2 - len(newReviewers)
-		if neededReviewers > 0 && len(candidates) > 0 {
-			// Shuffle and pick
-			rand.Shuffle(len(candidates), func(i, j int) {
-				candidates[i], candidates[j] = candidates[j], candidates[i]
-			})
-			count := min(len(candidates), neededReviewers)
-			for i := 0; i < count; i++ {
-				newReviewers = append(newReviewers, candidates[i].UserID)
+		decision := pool.decide(rng.Uint64(), max(0, 2-len(newReviewers)))
+		if loads != nil {
+			for _, reviewerID := range decision.Selected {
+				loads[reviewerID]++
 			}
 		}
-		// Update PR with new reviewers
+		newReviewers = append(newReviewers, decision.Selected...)
 		pr.AssignedReviewers = newReviewers
-		if err := s.storage.UpdatePR(ctx, pr); err != nil {
-			log.Error(ctx, "failed to update PR", zap.String("pr_id", pr.PullRequestID), zap.Error(err))
+		summary := domain.PRReassignmentSummary{
+			PullRequestID: pr.PullRequestID,
+			OldReviewers:  oldReviewers,
+			NewReviewers:  newReviewers,
+		}
+		plan.updates = append(plan.updates, bulkPRUpdate{pr: pr, oldReviewers: oldReviewers, summary: &summary, decision: decision})
+		plan.reassignments = append(plan.reassignments, summary)
+	}
+	plan.token = formatPlanToken(seed, fingerprint.Sum(nil))
+	return plan, nil
+}
+
+// formatPlanToken encodes the shuffle seed and the state fingerprint as "<seed>.<fingerprint>".
+func formatPlanToken(seed uint64, fingerprint []byte) string {
+	return strconv.FormatUint(seed, 16) + "." + hex.EncodeToString(fingerprint)
+}
+
+// parsePlanToken extracts the shuffle seed from a plan token.
+func parsePlanToken(token string) (uint64, error) {
+	seedPart, fingerprintPart, ok := strings.Cut(token, ".")
+	if !ok || len(fingerprintPart) != hex.EncodedLen(sha256.Size) {
+		return 0, fmt.Errorf("%w: malformed plan token", domain.ErrInvalidRequest)
+	}
+	seed, err := strconv.ParseUint(seedPart, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: malformed plan token", domain.ErrInvalidRequest)
+	}
+	return seed, nil
+}
+
+// BulkActivateTeamUsers reactivates a team (or the listed subset of it).
+// With Rebalance set, open reviews are moved from the members who covered during the absence
+// to the reactivated ones until no covering reviewer has two or more reviews above a newcomer.
+// Slots whose reviewer has already approved are never moved. The activation and the moves are written
+// in one transaction; a PR that changed while the moves were planned keeps its reviewers and is reported
+// as skipped. Only team admins may do it.
+func (s *Service) BulkActivateTeamUsers(ctx context.Context, req *domain.BulkActivateRequest) (*domain.BulkActivateResponse, error) {
+	log := logger.FromContext(ctx)
+	log.Info(ctx, "bulk activating team users",
+		zap.String("team_name", req.TeamName),
+		zap.Strings("user_ids", req.UserIDs),
+		zap.Bool("rebalance", req.Rebalance),
+	)
+	team, err := s.storage.GetTeam(ctx, organizationID(ctx), req.TeamName)
+	if err != nil {
+		return nil, fmt.Errorf("%w: team not found", domain.ErrNotFound)
+	}
+	if err := s.authorize(ctx, team.ID, domain.RoleAdmin); err != nil {
+		return nil, err
+	}
+	members := make(map[string]domain.TeamMember, len(team.Members))
+	for _, member := range team.Members {
+		members[member.UserID] = member
+	}
+	for _, userID := range req.UserIDs {
+		if _, ok := members[userID]; !ok {
+			return nil, fmt.Errorf("%w: user %s is not a member of team %s", domain.ErrNotFound, userID, req.TeamName)
+		}
+	}
+	requested := req.UserIDs
+	if len(requested) == 0 {
+		requested = make([]string, 0, len(team.Members))
+		for _, member := range team.Members {
+			requested = append(requested, member.UserID)
+		}
+	}
+	// Only members that are currently inactive become available
+	activated := make([]string, 0, len(requested))
+	for _, userID := range requested {
+		if !members[userID].IsActive && !slices.Contains(activated, userID) {
+			activated = append(activated, userID)
+		}
+	}
+	slices.Sort(activated)
+	response := &domain.BulkActivateResponse{
+		ActivatedCount:   len(activated),
+		ActivatedUserIDs: activated,
+		RebalancedPRs:    []domain.PRReassignmentSummary{},
+		SkippedPRs:       []string{},
+	}
+	if len(activated) == 0 {
+		log.Info(ctx, "no inactive users to activate", zap.String("team_name", req.TeamName))
+		return response, nil
+	}
+	plan := &rebalancePlan{}
+	if req.Rebalance {
+		if plan, err = s.planRebalance(ctx, team, activated); err != nil {
+			return nil, err
+		}
+	}
+	skipped, err := s.storage.ApplyBulkActivation(ctx, activated, plan.changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to activate users: %w", err)
+	}
+	response.RebalancedPRs = s.recordRebalance(ctx, team, plan, skipped)
+	response.SkippedPRs = append(response.SkippedPRs, skipped...)
+	log.Info(ctx, "bulk activation completed",
+		zap.Int("activated_count", len(activated)),
+		zap.Int("rebalanced_prs", len(response.RebalancedPRs)),
+		zap.Strings("skipped_prs", skipped),
+	)
+	return response, nil
+}
+
+// planRebalance plans moving open review slots of PRs authored in the team onto the newcomers.
+// Each step takes the slot with the largest load gap between its reviewer and an eligible newcomer,
+// so the most loaded covering reviewers are relieved first. The replacement is picked by the normal
+// selection pipeline (routing, skills, on-call, strategy) among the newcomers that would close a gap
+// of two or more, and its decision is kept for recording. A slot the pipeline can't fill is left alone.
+// Nothing is written; the plan is applied together with the activation.
+func (s *Service) planRebalance(ctx context.Context, team *domain.Team, newcomers []string) (*rebalancePlan, error) {
+	isNewcomer := make(map[string]bool, len(newcomers))
+	for _, userID := range newcomers {
+		isNewcomer[userID] = true
+	}
+	members, err := s.storage.GetUsersByTeamID(ctx, team.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team members: %w", err)
+	}
+	slices.SortFunc(members, func(a, b *domain.User) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	inTeam := make(map[string]bool, len(members))
+	reviewerIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		inTeam[member.UserID] = true
+		// Newcomers are activated in the same transaction as the moves, so they are planned as active
+		if isNewcomer[member.UserID] {
+			member.IsActive = true
+		}
+		if member.IsActive {
+			reviewerIDs = append(reviewerIDs, member.UserID)
+		}
+	}
+	openPRs, err := s.storage.GetOpenPRsByReviewers(ctx, reviewerIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open PRs: %w", err)
+	}
+	slices.SortFunc(openPRs, func(a, b *domain.PullRequest) int {
+		return strings.Compare(a.PullRequestID, b.PullRequestID)
+	})
+	loads := make(map[string]int)
+	prs := make([]*domain.PullRequest, 0, len(openPRs))
+	for _, pr := range openPRs {
+		for _, reviewerID := range pr.AssignedReviewers {
+			loads[reviewerID]++
+		}
+		// Reviewers always come from the author's team
+		if inTeam[pr.AuthorID] {
+			prs = append(prs, pr)
+		}
+	}
+	policy, err := s.storage.GetTeamPolicy(ctx, team.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team policy: %w", err)
+	}
+	rules, err := s.storage.GetRoutingRules(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	onCall, err := s.currentOnCall(ctx, team.ID)
+	if err != nil {
+		return nil, err
+	}
+	// eligible lists the newcomers who could take a slot, with the largest load gap they would close
+	eligible := func(pr *domain.PullRequest, reviewerID string) ([]string, int) {
+		var ids []string
+		bestGap := 0
+		for _, newcomerID := range newcomers {
+			if newcomerID == pr.AuthorID || slices.Contains(pr.AssignedReviewers, newcomerID) {
+				continue
+			}
+			if policy.MaxOpenReviews > 0 && loads[newcomerID] >= policy.MaxOpenReviews {
+				continue
+			}
+			if gap := loads[reviewerID] - loads[newcomerID]; gap > 1 {
+				ids = append(ids, newcomerID)
+				bestGap = max(bestGap, gap)
+			}
+		}
+		return ids, bestGap
+	}
+	original := make(map[string][]string)
+	moves := make(map[string][]rebalanceMove)
+	unfillable := make(map[string]bool)
+	for {
+		var (
+			bestPR      *domain.PullRequest
+			bestSlot    int
+			bestGap     = 1
+			bestTargets []string
+		)
+		for _, pr := range prs {
+			for slot, reviewerID := range pr.AssignedReviewers {
+				if isNewcomer[reviewerID] || slices.Contains(pr.ApprovedBy, reviewerID) ||
+					unfillable[pr.PullRequestID+"/"+reviewerID] {
+					continue
+				}
+				if targets, gap := eligible(pr, reviewerID); gap > bestGap {
+					bestPR, bestSlot, bestGap, bestTargets = pr, slot, gap, targets
+				}
+			}
+		}
+		if bestPR == nil {
+			break
+		}
+		// The slot's replacement goes through the selection pipeline, narrowed to the eligible newcomers
+		replaced := bestPR.AssignedReviewers[bestSlot]
+		kept := slices.Delete(slices.Clone(bestPR.AssignedReviewers), bestSlot, bestSlot+1)
+		pool := screenCandidates(team.ID, members, bestPR.AuthorID, bestPR.AssignedReviewers, policy, loads)
+		pool.requiredSkills = bestPR.RequiredSkills
+		if len(bestPR.Labels) > 0 {
+			pool.routeByLabels(rules, bestPR.Labels, kept)
+		}
+		pool.keepOnly(bestTargets, domain.ExcludedNotRebalanceTarget)
+		pool.takeOnCall(onCall)
+		if s.isUrgent(bestPR) {
+			pool.preferLightestQueue()
+		} else if err := s.applyAntiAffinity(ctx, pool, bestPR.AuthorID, policy); err != nil {
+			return nil, err
+		}
+		decision := s.pickReviewers(pool, 1)
+		if len(decision.Selected) == 0 {
+			unfillable[bestPR.PullRequestID+"/"+replaced] = true
+			continue
+		}
+		if _, ok := original[bestPR.PullRequestID]; !ok {
+			original[bestPR.PullRequestID] = slices.Clone(bestPR.AssignedReviewers)
+		}
+		loads[replaced]--
+		loads[decision.Selected[0]]++
+		bestPR.AssignedReviewers[bestSlot] = decision.Selected[0]
+		moves[bestPR.PullRequestID] = append(moves[bestPR.PullRequestID], rebalanceMove{
+			reviewers: slices.Clone(bestPR.AssignedReviewers),
+			decision:  decision,
+		})
+	}
+	plan := &rebalancePlan{prs: make(map[string]*domain.PullRequest), moves: moves}
+	for _, pr := range prs {
+		oldReviewers, ok := original[pr.PullRequestID]
+		if !ok {
 			continue
 		}
-		reassignments = append(reassignments, domain.PRReassignmentSummary{
+		plan.prs[pr.PullRequestID] = pr
+		plan.changes = append(plan.changes, domain.PRReassignmentSummary{
 			PullRequestID: pr.PullRequestID,
 			OldReviewers:  oldReviewers,
-			NewReviewers:  newReviewers,
+			NewReviewers:  pr.AssignedReviewers,
 		})
-		log.Info(ctx, "PR reviewers reassigned",
-			zap.String("pr_id", pr.PullRequestID),
-			zap.Strings("old_reviewers", oldReviewers),
-			zap.Strings("new_reviewers", newReviewers),
-		)
 	}
-	// Deactivate all users in the team
-	if err := s.storage.BulkUpdateUsersActive(ctx, userIDs, false); err != nil {
-		return nil, fmt.Errorf("failed to deactivate users: %w", err)
+	return plan, nil
+}
+
+// recordRebalance records the moves of an applied rebalance plan, leaving out the skipped PRs,
+// and returns the applied changes.
+func (s *Service) recordRebalance(ctx context.Context, team *domain.Team, plan *rebalancePlan, skipped []string) []domain.PRReassignmentSummary {
+	log := logger.FromContext(ctx)
+	rebalanced := make([]domain.PRReassignmentSummary, 0, len(plan.changes))
+	for _, change := range plan.changes {
+		if slices.Contains(skipped, change.PullRequestID) {
+			log.Warn(ctx, "PR changed while rebalancing, reviewers kept", zap.String("pr_id", change.PullRequestID))
+			continue
+		}
+		// Every moved slot is its own event, so each recorded decision matches the reviewers it produced
+		for _, move := range plan.moves[change.PullRequestID] {
+			step := *plan.prs[change.PullRequestID]
+			step.AssignedReviewers = move.reviewers
+			s.recordEvent(ctx, &step, domain.EventRebalanced, "", "team "+team.TeamName+" reactivated", move.decision)
+		}
+		rebalanced = append(rebalanced, change)
+		log.Info(ctx, "PR reviewers rebalanced",
+			zap.String("pr_id", change.PullRequestID),
+			zap.Strings("old_reviewers", change.OldReviewers),
+			zap.Strings("new_reviewers", change.NewReviewers),
+		)
 	}
-	log.Info(ctx, "bulk deactivation completed",
-		zap.Int("deactivated_count", len(userIDs)),
-		zap.Int("reassigned_prs", len(reassignments)),
-	)
-	return &domain.BulkDeactivateResponse{
-		DeactivatedCount: len(userIDs),
-		ReassignedPRs:    reassignments,
-	}, nil
+	return rebalanced
+}
+
+// rebalancePlan is the review slots a reactivation moves: the reviewer change of each PR, in PR order,
+// with the PR as planned and its moves.
+type rebalancePlan struct {
+	changes []domain.PRReassignmentSummary
+	prs     map[string]*domain.PullRequest
+	moves   map[string][]rebalanceMove
+}
+
+// rebalanceMove is one slot moved onto a newcomer: the PR's reviewers after the move and the decision that picked it.
+type rebalanceMove struct {
+	reviewers []string
+	decision  *domain.AssignmentDecision
 }
 
 // GetTeamIDByName resolves team name to team UUID.
@@ -596,7 +1102,7 @@ func (s *Service) GetTeamIDByName(ctx context.Context, teamName string) (string,
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "resolving team name to ID", zap.String("team_name", teamName))
 
-	teamID, err := s.storage.GetTeamIDByName(ctx, teamName)
+	teamID, err := s.storage.GetTeamIDByName(ctx, organizationID(ctx), teamName)
 	if err != nil {
 		return "", err
 	}
@@ -608,5 +1114,5 @@ func (s *Service) GetTeamIDByName(ctx context.Context, teamName string) (string,
 func (s *Service) GetUser(ctx context.Context, userID string) (*domain.User, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "getting user", zap.String("user_id", userID))
-	return s.storage.GetUser(ctx, userID)
+	return s.getUser(ctx, userID)
 }
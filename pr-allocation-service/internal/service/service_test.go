@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+)
+
+// deactivationFixture is team "backend" (a1, u5-u7) whose PRs are reviewed by team "platform" (u1, u2),
+// the team being deactivated.
+func deactivationFixture() *fakeStorage {
+	f := newFakeStorage("a1", "u5", "u6", "u7")
+	f.addTeam("platform", "u1", "u2")
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-1", AuthorID: "a1", AssignedReviewers: []string{"u1", "u5"}})
+	f.addPR(&domain.PullRequest{PullRequestID: "pr-2", AuthorID: "a1", AssignedReviewers: []string{"u2"}, Labels: []string{"db"}})
+	return f
+}
+
+func TestPlanBulkDeactivationFingerprint(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(f *fakeStorage)
+		seed   uint64
+		same   bool
+	}{
+		{name: "same state and seed", change: func(*fakeStorage) {}, seed: 7, same: true},
+		{name: "other seed", change: func(*fakeStorage) {}, seed: 8},
+		{name: "member deactivated", change: func(f *fakeStorage) { f.users["u6"].IsActive = false }, seed: 7},
+		{name: "deactivated team changed", change: func(f *fakeStorage) { f.users["u2"].IsActive = false }, seed: 7},
+		{name: "reviewers changed", change: func(f *fakeStorage) { f.prs["pr-1"].AssignedReviewers = []string{"u1", "u6"} }, seed: 7},
+		{name: "labels changed", change: func(f *fakeStorage) { f.prs["pr-1"].Labels = []string{"ui"} }, seed: 7},
+		{name: "routing rules changed", change: func(f *fakeStorage) {
+			f.rules = []domain.RoutingRule{{Label: "db", ReviewerIDs: []string{"u7"}}}
+		}, seed: 7},
+		{name: "policy changed", change: func(f *fakeStorage) { f.policy.MaxOpenReviews = 3 }, seed: 7},
+		{name: "skills changed", change: func(f *fakeStorage) { f.users["u5"].Skills = []string{"go"} }, seed: 7},
+		{name: "recent reviews changed", change: func(f *fakeStorage) {
+			f.policy.AntiAffinityDays = 7
+			f.recent["u5"] = 2
+		}, seed: 7},
+	}
+	ctx := context.Background()
+	base, err := NewService(deactivationFixture()).planBulkDeactivation(ctx, "platform", 7)
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := deactivationFixture()
+			tt.change(f)
+			plan, err := NewService(f).planBulkDeactivation(ctx, "platform", tt.seed)
+			if err != nil {
+				t.Fatalf("plan: %v", err)
+			}
+			if same := plan.token == base.token; same != tt.same {
+				t.Errorf("token equal to base = %t, want %t", same, tt.same)
+			}
+			seed, err := parsePlanToken(plan.token)
+			if err != nil || seed != tt.seed {
+				t.Errorf("parsePlanToken(%q) = %d, %v; want %d", plan.token, seed, err, tt.seed)
+			}
+		})
+	}
+}
+
+func TestPlanBulkDeactivationRouting(t *testing.T) {
+	f := deactivationFixture()
+	f.rules = []domain.RoutingRule{{Label: "db", ReviewerIDs: []string{"u7"}}}
+	for seed := range uint64(20) {
+		plan, err := NewService(f).planBulkDeactivation(context.Background(), "platform", seed)
+		if err != nil {
+			t.Fatalf("plan: %v", err)
+		}
+		for _, update := range plan.updates {
+			for _, reviewerID := range update.pr.AssignedReviewers {
+				if slices.Contains(plan.userIDs, reviewerID) || reviewerID == "a1" {
+					t.Fatalf("seed %d: %s keeps deactivated reviewer or author %s", seed, update.pr.PullRequestID, reviewerID)
+				}
+			}
+			if update.pr.PullRequestID != "pr-2" {
+				continue
+			}
+			if !slices.Equal(update.pr.AssignedReviewers, []string{"u7"}) || update.decision.RoutedBy != "db" {
+				t.Fatalf("seed %d: pr-2 reviewers %v routed by %q, want [u7] routed by db",
+					seed, update.pr.AssignedReviewers, update.decision.RoutedBy)
+			}
+			if !slices.Equal(update.oldReviewers, []string{"u2"}) {
+				t.Fatalf("seed %d: pr-2 old reviewers %v, want [u2]", seed, update.oldReviewers)
+			}
+		}
+	}
+}
+
+func TestParsePlanTokenMalformed(t *testing.T) {
+	for _, token := range []string{"", "7", "7.abc", "zz." + string(make([]byte, 64))} {
+		if _, err := parsePlanToken(token); !errors.Is(err, domain.ErrInvalidRequest) {
+			t.Errorf("parsePlanToken(%q) error = %v, want ErrInvalidRequest", token, err)
+		}
+	}
+}
+
+func TestRebalanceTeamReviews(t *testing.T) {
+	tests := []struct {
+		name      string
+		setup     func(f *fakeStorage)
+		newcomers []string
+		// moves is how many REBALANCED events get recorded; first is the newcomer picked by the first one
+		moves int
+		first string
+		// skipped are the planned PRs that changed before the plan was applied
+		skipped []string
+	}{
+		{name: "load gap closed", newcomers: []string{"n1"}, moves: 2, first: "n1"},
+		{
+			name: "PR merged while planning",
+			setup: func(f *fakeStorage) {
+				f.beforeApply = func() {
+					f.prs["pr-1"].Status = domain.StatusMerged
+					f.prs["pr-1"].ApprovedBy = []string{"c1"}
+				}
+			},
+			newcomers: []string{"n1"},
+			moves:     1,
+			first:     "n1",
+			skipped:   []string{"pr-1"},
+		},
+		{
+			name: "routed away from newcomers",
+			setup: func(f *fakeStorage) {
+				f.rules = []domain.RoutingRule{{Label: "db", ReviewerIDs: []string{"c1", "c2"}}}
+				for _, pr := range f.prs {
+					pr.Labels = []string{"db"}
+				}
+			},
+			newcomers: []string{"n1"},
+		},
+		{
+			name: "skill match preferred",
+			setup: func(f *fakeStorage) {
+				f.users["n2"].Skills = []string{"go"}
+				for _, pr := range f.prs {
+					pr.RequiredSkills = []string{"go"}
+				}
+			},
+			newcomers: []string{"n1", "n2"},
+			moves:     3,
+			first:     "n2",
+		},
+		{
+			name: "on-call newcomer first",
+			setup: func(f *fakeStorage) {
+				f.rotation = &domain.RotationSchedule{Roster: []string{"n2"}, PeriodDays: 7}
+			},
+			newcomers: []string{"n1", "n2"},
+			moves:     3,
+			first:     "n2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeStorage("a1", "c1", "c2", "n1", "n2")
+			for _, prID := range []string{"pr-1", "pr-2", "pr-3", "pr-4"} {
+				f.addPR(&domain.PullRequest{PullRequestID: prID, AuthorID: "a1", AssignedReviewers: []string{"c1"}})
+			}
+			if tt.setup != nil {
+				tt.setup(f)
+			}
+			for _, userID := range tt.newcomers {
+				f.users[userID].IsActive = false
+			}
+			f.users["a1"].Role = domain.RoleAdmin
+			ctx := WithActor(context.Background(), "a1")
+			svc := NewService(f)
+			resp, err := svc.BulkActivateTeamUsers(ctx, &domain.BulkActivateRequest{TeamName: "backend", UserIDs: tt.newcomers, Rebalance: true})
+			if err != nil {
+				t.Fatalf("bulk activate: %v", err)
+			}
+			if !slices.Equal(resp.SkippedPRs, append([]string{}, tt.skipped...)) {
+				t.Fatalf("skipped %v, want %v", resp.SkippedPRs, tt.skipped)
+			}
+			for _, prID := range tt.skipped {
+				if pr := f.prs[prID]; pr.Status != domain.StatusMerged || !slices.Equal(pr.AssignedReviewers, []string{"c1"}) || len(f.events[prID]) > 0 {
+					t.Fatalf("skipped %s = %+v with %d events, want it left as merged", prID, pr, len(f.events[prID]))
+				}
+			}
+			for _, change := range resp.RebalancedPRs {
+				if !slices.Equal(f.prs[change.PullRequestID].AssignedReviewers, change.NewReviewers) {
+					t.Fatalf("%s has reviewers %v, want %v", change.PullRequestID, f.prs[change.PullRequestID].AssignedReviewers, change.NewReviewers)
+				}
+			}
+			var events []*domain.PREvent
+			for _, prID := range []string{"pr-1", "pr-2", "pr-3", "pr-4"} {
+				events = append(events, f.events[prID]...)
+			}
+			slices.SortFunc(events, func(a, b *domain.PREvent) int { return int(a.EventID - b.EventID) })
+			if len(events) != tt.moves {
+				t.Fatalf("recorded %d events, want %d", len(events), tt.moves)
+			}
+			for i, event := range events {
+				if event.Type != domain.EventRebalanced || event.Decision == nil {
+					t.Fatalf("event %d: type %s with decision %v, want REBALANCED with a decision", i, event.Type, event.Decision)
+				}
+				if !slices.Contains(tt.newcomers, event.Decision.Selected[0]) || !slices.Contains(event.Reviewers, event.Decision.Selected[0]) {
+					t.Errorf("event %d: selected %v not a newcomer on reviewers %v", i, event.Decision.Selected, event.Reviewers)
+				}
+				if i == 0 && event.Decision.Selected[0] != tt.first {
+					t.Errorf("first move picked %v, want %s", event.Decision.Selected, tt.first)
+				}
+				if reason := event.Decision.Excluded["c2"]; reason != domain.ExcludedNotRebalanceTarget {
+					t.Errorf("event %d: c2 excluded as %q, want %s", i, reason, domain.ExcludedNotRebalanceTarget)
+				}
+				replay, err := svc.ReplayAssignment(ctx, event.PullRequestID, event.EventID)
+				if err != nil || !replay.Matches {
+					t.Errorf("event %d: replay = %+v, %v; want a match", i, replay, err)
+				}
+			}
+			if tt.moves == 0 {
+				return
+			}
+			explain, err := svc.ExplainAssignment(ctx, events[0].PullRequestID)
+			if err != nil {
+				t.Fatalf("explain: %v", err)
+			}
+			if explain.EventType != domain.EventRebalanced {
+				t.Errorf("explain reports %s, want the REBALANCED decision", explain.EventType)
+			}
+		})
+	}
+}
+
+func TestMergePRDryRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		approvedBy []string
+		dependsOn  []string
+		wantErr    error
+	}{
+		{name: "mergeable", approvedBy: []string{"u1"}},
+		{name: "not approved", wantErr: domain.ErrNotAllApproved},
+		{name: "dependency open", approvedBy: []string{"u1"}, dependsOn: []string{"pr-0"}, wantErr: domain.ErrDepsNotMerged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeStorage("a1", "u1", "m1")
+			f.users["m1"].Role = domain.RoleMaintainer
+			f.addPR(&domain.PullRequest{PullRequestID: "pr-0", AuthorID: "a1"})
+			f.addPR(&domain.PullRequest{PullRequestID: "pr-1", AuthorID: "a1", AssignedReviewers: []string{"u1"},
+				ApprovedBy: tt.approvedBy, DependsOn: tt.dependsOn})
+			ctx := WithActor(context.Background(), "m1")
+			pr, err := NewService(f).MergePR(ctx, &domain.MergePRRequest{PullRequestID: "pr-1", DryRun: true})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+			} else if err != nil || pr.Status != domain.StatusOpen {
+				t.Fatalf("dry run = %+v, %v; want the open PR", pr, err)
+			}
+			if f.prs["pr-1"].Status != domain.StatusOpen || len(f.events["pr-1"]) > 0 {
+				t.Fatal("dry run changed the PR")
+			}
+		})
+	}
+}
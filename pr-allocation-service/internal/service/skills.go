@@ -0,0 +1,81 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// AddUserSkills adds skill tags to a user (POST /users/addSkills).
+func (s *Service) AddUserSkills(ctx context.Context, req *domain.UserSkillsRequest) (*domain.User, error) {
+	return s.changeUserSkills(ctx, req, mergeTags)
+}
+
+// RemoveUserSkills removes skill tags from a user (POST /users/removeSkills).
+func (s *Service) RemoveUserSkills(ctx context.Context, req *domain.UserSkillsRequest) (*domain.User, error) {
+	return s.changeUserSkills(ctx, req, subtractTags)
+}
+
+// SetUserSkills replaces all skill tags of a user (POST /users/setSkills).
+func (s *Service) SetUserSkills(ctx context.Context, req *domain.UserSkillsRequest) (*domain.User, error) {
+	return s.changeUserSkills(ctx, req, func(_, skills []string) []string {
+		return skills
+	})
+}
+
+func (s *Service) changeUserSkills(
+	ctx context.Context,
+	req *domain.UserSkillsRequest,
+	change func(current, skills []string) []string,
+) (*domain.User, error) {
+	log := logger.FromContext(ctx)
+	skills, err := normalizeTags("skill", req.Skills)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.getUser(ctx, req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: user not found", domain.ErrNotFound)
+	}
+	updated := change(user.Skills, skills)
+	if slices.Equal(updated, user.Skills) {
+		return user, nil
+	}
+	if err := s.storage.SetUserSkills(ctx, user.UserID, updated); err != nil {
+		return nil, err
+	}
+	user.Skills = updated
+	log.Info(ctx, "user skills changed", zap.String("user_id", user.UserID), zap.Strings("skills", user.Skills))
+	return user, nil
+}
+
+// SetPRRequiredSkills replaces the skills a PR asks its reviewers to cover (POST /pullRequest/setRequiredSkills).
+// Current reviewers are kept; the skills apply to the next assignment.
+func (s *Service) SetPRRequiredSkills(ctx context.Context, req *domain.PRSkillsRequest) (*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	skills, err := normalizeTags("skill", req.Skills)
+	if err != nil {
+		return nil, err
+	}
+	pr, err := s.getPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	if slices.Equal(skills, pr.RequiredSkills) {
+		return pr, nil
+	}
+	pr.RequiredSkills = skills
+	if err := s.storage.UpdatePR(ctx, pr); err != nil {
+		log.Error(ctx, "failed to update PR required skills", zap.Error(err))
+		return nil, err
+	}
+	s.recordEvent(ctx, pr, domain.EventRequiredSkillsChanged, "", "required skills: "+strings.Join(skills, ","), nil)
+	log.Info(ctx, "PR required skills changed", zap.String("pr_id", pr.PullRequestID), zap.Strings("skills", skills))
+	return pr, nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// UpdatePRSource records that an open PR's source moved to a new commit (POST /pullRequest/updateSource).
+// Unless the author's team turned dismiss_stale_approvals off, the approvals given so far no longer count
+// and are dismissed; the dismissed reviewers are returned. Only the author or a maintainer may do it.
+func (s *Service) UpdatePRSource(ctx context.Context, req *domain.UpdatePRSourceRequest) (*domain.PullRequest, []string, error) {
+	log := logger.FromContext(ctx)
+	pr, err := s.getPR(ctx, req.PullRequestID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: PR not found", domain.ErrNotFound)
+	}
+	if actorID(ctx) != pr.AuthorID {
+		if err := s.authorizePR(ctx, pr, domain.RoleMaintainer); err != nil {
+			return nil, nil, err
+		}
+	}
+	if pr.Status != domain.StatusOpen {
+		return nil, nil, fmt.Errorf("%w: PR is not open", domain.ErrPRNotOpen)
+	}
+	author, err := s.storage.GetUser(ctx, pr.AuthorID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: PR author not found", domain.ErrNotFound)
+	}
+	policy, err := s.storage.GetTeamPolicy(ctx, author.TeamID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reason := "source: " + req.SourceCommit
+	var dismissed []string
+	if policy.DismissStaleApprovals && len(pr.ApprovedBy) > 0 {
+		dismissed = pr.ApprovedBy
+		pr.ApprovedBy = []string{}
+		if err := s.storage.UpdatePR(ctx, pr); err != nil {
+			log.Error(ctx, "failed to dismiss approvals", zap.Error(err))
+			return nil, nil, err
+		}
+		reason += "; dismissed approvals: " + strings.Join(dismissed, ",")
+	}
+	s.recordEvent(ctx, pr, domain.EventSourceUpdated, actorID(ctx), reason, nil)
+	log.Info(ctx, "PR source updated", zap.String("pr_id", pr.PullRequestID),
+		zap.String("source_commit", req.SourceCommit), zap.Strings("dismissed_approvals", dismissed))
+	return pr, dismissed, nil
+}
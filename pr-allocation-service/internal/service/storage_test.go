@@ -0,0 +1,274 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/pr-allocation-service/internal/storage"
+	"github.com/google/uuid"
+)
+
+// fakeStorage is an in-memory storage covering what reviewer selection reads.
+// Calls to any other method panic on the nil embedded Storage.
+type fakeStorage struct {
+	storage.Storage
+	teams    map[string]uuid.UUID
+	teamID   uuid.UUID
+	users    map[string]*domain.User
+	prs      map[string]*domain.PullRequest
+	policy   domain.TeamPolicy
+	rules    []domain.RoutingRule
+	rotation *domain.RotationSchedule
+	recent   map[string]int
+	events   map[string][]*domain.PREvent
+	eventID  int64
+	// beforeApply runs as a bulk plan or a dependency change is applied, to change the data under it
+	beforeApply func()
+}
+
+// newFakeStorage returns a storage with team "backend" made of active members with the given IDs.
+// Policy, routing rules and rotation are shared by all teams.
+func newFakeStorage(userIDs ...string) *fakeStorage {
+	f := &fakeStorage{
+		teams:  make(map[string]uuid.UUID),
+		users:  make(map[string]*domain.User),
+		prs:    make(map[string]*domain.PullRequest),
+		recent: make(map[string]int),
+		events: make(map[string][]*domain.PREvent),
+	}
+	f.teamID = f.addTeam("backend", userIDs...)
+	return f
+}
+
+// addTeam adds a team of active members with the given IDs and returns its ID.
+func (f *fakeStorage) addTeam(teamName string, userIDs ...string) uuid.UUID {
+	teamID := uuid.NewSHA1(uuid.Nil, []byte(teamName))
+	f.teams[teamName] = teamID
+	for _, userID := range userIDs {
+		f.users[userID] = &domain.User{UserID: userID, Username: userID, TeamID: teamID, IsActive: true, Role: domain.RoleMember}
+	}
+	return teamID
+}
+
+func (f *fakeStorage) addPR(pr *domain.PullRequest) {
+	if pr.Status == "" {
+		pr.Status = domain.StatusOpen
+	}
+	if pr.Priority == "" {
+		pr.Priority = domain.DefaultPriority
+	}
+	f.prs[pr.PullRequestID] = pr
+}
+
+func clonePR(pr *domain.PullRequest) *domain.PullRequest {
+	c := *pr
+	c.AssignedReviewers = slices.Clone(pr.AssignedReviewers)
+	c.ApprovedBy = slices.Clone(pr.ApprovedBy)
+	c.Labels = slices.Clone(pr.Labels)
+	c.RequiredSkills = slices.Clone(pr.RequiredSkills)
+	return &c
+}
+
+func (f *fakeStorage) teamUsers(teamID uuid.UUID) []*domain.User {
+	users := make([]*domain.User, 0, len(f.users))
+	for _, user := range f.users {
+		if user.TeamID != teamID {
+			continue
+		}
+		c := *user
+		users = append(users, &c)
+	}
+	slices.SortFunc(users, func(a, b *domain.User) int {
+		return strings.Compare(a.UserID, b.UserID)
+	})
+	return users
+}
+
+func (f *fakeStorage) GetUser(_ context.Context, userID string) (*domain.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	c := *user
+	return &c, nil
+}
+
+func (f *fakeStorage) UpdateUser(_ context.Context, user *domain.User) error {
+	if _, ok := f.users[user.UserID]; !ok {
+		return errors.New("user not found")
+	}
+	c := *user
+	f.users[user.UserID] = &c
+	return nil
+}
+
+func (f *fakeStorage) GetUsersByTeamID(_ context.Context, teamID uuid.UUID) ([]*domain.User, error) {
+	return f.teamUsers(teamID), nil
+}
+
+func (f *fakeStorage) GetTeam(_ context.Context, _ uuid.UUID, teamName string) (*domain.Team, error) {
+	teamID, ok := f.teams[teamName]
+	if !ok {
+		return nil, errors.New("team not found")
+	}
+	team := &domain.Team{ID: teamID, TeamName: teamName}
+	for _, user := range f.teamUsers(teamID) {
+		team.Members = append(team.Members, domain.TeamMember{UserID: user.UserID, Username: user.Username, IsActive: user.IsActive, Role: user.Role})
+	}
+	return team, nil
+}
+
+func (f *fakeStorage) GetTeamPolicy(_ context.Context, _ uuid.UUID) (*domain.TeamPolicy, error) {
+	policy := f.policy
+	return &policy, nil
+}
+
+func (f *fakeStorage) GetRoutingRules(_ context.Context, _ uuid.UUID) ([]domain.RoutingRule, error) {
+	return slices.Clone(f.rules), nil
+}
+
+func (f *fakeStorage) GetRotation(_ context.Context, _ uuid.UUID) (*domain.RotationSchedule, error) {
+	return f.rotation, nil
+}
+
+func (f *fakeStorage) SetRotation(_ context.Context, _ uuid.UUID, rotation *domain.RotationSchedule) error {
+	f.rotation = rotation
+	return nil
+}
+
+func (f *fakeStorage) GetRecentReviewCounts(_ context.Context, _ string, _ time.Time) (map[string]int, error) {
+	return f.recent, nil
+}
+
+func (f *fakeStorage) GetPR(_ context.Context, prID string) (*domain.PullRequest, error) {
+	pr, ok := f.prs[prID]
+	if !ok {
+		return nil, errors.New("PR not found")
+	}
+	return clonePR(pr), nil
+}
+
+func (f *fakeStorage) GetOpenPRsByReviewers(_ context.Context, userIDs []string) ([]*domain.PullRequest, error) {
+	var prs []*domain.PullRequest
+	for _, pr := range f.prs {
+		if pr.Status == domain.StatusOpen && slices.ContainsFunc(pr.AssignedReviewers, func(id string) bool { return slices.Contains(userIDs, id) }) {
+			prs = append(prs, clonePR(pr))
+		}
+	}
+	return prs, nil
+}
+
+func (f *fakeStorage) GetPREvents(_ context.Context, prID string) ([]*domain.PREvent, error) {
+	return f.events[prID], nil
+}
+
+func (f *fakeStorage) AddPREvent(_ context.Context, event *domain.PREvent) error {
+	f.eventID++
+	event.EventID = f.eventID
+	f.events[event.PullRequestID] = append(f.events[event.PullRequestID], event)
+	return nil
+}
+
+func (f *fakeStorage) UpdatePR(_ context.Context, pr *domain.PullRequest) error {
+	if _, ok := f.prs[pr.PullRequestID]; !ok {
+		return errors.New("PR not found")
+	}
+	f.prs[pr.PullRequestID] = clonePR(pr)
+	return nil
+}
+
+func (f *fakeStorage) ApplyBulkActivation(_ context.Context, userIDs []string, changes []domain.PRReassignmentSummary) ([]string, error) {
+	if f.beforeApply != nil {
+		f.beforeApply()
+	}
+	for _, userID := range userIDs {
+		f.users[userID].IsActive = true
+	}
+	skipped := []string{}
+	for _, change := range changes {
+		pr, ok := f.prs[change.PullRequestID]
+		if !ok || pr.Status != domain.StatusOpen || !slices.Equal(pr.AssignedReviewers, change.OldReviewers) {
+			skipped = append(skipped, change.PullRequestID)
+			continue
+		}
+		pr.AssignedReviewers = slices.Clone(change.NewReviewers)
+	}
+	return skipped, nil
+}
+
+func (f *fakeStorage) GetPRDependents(_ context.Context, prID string) ([]*domain.PullRequest, error) {
+	var prs []*domain.PullRequest
+	for _, pr := range f.prs {
+		if slices.Contains(pr.DependsOn, prID) {
+			prs = append(prs, clonePR(pr))
+		}
+	}
+	slices.SortFunc(prs, func(a, b *domain.PullRequest) int { return strings.Compare(a.PullRequestID, b.PullRequestID) })
+	return prs, nil
+}
+
+// SetPRDependencies repeats the checks of the postgres storage on the stored PRs before writing.
+func (f *fakeStorage) SetPRDependencies(_ context.Context, prID string, dependsOn []string) error {
+	if f.beforeApply != nil {
+		f.beforeApply()
+	}
+	pr, ok := f.prs[prID]
+	if !ok {
+		return errors.New("PR not found")
+	}
+	if pr.Status != domain.StatusOpen {
+		return domain.ErrPRNotOpen
+	}
+	reachable := slices.Clone(dependsOn)
+	for i := 0; i < len(reachable); i++ {
+		if reachable[i] == prID {
+			return domain.ErrInvalidRequest
+		}
+		if dep, ok := f.prs[reachable[i]]; ok {
+			if i < len(dependsOn) && dep.Status == domain.StatusRejected {
+				return domain.ErrInvalidRequest
+			}
+			reachable = append(reachable, dep.DependsOn...)
+		}
+	}
+	pr.DependsOn = slices.Clone(dependsOn)
+	return nil
+}
+
+// RejectPR rejects the PR and cascades to the open PRs stacked on it, as the postgres storage does.
+func (f *fakeStorage) RejectPR(ctx context.Context, prID, reason string) ([]*domain.PullRequest, error) {
+	pr, ok := f.prs[prID]
+	if !ok || pr.Status != domain.StatusOpen {
+		return nil, domain.ErrPRNotOpen
+	}
+	pr.Status, pr.RejectReason = domain.StatusRejected, reason
+	var cascaded []*domain.PullRequest
+	for queue := []string{prID}; len(queue) > 0; queue = queue[1:] {
+		dependents, _ := f.GetPRDependents(ctx, queue[0])
+		for _, dependent := range dependents {
+			if dependent.Status != domain.StatusOpen {
+				continue
+			}
+			stored := f.prs[dependent.PullRequestID]
+			stored.Status, stored.RejectReason = domain.StatusRejected, "dependency "+queue[0]+" was rejected"
+			cascaded = append(cascaded, clonePR(stored))
+			queue = append(queue, stored.PullRequestID)
+		}
+	}
+	return cascaded, nil
+}
+
+func (f *fakeStorage) AnonymizeUser(_ context.Context, userID, anonID string) (*domain.AnonymizationReport, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return nil, errors.New("user not found")
+	}
+	delete(f.users, userID)
+	user.UserID = anonID
+	f.users[anonID] = user
+	return &domain.AnonymizationReport{UserID: userID, AnonymizedID: anonID}, nil
+}
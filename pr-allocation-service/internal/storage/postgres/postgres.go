@@ -3,9 +3,12 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Meldy183/pr-allocation-service/internal/domain"
@@ -48,6 +51,11 @@ func NewPostgresStorage(ctx context.Context, host, port, user, password, dbname,
 	return &Storage{db: db}, nil
 }
 
+// DB exposes the connection pool to readiness checks.
+func (s *Storage) DB() *sql.DB {
+	return s.db
+}
+
 func (s *Storage) Close(ctx context.Context) error {
 	log := logger.FromContext(ctx)
 	if err := s.db.Close(); err != nil {
@@ -58,6 +66,64 @@ func (s *Storage) Close(ctx context.Context) error {
 	return nil
 }
 
+// CreateOrganization Organization operations.
+func (s *Storage) CreateOrganization(ctx context.Context, org *domain.Organization) error {
+	log := logger.FromContext(ctx)
+	query := `INSERT INTO organizations (org_name, created_at, updated_at) VALUES ($1, $2, $3) RETURNING id`
+
+	now := time.Now()
+	org.CreatedAt = now
+	org.UpdatedAt = now
+
+	if err := s.db.QueryRowContext(ctx, query, org.OrgName, org.CreatedAt, org.UpdatedAt).Scan(&org.ID); err != nil {
+		log.Error(ctx, "failed to create organization", zap.Error(err), zap.String("org_name", org.OrgName))
+		return fmt.Errorf("failed to create organization: %w", err)
+	}
+
+	log.Info(ctx, "organization created", zap.String("org_name", org.OrgName), zap.String("organization_id", org.ID.String()))
+	return nil
+}
+
+// GetOrganization returns the organization by name.
+func (s *Storage) GetOrganization(ctx context.Context, orgName string) (*domain.Organization, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT id, org_name, created_at, updated_at FROM organizations WHERE org_name = $1`
+
+	org := &domain.Organization{}
+	err := s.db.QueryRowContext(ctx, query, orgName).Scan(&org.ID, &org.OrgName, &org.CreatedAt, &org.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("organization not found")
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get organization", zap.Error(err), zap.String("org_name", orgName))
+		return nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	return org, nil
+}
+
+// ListOrganizations returns all organizations ordered by name.
+func (s *Storage) ListOrganizations(ctx context.Context) ([]*domain.Organization, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT id, org_name, created_at, updated_at FROM organizations ORDER BY org_name`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		log.Error(ctx, "failed to list organizations", zap.Error(err))
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+	defer rows.Close()
+
+	orgs := make([]*domain.Organization, 0)
+	for rows.Next() {
+		org := &domain.Organization{}
+		if err := rows.Scan(&org.ID, &org.OrgName, &org.CreatedAt, &org.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan organization: %w", err)
+		}
+		orgs = append(orgs, org)
+	}
+	return orgs, rows.Err()
+}
+
 // CreateUser User operations.
 func (s *Storage) CreateUser(ctx context.Context, user *domain.User) error {
 	log := logger.FromContext(ctx)
@@ -91,14 +157,15 @@ func (s *Storage) CreateUser(ctx context.Context, user *domain.User) error {
 
 func (s *Storage) GetUser(ctx context.Context, userID string) (*domain.User, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT u.user_id, u.username, u.team_id, t.team_name, u.is_active, u.created_at, u.updated_at 
+	query := `SELECT u.user_id, u.username, u.team_id, t.team_name, t.organization_id, u.is_active, u.role, u.skills, u.created_at, u.updated_at 
 	          FROM users u LEFT JOIN teams t ON u.team_id = t.id WHERE u.user_id = $1`
 
 	user := &domain.User{}
-	var teamID uuid.NullUUID
+	var teamID, orgID uuid.NullUUID
 	var teamName sql.NullString
 	err := s.db.QueryRowContext(ctx, query, userID).Scan(
-		&user.UserID, &user.Username, &teamID, &teamName, &user.IsActive, &user.CreatedAt, &user.UpdatedAt,
+		&user.UserID, &user.Username, &teamID, &teamName, &orgID, &user.IsActive, &user.Role, pq.Array(&user.Skills),
+		&user.CreatedAt, &user.UpdatedAt,
 	)
 
 	if teamID.Valid {
@@ -107,6 +174,9 @@ func (s *Storage) GetUser(ctx context.Context, userID string) (*domain.User, err
 	if teamName.Valid {
 		user.TeamName = teamName.String
 	}
+	if orgID.Valid {
+		user.OrganizationID = orgID.UUID
+	}
 
 	if err == sql.ErrNoRows {
 		log.Debug(ctx, "user not found", zap.String("user_id", userID))
@@ -153,9 +223,55 @@ func (s *Storage) UpdateUser(ctx context.Context, user *domain.User) error {
 	return nil
 }
 
+// SetUserSkills replaces the user's skill tags.
+func (s *Storage) SetUserSkills(ctx context.Context, userID string, skills []string) error {
+	log := logger.FromContext(ctx)
+	query := `UPDATE users SET skills = $1, updated_at = $2 WHERE user_id = $3`
+
+	result, err := s.db.ExecContext(ctx, query, pq.Array(skills), time.Now(), userID)
+	if err != nil {
+		log.Error(ctx, "failed to set user skills", zap.Error(err), zap.String("user_id", userID))
+		return fmt.Errorf("failed to set user skills: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	log.Info(ctx, "user skills set", zap.String("user_id", userID), zap.Strings("skills", skills))
+	return nil
+}
+
+// SetUserRole sets the user's role in their team.
+func (s *Storage) SetUserRole(ctx context.Context, userID string, role domain.Role) error {
+	log := logger.FromContext(ctx)
+	query := `UPDATE users SET role = $1, updated_at = $2 WHERE user_id = $3`
+
+	result, err := s.db.ExecContext(ctx, query, role, time.Now(), userID)
+	if err != nil {
+		log.Error(ctx, "failed to set user role", zap.Error(err), zap.String("user_id", userID))
+		return fmt.Errorf("failed to set user role: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.New("user not found")
+	}
+
+	log.Info(ctx, "user role set", zap.String("user_id", userID), zap.String("role", string(role)))
+	return nil
+}
+
 func (s *Storage) GetUsersByTeamID(ctx context.Context, teamID uuid.UUID) ([]*domain.User, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT u.user_id, u.username, u.team_id, t.team_name, u.is_active, u.created_at, u.updated_at 
+	query := `SELECT u.user_id, u.username, u.team_id, t.team_name, u.is_active, u.role, u.skills, u.created_at, u.updated_at 
 	          FROM users u LEFT JOIN teams t ON u.team_id = t.id WHERE u.team_id = $1`
 
 	rows, err := s.db.QueryContext(ctx, query, teamID)
@@ -170,7 +286,8 @@ func (s *Storage) GetUsersByTeamID(ctx context.Context, teamID uuid.UUID) ([]*do
 		user := &domain.User{}
 		var tID uuid.NullUUID
 		var teamName sql.NullString
-		if err := rows.Scan(&user.UserID, &user.Username, &tID, &teamName, &user.IsActive, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		if err := rows.Scan(&user.UserID, &user.Username, &tID, &teamName, &user.IsActive, &user.Role, pq.Array(&user.Skills),
+			&user.CreatedAt, &user.UpdatedAt); err != nil {
 			log.Error(ctx, "failed to scan user", zap.Error(err))
 			return nil, fmt.Errorf("failed to scan user: %w", err)
 		}
@@ -198,12 +315,12 @@ func (s *Storage) CreateTeam(ctx context.Context, team *domain.Team) error {
 	defer tx.Rollback()
 
 	// Insert team and get generated UUID
-	query := `INSERT INTO teams (team_name, created_at, updated_at) VALUES ($1, $2, $3) RETURNING id`
+	query := `INSERT INTO teams (organization_id, team_name, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id`
 	now := time.Now()
 	team.CreatedAt = now
 	team.UpdatedAt = now
 
-	err = tx.QueryRowContext(ctx, query, team.TeamName, team.CreatedAt, team.UpdatedAt).Scan(&team.ID)
+	err = tx.QueryRowContext(ctx, query, team.OrganizationID, team.TeamName, team.CreatedAt, team.UpdatedAt).Scan(&team.ID)
 	if err != nil {
 		log.Error(ctx, "failed to create team", zap.Error(err), zap.String("team_name", team.TeamName))
 		return fmt.Errorf("failed to create team: %w", err)
@@ -217,14 +334,15 @@ func (s *Storage) CreateTeam(ctx context.Context, team *domain.Team) error {
 			TeamID:   team.ID,
 			TeamName: team.TeamName,
 			IsActive: member.IsActive,
+			Role:     member.Role,
 		}
 
-		userQuery := `INSERT INTO users (user_id, username, team_id, is_active, created_at, updated_at)
-                      VALUES ($1, $2, $3, $4, $5, $6)
+		userQuery := `INSERT INTO users (user_id, username, team_id, is_active, role, created_at, updated_at)
+                      VALUES ($1, $2, $3, $4, $5, $6, $7)
                       ON CONFLICT (user_id) DO UPDATE 
-                      SET username = $2, team_id = $3, is_active = $4, updated_at = $6`
+                      SET username = $2, team_id = $3, is_active = $4, role = $5, updated_at = $7`
 
-		_, err = tx.ExecContext(ctx, userQuery, user.UserID, user.Username, user.TeamID, user.IsActive, now, now)
+		_, err = tx.ExecContext(ctx, userQuery, user.UserID, user.Username, user.TeamID, user.IsActive, user.Role, now, now)
 		if err != nil {
 			log.Error(ctx, "failed to create/update user", zap.Error(err), zap.String("user_id", user.UserID))
 			return fmt.Errorf("failed to create user: %w", err)
@@ -239,12 +357,13 @@ func (s *Storage) CreateTeam(ctx context.Context, team *domain.Team) error {
 	return nil
 }
 
-func (s *Storage) GetTeam(ctx context.Context, teamName string) (*domain.Team, error) {
+func (s *Storage) GetTeam(ctx context.Context, orgID uuid.UUID, teamName string) (*domain.Team, error) {
 	log := logger.FromContext(ctx)
 
 	// Get team with ID
 	var teamID uuid.UUID
-	err := s.db.QueryRowContext(ctx, `SELECT id FROM teams WHERE team_name = $1`, teamName).Scan(&teamID)
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM teams WHERE organization_id = $1 AND team_name = $2`, orgID, teamName).
+		Scan(&teamID)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("team not found")
 	}
@@ -260,9 +379,10 @@ func (s *Storage) GetTeam(ctx context.Context, teamName string) (*domain.Team, e
 	}
 
 	team := &domain.Team{
-		ID:       teamID,
-		TeamName: teamName,
-		Members:  make([]domain.TeamMember, len(users)),
+		ID:             teamID,
+		TeamName:       teamName,
+		Members:        make([]domain.TeamMember, len(users)),
+		OrganizationID: orgID,
 	}
 
 	for i, user := range users {
@@ -270,6 +390,7 @@ func (s *Storage) GetTeam(ctx context.Context, teamName string) (*domain.Team, e
 			UserID:   user.UserID,
 			Username: user.Username,
 			IsActive: user.IsActive,
+			Role:     user.Role,
 		}
 	}
 
@@ -277,10 +398,11 @@ func (s *Storage) GetTeam(ctx context.Context, teamName string) (*domain.Team, e
 	return team, nil
 }
 
-func (s *Storage) TeamExists(ctx context.Context, teamName string) (bool, error) {
+func (s *Storage) TeamExists(ctx context.Context, orgID uuid.UUID, teamName string) (bool, error) {
 	log := logger.FromContext(ctx)
 	var exists bool
-	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE team_name = $1)`, teamName).Scan(&exists)
+	err := s.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM teams WHERE organization_id = $1 AND team_name = $2)`,
+		orgID, teamName).Scan(&exists)
 	if err != nil {
 		log.Error(ctx, "failed to check team existence", zap.Error(err), zap.String("team_name", teamName))
 		return false, err
@@ -289,11 +411,12 @@ func (s *Storage) TeamExists(ctx context.Context, teamName string) (bool, error)
 	return exists, nil
 }
 
-// GetTeamIDByName returns team UUID by team name
-func (s *Storage) GetTeamIDByName(ctx context.Context, teamName string) (uuid.UUID, error) {
+// GetTeamIDByName returns team UUID by team name within the organization
+func (s *Storage) GetTeamIDByName(ctx context.Context, orgID uuid.UUID, teamName string) (uuid.UUID, error) {
 	log := logger.FromContext(ctx)
 	var teamID uuid.UUID
-	err := s.db.QueryRowContext(ctx, `SELECT id FROM teams WHERE team_name = $1`, teamName).Scan(&teamID)
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM teams WHERE organization_id = $1 AND team_name = $2`, orgID, teamName).
+		Scan(&teamID)
 	if err == sql.ErrNoRows {
 		return uuid.Nil, errors.New("team not found")
 	}
@@ -305,21 +428,192 @@ func (s *Storage) GetTeamIDByName(ctx context.Context, teamName string) (uuid.UU
 	return teamID, nil
 }
 
+// GetTeamPolicy returns the team's assignment policy, or the defaults if none was set.
+func (s *Storage) GetTeamPolicy(ctx context.Context, teamID uuid.UUID) (*domain.TeamPolicy, error) {
+	log := logger.FromContext(ctx)
+	policy := &domain.TeamPolicy{DismissStaleApprovals: true}
+	query := `SELECT max_open_reviews, anti_affinity_days, dismiss_stale_approvals FROM team_policies WHERE team_id = $1`
+	err := s.db.QueryRowContext(ctx, query, teamID).
+		Scan(&policy.MaxOpenReviews, &policy.AntiAffinityDays, &policy.DismissStaleApprovals)
+	if err == sql.ErrNoRows {
+		return policy, nil
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get team policy", zap.Error(err), zap.String("team_id", teamID.String()))
+		return nil, fmt.Errorf("failed to get team policy: %w", err)
+	}
+	return policy, nil
+}
+
+// SetTeamPolicy creates or replaces the team's assignment policy.
+func (s *Storage) SetTeamPolicy(ctx context.Context, teamID uuid.UUID, policy *domain.TeamPolicy) error {
+	log := logger.FromContext(ctx)
+	query := `INSERT INTO team_policies (team_id, max_open_reviews, anti_affinity_days, dismiss_stale_approvals, updated_at)
+              VALUES ($1, $2, $3, $4, $5)
+              ON CONFLICT (team_id) DO UPDATE SET max_open_reviews = EXCLUDED.max_open_reviews,
+              anti_affinity_days = EXCLUDED.anti_affinity_days, dismiss_stale_approvals = EXCLUDED.dismiss_stale_approvals,
+              updated_at = EXCLUDED.updated_at`
+	if _, err := s.db.ExecContext(ctx, query, teamID, policy.MaxOpenReviews, policy.AntiAffinityDays,
+		policy.DismissStaleApprovals, time.Now()); err != nil {
+		log.Error(ctx, "failed to set team policy", zap.Error(err), zap.String("team_id", teamID.String()))
+		return fmt.Errorf("failed to set team policy: %w", err)
+	}
+	log.Info(ctx, "team policy updated", zap.String("team_id", teamID.String()),
+		zap.Int("max_open_reviews", policy.MaxOpenReviews), zap.Int("anti_affinity_days", policy.AntiAffinityDays),
+		zap.Bool("dismiss_stale_approvals", policy.DismissStaleApprovals))
+	return nil
+}
+
+// GetRoutingRules returns the team's label routing rules in the order they are tried.
+func (s *Storage) GetRoutingRules(ctx context.Context, teamID uuid.UUID) ([]domain.RoutingRule, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT label, reviewer_ids FROM team_routing_rules WHERE team_id = $1 ORDER BY position`
+
+	rows, err := s.db.QueryContext(ctx, query, teamID)
+	if err != nil {
+		log.Error(ctx, "failed to get routing rules", zap.Error(err), zap.String("team_id", teamID.String()))
+		return nil, fmt.Errorf("failed to get routing rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := make([]domain.RoutingRule, 0)
+	for rows.Next() {
+		var rule domain.RoutingRule
+		if err := rows.Scan(&rule.Label, pq.Array(&rule.ReviewerIDs)); err != nil {
+			return nil, fmt.Errorf("failed to scan routing rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// SetRoutingRules replaces the team's label routing rules in a transaction.
+func (s *Storage) SetRoutingRules(ctx context.Context, teamID uuid.UUID, rules []domain.RoutingRule) error {
+	log := logger.FromContext(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM team_routing_rules WHERE team_id = $1`, teamID); err != nil {
+		log.Error(ctx, "failed to clear routing rules", zap.Error(err), zap.String("team_id", teamID.String()))
+		return fmt.Errorf("failed to clear routing rules: %w", err)
+	}
+	query := `INSERT INTO team_routing_rules (team_id, position, label, reviewer_ids) VALUES ($1, $2, $3, $4)`
+	for i, rule := range rules {
+		if _, err := tx.ExecContext(ctx, query, teamID, i, rule.Label, pq.Array(rule.ReviewerIDs)); err != nil {
+			log.Error(ctx, "failed to insert routing rule", zap.Error(err), zap.String("label", rule.Label))
+			return fmt.Errorf("failed to insert routing rule: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Info(ctx, "routing rules replaced", zap.String("team_id", teamID.String()), zap.Int("rules", len(rules)))
+	return nil
+}
+
+// GetRotation returns the team's on-call rotation, or nil if it has none.
+func (s *Storage) GetRotation(ctx context.Context, teamID uuid.UUID) (*domain.RotationSchedule, error) {
+	log := logger.FromContext(ctx)
+	rotation := &domain.RotationSchedule{}
+	err := s.db.QueryRowContext(ctx, `SELECT roster, period_days, handover_at FROM team_rotations WHERE team_id = $1`, teamID).
+		Scan(pq.Array(&rotation.Roster), &rotation.PeriodDays, &rotation.HandoverAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error(ctx, "failed to get rotation", zap.Error(err), zap.String("team_id", teamID.String()))
+		return nil, fmt.Errorf("failed to get rotation: %w", err)
+	}
+	return rotation, nil
+}
+
+// SetRotation creates or replaces the team's on-call rotation.
+func (s *Storage) SetRotation(ctx context.Context, teamID uuid.UUID, rotation *domain.RotationSchedule) error {
+	log := logger.FromContext(ctx)
+	query := `INSERT INTO team_rotations (team_id, roster, period_days, handover_at, updated_at) VALUES ($1, $2, $3, $4, $5)
+              ON CONFLICT (team_id) DO UPDATE SET roster = EXCLUDED.roster, period_days = EXCLUDED.period_days,
+              handover_at = EXCLUDED.handover_at, updated_at = EXCLUDED.updated_at`
+	_, err := s.db.ExecContext(ctx, query, teamID, pq.Array(rotation.Roster), rotation.PeriodDays, rotation.HandoverAt, time.Now())
+	if err != nil {
+		log.Error(ctx, "failed to set rotation", zap.Error(err), zap.String("team_id", teamID.String()))
+		return fmt.Errorf("failed to set rotation: %w", err)
+	}
+	log.Info(ctx, "rotation updated", zap.String("team_id", teamID.String()),
+		zap.Strings("roster", rotation.Roster), zap.Int("period_days", rotation.PeriodDays))
+	return nil
+}
+
+// DeleteRotation removes the team's on-call rotation, if any.
+func (s *Storage) DeleteRotation(ctx context.Context, teamID uuid.UUID) error {
+	log := logger.FromContext(ctx)
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM team_rotations WHERE team_id = $1`, teamID); err != nil {
+		log.Error(ctx, "failed to delete rotation", zap.Error(err), zap.String("team_id", teamID.String()))
+		return fmt.Errorf("failed to delete rotation: %w", err)
+	}
+	log.Info(ctx, "rotation removed", zap.String("team_id", teamID.String()))
+	return nil
+}
+
+// GetRecentReviewCounts counts, per reviewer, the author's PRs created since the given time they were assigned to.
+func (s *Storage) GetRecentReviewCounts(ctx context.Context, authorID string, since time.Time) (map[string]int, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT reviewer_id, COUNT(*) FROM pull_requests, unnest(assigned_reviewers) AS reviewer_id
+              WHERE author_id = $1 AND created_at >= $2 GROUP BY reviewer_id`
+
+	rows, err := s.db.QueryContext(ctx, query, authorID, since)
+	if err != nil {
+		log.Error(ctx, "failed to get recent review counts", zap.Error(err), zap.String("author_id", authorID))
+		return nil, fmt.Errorf("failed to get recent review counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var reviewerID string
+		var count int
+		if err := rows.Scan(&reviewerID, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan review count: %w", err)
+		}
+		counts[reviewerID] = count
+	}
+	return counts, rows.Err()
+}
+
 // CreatePR PR operations.
 func (s *Storage) CreatePR(ctx context.Context, pr *domain.PullRequest) error {
 	log := logger.FromContext(ctx)
-	query := `INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, assigned_reviewers, approved_by, created_at, updated_at)
-              VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`
+	query := `INSERT INTO pull_requests (pull_request_id, pull_request_name, author_id, status, assigned_reviewers, approved_by, labels,
+              required_skills, priority, due_at, depends_on, reject_reason, created_at, updated_at, organization_id)
+              VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)`
 
 	now := time.Now()
-	pr.CreatedAt = &now
+	if pr.CreatedAt == nil {
+		pr.CreatedAt = &now
+	}
 	pr.Status = domain.StatusOpen
 	if pr.ApprovedBy == nil {
 		pr.ApprovedBy = []string{}
 	}
+	if pr.Labels == nil {
+		pr.Labels = []string{}
+	}
+	if pr.RequiredSkills == nil {
+		pr.RequiredSkills = []string{}
+	}
+	if pr.DependsOn == nil {
+		pr.DependsOn = []string{}
+	}
+	if pr.Priority == "" {
+		pr.Priority = domain.DefaultPriority
+	}
 
 	_, err := s.db.ExecContext(ctx, query, pr.PullRequestID, pr.PullRequestName, pr.AuthorID, pr.Status,
-		pq.Array(pr.AssignedReviewers), pq.Array(pr.ApprovedBy), pr.CreatedAt, now)
+		pq.Array(pr.AssignedReviewers), pq.Array(pr.ApprovedBy), pq.Array(pr.Labels), pq.Array(pr.RequiredSkills),
+		pr.Priority, pr.DueAt, pq.Array(pr.DependsOn), pr.RejectReason, pr.CreatedAt, now, pr.OrganizationID)
 	if err != nil {
 		log.Error(ctx, "failed to create PR", zap.Error(err), zap.String("pr_id", pr.PullRequestID))
 		return fmt.Errorf("failed to create PR: %w", err)
@@ -330,20 +624,63 @@ func (s *Storage) CreatePR(ctx context.Context, pr *domain.PullRequest) error {
 	return nil
 }
 
-func (s *Storage) GetPR(ctx context.Context, prID string) (*domain.PullRequest, error) {
-	log := logger.FromContext(ctx)
-	query := `SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, approved_by, created_at, merged_at, updated_at 
-              FROM pull_requests WHERE pull_request_id = $1`
+// prColumns lists the pull_requests columns read by scanPR, in order.
+const prColumns = `pull_request_id, pull_request_name, author_id, status, assigned_reviewers, approved_by, labels,
+              required_skills, priority, due_at, depends_on, reject_reason, created_at, merged_at, updated_at, organization_id`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
+// scanPR reads one row selected with prColumns.
+func scanPR(row rowScanner) (*domain.PullRequest, error) {
 	pr := &domain.PullRequest{}
 	var createdAt, updatedAt time.Time
-	var mergedAt sql.NullTime
+	var mergedAt, dueAt sql.NullTime
 
-	err := s.db.QueryRowContext(ctx, query, prID).Scan(
-		&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status,
-		pq.Array(&pr.AssignedReviewers), pq.Array(&pr.ApprovedBy), &createdAt, &mergedAt, &updatedAt,
-	)
+	if err := row.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status,
+		pq.Array(&pr.AssignedReviewers), pq.Array(&pr.ApprovedBy), pq.Array(&pr.Labels), pq.Array(&pr.RequiredSkills),
+		&pr.Priority, &dueAt, pq.Array(&pr.DependsOn), &pr.RejectReason, &createdAt, &mergedAt, &updatedAt,
+		&pr.OrganizationID); err != nil {
+		return nil, err
+	}
 
+	pr.CreatedAt = &createdAt
+	if dueAt.Valid {
+		pr.DueAt = &dueAt.Time
+	}
+	if mergedAt.Valid {
+		pr.MergedAt = &mergedAt.Time
+	}
+	return pr, nil
+}
+
+// queryPRs runs a query selecting prColumns and scans every row.
+func (s *Storage) queryPRs(ctx context.Context, query string, args ...any) ([]*domain.PullRequest, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prs []*domain.PullRequest
+	for rows.Next() {
+		pr, err := scanPR(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan PR: %w", err)
+		}
+		prs = append(prs, pr)
+	}
+
+	return prs, rows.Err()
+}
+
+func (s *Storage) GetPR(ctx context.Context, prID string) (*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT ` + prColumns + ` FROM pull_requests WHERE pull_request_id = $1`
+
+	pr, err := scanPR(s.db.QueryRowContext(ctx, query, prID))
 	if err == sql.ErrNoRows {
 		return nil, errors.New("PR not found")
 	}
@@ -352,11 +689,6 @@ func (s *Storage) GetPR(ctx context.Context, prID string) (*domain.PullRequest,
 		return nil, fmt.Errorf("failed to get PR: %w", err)
 	}
 
-	pr.CreatedAt = &createdAt
-	if mergedAt.Valid {
-		pr.MergedAt = &mergedAt.Time
-	}
-
 	log.Info(ctx, "PR retrieved successfully", zap.String("pr_id", prID), zap.String("status", string(pr.Status)))
 	return pr, nil
 }
@@ -364,13 +696,24 @@ func (s *Storage) GetPR(ctx context.Context, prID string) (*domain.PullRequest,
 func (s *Storage) UpdatePR(ctx context.Context, pr *domain.PullRequest) error {
 	log := logger.FromContext(ctx)
 	query := `UPDATE pull_requests 
-              SET pull_request_name = $1, status = $2, assigned_reviewers = $3, approved_by = $4, merged_at = $5, updated_at = $6
-              WHERE pull_request_id = $7`
+              SET pull_request_name = $1, status = $2, assigned_reviewers = $3, approved_by = $4, merged_at = $5, updated_at = $6,
+                  labels = $7, required_skills = $8, priority = $9, due_at = $10, depends_on = $11, reject_reason = $12
+              WHERE pull_request_id = $13`
 
 	now := time.Now()
+	if pr.Labels == nil {
+		pr.Labels = []string{}
+	}
+	if pr.RequiredSkills == nil {
+		pr.RequiredSkills = []string{}
+	}
+	if pr.DependsOn == nil {
+		pr.DependsOn = []string{}
+	}
 
 	result, err := s.db.ExecContext(ctx, query, pr.PullRequestName, pr.Status, pq.Array(pr.AssignedReviewers),
-		pq.Array(pr.ApprovedBy), pr.MergedAt, now, pr.PullRequestID)
+		pq.Array(pr.ApprovedBy), pr.MergedAt, now, pq.Array(pr.Labels), pq.Array(pr.RequiredSkills),
+		pr.Priority, pr.DueAt, pq.Array(pr.DependsOn), pr.RejectReason, pr.PullRequestID)
 	if err != nil {
 		log.Error(ctx, "failed to update PR", zap.Error(err), zap.String("pr_id", pr.PullRequestID))
 		return fmt.Errorf("failed to update PR: %w", err)
@@ -388,70 +731,177 @@ func (s *Storage) UpdatePR(ctx context.Context, pr *domain.PullRequest) error {
 	return nil
 }
 
-func (s *Storage) GetPRsByReviewer(ctx context.Context, userID string) ([]*domain.PullRequest, error) {
+func (s *Storage) GetPRsByReviewer(ctx context.Context, orgID uuid.UUID, userID string) ([]*domain.PullRequest, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, approved_by, created_at, merged_at, updated_at 
-              FROM pull_requests WHERE $1 = ANY(assigned_reviewers)`
+	// Review queue order: most urgent priority first, then oldest
+	query := `SELECT ` + prColumns + ` FROM pull_requests WHERE organization_id = $1 AND $2 = ANY(assigned_reviewers)
+              ORDER BY priority, created_at, pull_request_id`
 
-	rows, err := s.db.QueryContext(ctx, query, userID)
+	prs, err := s.queryPRs(ctx, query, orgID, userID)
 	if err != nil {
 		log.Error(ctx, "failed to get PRs by reviewer", zap.Error(err), zap.String("user_id", userID))
 		return nil, fmt.Errorf("failed to get PRs: %w", err)
 	}
-	defer rows.Close()
 
-	var prs []*domain.PullRequest
-	for rows.Next() {
-		pr := &domain.PullRequest{}
-		var createdAt, updatedAt time.Time
-		var mergedAt sql.NullTime
+	return prs, nil
+}
 
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status,
-			pq.Array(&pr.AssignedReviewers), pq.Array(&pr.ApprovedBy), &createdAt, &mergedAt, &updatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan PR: %w", err)
-		}
+func (s *Storage) GetPRsByAuthor(ctx context.Context, orgID uuid.UUID, authorID string) ([]*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT ` + prColumns + ` FROM pull_requests WHERE organization_id = $1 AND author_id = $2`
 
-		pr.CreatedAt = &createdAt
-		if mergedAt.Valid {
-			pr.MergedAt = &mergedAt.Time
-		}
-		prs = append(prs, pr)
+	prs, err := s.queryPRs(ctx, query, orgID, authorID)
+	if err != nil {
+		log.Error(ctx, "failed to get PRs by author", zap.Error(err), zap.String("author_id", authorID))
+		return nil, fmt.Errorf("failed to get PRs: %w", err)
 	}
 
 	return prs, nil
 }
 
-func (s *Storage) GetPRsByAuthor(ctx context.Context, authorID string) ([]*domain.PullRequest, error) {
+// GetPRDependents retrieves the PRs that directly depend on the given one.
+func (s *Storage) GetPRDependents(ctx context.Context, prID string) ([]*domain.PullRequest, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, approved_by, created_at, merged_at, updated_at 
-              FROM pull_requests WHERE author_id = $1`
+	query := `SELECT ` + prColumns + ` FROM pull_requests WHERE $1 = ANY(depends_on) ORDER BY pull_request_id`
 
-	rows, err := s.db.QueryContext(ctx, query, authorID)
+	prs, err := s.queryPRs(ctx, query, prID)
 	if err != nil {
-		log.Error(ctx, "failed to get PRs by author", zap.Error(err), zap.String("author_id", authorID))
+		log.Error(ctx, "failed to get PR dependents", zap.Error(err), zap.String("pr_id", prID))
 		return nil, fmt.Errorf("failed to get PRs: %w", err)
 	}
-	defer rows.Close()
 
-	var prs []*domain.PullRequest
-	for rows.Next() {
-		pr := &domain.PullRequest{}
-		var createdAt, updatedAt time.Time
-		var mergedAt sql.NullTime
+	return prs, nil
+}
 
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status,
-			pq.Array(&pr.AssignedReviewers), pq.Array(&pr.ApprovedBy), &createdAt, &mergedAt, &updatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan PR: %w", err)
-		}
+// dependencyLock is the advisory lock that serializes changes to the PR dependency graph: dependency
+// updates and rejections. A cycle can be closed by edits on PRs that share no row, so row locks can't rule it out.
+const dependencyLock = 0x7072646570 // "prdep"
+
+// SetPRDependencies replaces the PRs an open PR depends on, writing only depends_on. Under the dependency
+// lock the checks are repeated on committed data: the PR must still be open, no dependency may be rejected,
+// and none may depend on the PR, directly or not. A failed check is domain.ErrPRNotOpen or domain.ErrInvalidRequest.
+func (s *Storage) SetPRDependencies(ctx context.Context, prID string, dependsOn []string) error {
+	log := logger.FromContext(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, dependencyLock); err != nil {
+		return fmt.Errorf("failed to lock dependencies: %w", err)
+	}
+	var status domain.PRStatus
+	err = tx.QueryRowContext(ctx, `SELECT status FROM pull_requests WHERE pull_request_id = $1 FOR UPDATE`, prID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.New("PR not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get PR: %w", err)
+	}
+	if status != domain.StatusOpen {
+		return fmt.Errorf("%w: PR is not open", domain.ErrPRNotOpen)
+	}
+	var rejected sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT min(pull_request_id) FROM pull_requests WHERE pull_request_id = ANY($1) AND status = $2`,
+		pq.Array(dependsOn), domain.StatusRejected).Scan(&rejected)
+	if err != nil {
+		return fmt.Errorf("failed to check dependencies: %w", err)
+	}
+	if rejected.Valid {
+		return fmt.Errorf("%w: dependency %s was rejected", domain.ErrInvalidRequest, rejected.String)
+	}
+	var cycle bool
+	err = tx.QueryRowContext(ctx, `WITH RECURSIVE reachable(id) AS (
+                  SELECT unnest($2::text[])
+                  UNION
+                  SELECT dep FROM pull_requests p JOIN reachable r ON p.pull_request_id = r.id, unnest(p.depends_on) AS dep
+              )
+              SELECT EXISTS(SELECT 1 FROM reachable WHERE id = $1)`, prID, pq.Array(dependsOn)).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("failed to check dependency cycles: %w", err)
+	}
+	if cycle {
+		return fmt.Errorf("%w: the dependencies of %s would depend on it", domain.ErrInvalidRequest, prID)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE pull_requests SET depends_on = $2, updated_at = $3 WHERE pull_request_id = $1`,
+		prID, pq.Array(dependsOn), time.Now()); err != nil {
+		log.Error(ctx, "failed to update PR dependencies", zap.Error(err), zap.String("pr_id", prID))
+		return fmt.Errorf("failed to update PR dependencies: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// RejectPR rejects an open PR together with every open PR stacked on it, directly or not, in one transaction
+// under the dependency lock, so no dependent can be added halfway. Each dependent's reason names the PR it
+// was stacked on. A PR that is no longer open is domain.ErrPRNotOpen; the rejected dependents are returned
+// in the order they were reached.
+func (s *Storage) RejectPR(ctx context.Context, prID, reason string) ([]*domain.PullRequest, error) {
+	log := logger.FromContext(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, dependencyLock); err != nil {
+		return nil, fmt.Errorf("failed to lock dependencies: %w", err)
+	}
+	now := time.Now()
+	result, err := tx.ExecContext(ctx, `UPDATE pull_requests SET status = $2, reject_reason = $3, updated_at = $4
+              WHERE pull_request_id = $1 AND status = $5`, prID, domain.StatusRejected, reason, now, domain.StatusOpen)
+	if err != nil {
+		log.Error(ctx, "failed to reject PR", zap.Error(err), zap.String("pr_id", prID))
+		return nil, fmt.Errorf("failed to reject PR: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("%w: PR is not open", domain.ErrPRNotOpen)
+	}
 
-		pr.CreatedAt = &createdAt
-		if mergedAt.Valid {
-			pr.MergedAt = &mergedAt.Time
+	var cascaded []*domain.PullRequest
+	queue := []string{prID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		dependents, err := tx.QueryContext(ctx, `UPDATE pull_requests SET status = $2, reject_reason = $3, updated_at = $4
+              WHERE $1 = ANY(depends_on) AND status = $5 RETURNING `+prColumns,
+			current, domain.StatusRejected, fmt.Sprintf("dependency %s was rejected", current), now, domain.StatusOpen)
+		if err != nil {
+			log.Error(ctx, "failed to reject dependent PRs", zap.Error(err), zap.String("pr_id", current))
+			return nil, fmt.Errorf("failed to reject dependent PRs: %w", err)
+		}
+		var rejected []*domain.PullRequest
+		for dependents.Next() {
+			pr, err := scanPR(dependents)
+			if err != nil {
+				dependents.Close()
+				return nil, fmt.Errorf("failed to scan PR: %w", err)
+			}
+			rejected = append(rejected, pr)
+		}
+		dependents.Close()
+		if err := dependents.Err(); err != nil {
+			return nil, fmt.Errorf("failed to reject dependent PRs: %w", err)
+		}
+		slices.SortFunc(rejected, func(a, b *domain.PullRequest) int { return strings.Compare(a.PullRequestID, b.PullRequestID) })
+		for _, pr := range rejected {
+			cascaded = append(cascaded, pr)
+			queue = append(queue, pr.PullRequestID)
 		}
-		prs = append(prs, pr)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return prs, nil
+	log.Info(ctx, "PR rejected", zap.String("pr_id", prID), zap.Int("rejected_dependents", len(cascaded)))
+	return cascaded, nil
 }
 
 func (s *Storage) PRExists(ctx context.Context, prID string) (bool, error) {
@@ -461,36 +911,16 @@ func (s *Storage) PRExists(ctx context.Context, prID string) (bool, error) {
 	return exists, err
 }
 
-// GetAllPRs retrieves all pull requests.
-func (s *Storage) GetAllPRs(ctx context.Context) ([]*domain.PullRequest, error) {
+// GetAllPRs retrieves all pull requests of the organization.
+func (s *Storage) GetAllPRs(ctx context.Context, orgID uuid.UUID) ([]*domain.PullRequest, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at, updated_at 
-              FROM pull_requests`
+	query := `SELECT ` + prColumns + ` FROM pull_requests WHERE organization_id = $1`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	prs, err := s.queryPRs(ctx, query, orgID)
 	if err != nil {
 		log.Error(ctx, "failed to get all PRs", zap.Error(err))
 		return nil, fmt.Errorf("failed to get PRs: %w", err)
 	}
-	defer rows.Close()
-
-	var prs []*domain.PullRequest
-	for rows.Next() {
-		pr := &domain.PullRequest{}
-		var createdAt, updatedAt time.Time
-		var mergedAt sql.NullTime
-
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status,
-			pq.Array(&pr.AssignedReviewers), &createdAt, &mergedAt, &updatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan PR: %w", err)
-		}
-
-		pr.CreatedAt = &createdAt
-		if mergedAt.Valid {
-			pr.MergedAt = &mergedAt.Time
-		}
-		prs = append(prs, pr)
-	}
 
 	return prs, nil
 }
@@ -498,82 +928,140 @@ func (s *Storage) GetAllPRs(ctx context.Context) ([]*domain.PullRequest, error)
 // GetOpenPRsByReviewers retrieves open PRs assigned to any of the given reviewers.
 func (s *Storage) GetOpenPRsByReviewers(ctx context.Context, userIDs []string) ([]*domain.PullRequest, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT pull_request_id, pull_request_name, author_id, status, assigned_reviewers, created_at, merged_at, updated_at 
-              FROM pull_requests 
-              WHERE status = $1 AND assigned_reviewers && $2`
+	query := `SELECT ` + prColumns + ` FROM pull_requests WHERE status = $1 AND assigned_reviewers && $2`
 
-	rows, err := s.db.QueryContext(ctx, query, domain.StatusOpen, pq.Array(userIDs))
+	prs, err := s.queryPRs(ctx, query, domain.StatusOpen, pq.Array(userIDs))
 	if err != nil {
 		log.Error(ctx, "failed to get open PRs by reviewers", zap.Error(err))
 		return nil, fmt.Errorf("failed to get PRs: %w", err)
 	}
-	defer rows.Close()
 
-	var prs []*domain.PullRequest
-	for rows.Next() {
-		pr := &domain.PullRequest{}
-		var createdAt, updatedAt time.Time
-		var mergedAt sql.NullTime
+	log.Info(ctx, "open PRs retrieved by reviewers", zap.Int("count", len(prs)))
+	return prs, nil
+}
 
-		if err := rows.Scan(&pr.PullRequestID, &pr.PullRequestName, &pr.AuthorID, &pr.Status,
-			pq.Array(&pr.AssignedReviewers), &createdAt, &mergedAt, &updatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan PR: %w", err)
-		}
+// SearchPRs runs a full-text search over PR names, labels and reject reasons (search_vector),
+// filtered and paginated as in q. Hits are ranked when q.Query is set and newest first otherwise.
+func (s *Storage) SearchPRs(ctx context.Context, q *domain.PRSearchQuery) ([]domain.PRSearchHit, int, error) {
+	log := logger.FromContext(ctx)
+
+	var args []any
+	arg := func(value any) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	where := []string{"organization_id = " + arg(q.OrganizationID)}
+	rank := "0::float8"
+	order := "created_at DESC, pull_request_id"
+	if q.Query != "" {
+		tsQuery := "websearch_to_tsquery('english', " + arg(q.Query) + ")"
+		where = append(where, "search_vector @@ "+tsQuery)
+		rank = "ts_rank(search_vector, " + tsQuery + ")::float8"
+		order = "rank DESC, " + order
+	}
+	if q.TeamName != "" {
+		where = append(where, "author_id IN (SELECT u.user_id FROM users u JOIN teams t ON t.id = u.team_id WHERE t.organization_id = "+
+			arg(q.OrganizationID)+" AND t.team_name = "+arg(q.TeamName)+")")
+	}
+	if q.Status != "" {
+		where = append(where, "status = "+arg(q.Status))
+	}
+	if q.AuthorID != "" {
+		where = append(where, "author_id = "+arg(q.AuthorID))
+	}
+	if q.ReviewerID != "" {
+		where = append(where, arg(q.ReviewerID)+" = ANY(assigned_reviewers)")
+	}
+	filter := " WHERE " + strings.Join(where, " AND ")
 
-		pr.CreatedAt = &createdAt
-		if mergedAt.Valid {
-			pr.MergedAt = &mergedAt.Time
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pull_requests`+filter, args...).Scan(&total); err != nil {
+		log.Error(ctx, "failed to count PR search results", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to search PRs: %w", err)
+	}
+
+	query := `SELECT ` + prColumns + `, ` + rank + ` AS rank FROM pull_requests` + filter +
+		` ORDER BY ` + order + ` LIMIT ` + arg(q.Limit) + ` OFFSET ` + arg(q.Offset)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		log.Error(ctx, "failed to search PRs", zap.Error(err))
+		return nil, 0, fmt.Errorf("failed to search PRs: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []domain.PRSearchHit{}
+	for rows.Next() {
+		var hit domain.PRSearchHit
+		if hit.PR, err = scanPR(rankedRow{rows, &hit.Rank}); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan PR: %w", err)
 		}
-		prs = append(prs, pr)
+		hits = append(hits, hit)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to search PRs: %w", err)
 	}
 
-	log.Info(ctx, "open PRs retrieved by reviewers", zap.Int("count", len(prs)))
-	return prs, nil
+	log.Info(ctx, "PRs searched", zap.String("query", q.Query), zap.Int("total", total), zap.Int("returned", len(hits)))
+	return hits, total, nil
+}
+
+// rankedRow scans prColumns followed by a rank column.
+type rankedRow struct {
+	rows *sql.Rows
+	rank *float64
+}
+
+func (r rankedRow) Scan(dest ...any) error {
+	return r.rows.Scan(append(dest, r.rank)...)
 }
 
 // Statistics operations
 
-// GetTotalPRsCount returns total number of PRs.
-func (s *Storage) GetTotalPRsCount(ctx context.Context) (int, error) {
+// GetTotalPRsCount returns total number of PRs in the organization.
+func (s *Storage) GetTotalPRsCount(ctx context.Context, orgID uuid.UUID) (int, error) {
 	var count int
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pull_requests`).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pull_requests WHERE organization_id = $1`, orgID).Scan(&count)
 	return count, err
 }
 
-// GetPRsCountByStatus returns number of PRs by status.
-func (s *Storage) GetPRsCountByStatus(ctx context.Context, status domain.PRStatus) (int, error) {
+// GetPRsCountByStatus returns number of PRs in the organization by status.
+func (s *Storage) GetPRsCountByStatus(ctx context.Context, orgID uuid.UUID, status domain.PRStatus) (int, error) {
 	var count int
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pull_requests WHERE status = $1`, status).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM pull_requests WHERE organization_id = $1 AND status = $2`,
+		orgID, status).Scan(&count)
 	return count, err
 }
 
-// GetTotalTeamsCount returns total number of teams.
-func (s *Storage) GetTotalTeamsCount(ctx context.Context) (int, error) {
+// GetTotalTeamsCount returns total number of teams in the organization.
+func (s *Storage) GetTotalTeamsCount(ctx context.Context, orgID uuid.UUID) (int, error) {
 	var count int
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM teams`).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM teams WHERE organization_id = $1`, orgID).Scan(&count)
 	return count, err
 }
 
-// GetTotalUsersCount returns total number of users.
-func (s *Storage) GetTotalUsersCount(ctx context.Context) (int, error) {
+// GetTotalUsersCount returns total number of users in the organization's teams.
+func (s *Storage) GetTotalUsersCount(ctx context.Context, orgID uuid.UUID) (int, error) {
 	var count int
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users u JOIN teams t ON u.team_id = t.id WHERE t.organization_id = $1`,
+		orgID).Scan(&count)
 	return count, err
 }
 
-// GetActiveUsersCount returns number of active users.
-func (s *Storage) GetActiveUsersCount(ctx context.Context) (int, error) {
+// GetActiveUsersCount returns number of active users in the organization's teams.
+func (s *Storage) GetActiveUsersCount(ctx context.Context, orgID uuid.UUID) (int, error) {
 	var count int
-	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE is_active = true`).Scan(&count)
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users u JOIN teams t ON u.team_id = t.id
+              WHERE t.organization_id = $1 AND u.is_active = true`, orgID).Scan(&count)
 	return count, err
 }
 
-// GetAllUsers retrieves all users.
-func (s *Storage) GetAllUsers(ctx context.Context) ([]*domain.User, error) {
+// GetAllUsers retrieves all users of the organization's teams.
+func (s *Storage) GetAllUsers(ctx context.Context, orgID uuid.UUID) ([]*domain.User, error) {
 	log := logger.FromContext(ctx)
-	query := `SELECT user_id, username, team_name, is_active, created_at, updated_at FROM users`
+	query := `SELECT u.user_id, u.username, t.team_name, u.is_active, u.created_at, u.updated_at
+	          FROM users u JOIN teams t ON u.team_id = t.id WHERE t.organization_id = $1`
 
-	rows, err := s.db.QueryContext(ctx, query)
+	rows, err := s.db.QueryContext(ctx, query, orgID)
 	if err != nil {
 		log.Error(ctx, "failed to get all users", zap.Error(err))
 		return nil, fmt.Errorf("failed to get users: %w", err)
@@ -622,3 +1110,358 @@ func (s *Storage) BulkUpdateUsersActive(ctx context.Context, userIDs []string, i
 	log.Info(ctx, "bulk update users completed", zap.Int("updated", int(rows)), zap.Bool("is_active", isActive))
 	return nil
 }
+
+// ApplyBulkDeactivation applies a bulk deactivation plan in one transaction: every PR gets its new reviewers
+// and every listed user is deactivated. A PR that is no longer open with the planned old reviewers,
+// or a user that is no longer active, aborts the whole plan with domain.ErrPlanStale.
+func (s *Storage) ApplyBulkDeactivation(ctx context.Context, changes []domain.PRReassignmentSummary, userIDs []string) error {
+	log := logger.FromContext(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, change := range changes {
+		result, err := tx.ExecContext(ctx, `UPDATE pull_requests SET assigned_reviewers = $1, updated_at = $2
+              WHERE pull_request_id = $3 AND status = $4 AND assigned_reviewers = $5::text[]`,
+			pq.Array(change.NewReviewers), now, change.PullRequestID, domain.StatusOpen, pq.Array(change.OldReviewers))
+		if err != nil {
+			log.Error(ctx, "failed to update PR", zap.Error(err), zap.String("pr_id", change.PullRequestID))
+			return fmt.Errorf("failed to update PR: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			return fmt.Errorf("%w: pull request %s changed", domain.ErrPlanStale, change.PullRequestID)
+		}
+	}
+	result, err := tx.ExecContext(ctx, `UPDATE users SET is_active = false, updated_at = $1
+              WHERE user_id = ANY($2) AND is_active`, now, pq.Array(userIDs))
+	if err != nil {
+		log.Error(ctx, "failed to bulk deactivate users", zap.Error(err))
+		return fmt.Errorf("failed to bulk deactivate users: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows != int64(len(userIDs)) {
+		return fmt.Errorf("%w: team members changed", domain.ErrPlanStale)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Info(ctx, "bulk deactivation applied", zap.Int("prs", len(changes)), zap.Int("users", len(userIDs)))
+	return nil
+}
+
+// ApplyBulkActivation activates users and applies their rebalanced reviews in one transaction. Only
+// assigned_reviewers is written, and only while a PR is still open with the old reviewers it was planned
+// from; the IDs of the PRs that changed since are skipped and returned.
+func (s *Storage) ApplyBulkActivation(ctx context.Context, userIDs []string, changes []domain.PRReassignmentSummary) ([]string, error) {
+	log := logger.FromContext(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET is_active = true, updated_at = $1
+              WHERE user_id = ANY($2) AND NOT is_active`, now, pq.Array(userIDs)); err != nil {
+		log.Error(ctx, "failed to bulk activate users", zap.Error(err))
+		return nil, fmt.Errorf("failed to bulk activate users: %w", err)
+	}
+	skipped := []string{}
+	for _, change := range changes {
+		result, err := tx.ExecContext(ctx, `UPDATE pull_requests SET assigned_reviewers = $1, updated_at = $2
+              WHERE pull_request_id = $3 AND status = $4 AND assigned_reviewers = $5::text[]`,
+			pq.Array(change.NewReviewers), now, change.PullRequestID, domain.StatusOpen, pq.Array(change.OldReviewers))
+		if err != nil {
+			log.Error(ctx, "failed to update PR", zap.Error(err), zap.String("pr_id", change.PullRequestID))
+			return nil, fmt.Errorf("failed to update PR: %w", err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rows == 0 {
+			skipped = append(skipped, change.PullRequestID)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Info(ctx, "bulk activation applied",
+		zap.Int("users", len(userIDs)),
+		zap.Int("prs", len(changes)-len(skipped)),
+		zap.Strings("skipped_prs", skipped),
+	)
+	return skipped, nil
+}
+
+// AnonymizeUser replaces userID with anonID everywhere it is stored, in one transaction:
+// the users row is swapped for an anonymous tombstone (same team, inactive, no skills) and every
+// PR, history event, routing rule and rotation roster is rewritten, so counts stay the same under the new ID.
+func (s *Storage) AnonymizeUser(ctx context.Context, userID, anonID string) (*domain.AnonymizationReport, error) {
+	log := logger.FromContext(ctx)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `INSERT INTO users (user_id, username, team_id, is_active, skills, created_at, updated_at)
+              SELECT $2, $3, team_id, false, '{}', created_at, NOW() FROM users WHERE user_id = $1`,
+		userID, anonID, domain.AnonymizedUsername)
+	if err != nil {
+		log.Error(ctx, "failed to create anonymous user", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to create anonymous user: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return nil, errors.New("user not found")
+	}
+
+	report := &domain.AnonymizationReport{UserID: userID, AnonymizedID: anonID}
+	events, err := anonymizeDecisions(ctx, tx, userID, anonID)
+	if err != nil {
+		log.Error(ctx, "failed to anonymize decisions", zap.Error(err), zap.String("user_id", userID))
+		return nil, err
+	}
+	// Reassignment reasons name the replaced reviewer ("replaced <id>")
+	eventRows, err := tx.QueryContext(ctx, `UPDATE pull_request_events
+              SET actor_id = CASE WHEN actor_id = $1 THEN $2 ELSE actor_id END,
+                  reviewers = array_replace(reviewers, $1, $2),
+                  reason = CASE WHEN reason = 'replaced ' || $1 THEN 'replaced ' || $2 ELSE reason END
+              WHERE actor_id = $1 OR $1 = ANY(reviewers) OR reason = 'replaced ' || $1
+              RETURNING id`, userID, anonID)
+	if err != nil {
+		log.Error(ctx, "failed to anonymize history events", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to anonymize history events: %w", err)
+	}
+	for eventRows.Next() {
+		var id int64
+		if err := eventRows.Scan(&id); err != nil {
+			eventRows.Close()
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		events[id] = true
+	}
+	eventRows.Close()
+	if err := eventRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to anonymize history events: %w", err)
+	}
+	report.Events = len(events)
+
+	steps := []struct {
+		what    string
+		query   string
+		counter *int
+	}{
+		{"authored PRs", `UPDATE pull_requests SET author_id = $2 WHERE author_id = $1`, &report.AuthoredPRs},
+		{"review assignments", `UPDATE pull_requests SET assigned_reviewers = array_replace(assigned_reviewers, $1, $2)
+              WHERE $1 = ANY(assigned_reviewers)`, &report.ReviewAssignments},
+		{"approvals", `UPDATE pull_requests SET approved_by = array_replace(approved_by, $1, $2)
+              WHERE $1 = ANY(approved_by)`, &report.Approvals},
+		{"routing rules", `UPDATE team_routing_rules SET reviewer_ids = array_replace(reviewer_ids, $1, $2)
+              WHERE $1 = ANY(reviewer_ids)`, &report.RoutingRules},
+		{"rotations", `UPDATE team_rotations SET roster = array_replace(roster, $1, $2)
+              WHERE $1 = ANY(roster)`, &report.Rotations},
+	}
+	for _, step := range steps {
+		result, err := tx.ExecContext(ctx, step.query, userID, anonID)
+		if err != nil {
+			log.Error(ctx, "failed to anonymize "+step.what, zap.Error(err), zap.String("user_id", userID))
+			return nil, fmt.Errorf("failed to anonymize %s: %w", step.what, err)
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		*step.counter = int(rows)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE user_id = $1`, userID); err != nil {
+		log.Error(ctx, "failed to delete user", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to delete user: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	log.Info(ctx, "user anonymized", zap.String("anonymized_id", anonID), zap.Int("authored_prs", report.AuthoredPRs),
+		zap.Int("review_assignments", report.ReviewAssignments), zap.Int("events", report.Events))
+	return report, nil
+}
+
+// anonymizeDecisions renames userID to anonID in the recorded assignment decisions and returns the IDs
+// of the events it changed. Decisions are decoded and only their user ID fields rewritten, so a label,
+// skill or strategy that happens to equal the ID stays as it is.
+func anonymizeDecisions(ctx context.Context, tx *sql.Tx, userID, anonID string) (map[int64]bool, error) {
+	// The text match only narrows the rows down; each decision is then checked field by field
+	rows, err := tx.QueryContext(ctx, `SELECT id, decision FROM pull_request_events
+              WHERE strpos(decision::text, to_json($1::text)::text) > 0 FOR UPDATE`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get decisions: %w", err)
+	}
+	type eventDecision struct {
+		id       int64
+		decision domain.AssignmentDecision
+	}
+	var decisions []eventDecision
+	for rows.Next() {
+		var (
+			event eventDecision
+			raw   []byte
+		)
+		if err := rows.Scan(&event.id, &raw); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan decision: %w", err)
+		}
+		if err := json.Unmarshal(raw, &event.decision); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to decode decision of event %d: %w", event.id, err)
+		}
+		decisions = append(decisions, event)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get decisions: %w", err)
+	}
+
+	changed := make(map[int64]bool)
+	for _, event := range decisions {
+		if !event.decision.ReplaceUser(userID, anonID) {
+			continue
+		}
+		raw, err := json.Marshal(event.decision)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode decision: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE pull_request_events SET decision = $2 WHERE id = $1`, event.id, raw); err != nil {
+			return nil, fmt.Errorf("failed to update decision of event %d: %w", event.id, err)
+		}
+		changed[event.id] = true
+	}
+	return changed, nil
+}
+
+// AddPREvent appends an event to a PR's history and sets its ID.
+func (s *Storage) AddPREvent(ctx context.Context, event *domain.PREvent) error {
+	log := logger.FromContext(ctx)
+	query := `INSERT INTO pull_request_events (pull_request_id, event_type, actor_id, reviewers, reason, decision, created_at)
+              VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+
+	var decision []byte
+	if event.Decision != nil {
+		var err error
+		if decision, err = json.Marshal(event.Decision); err != nil {
+			return fmt.Errorf("failed to encode decision: %w", err)
+		}
+	}
+	if event.Reviewers == nil {
+		event.Reviewers = []string{}
+	}
+
+	err := s.db.QueryRowContext(ctx, query, event.PullRequestID, event.Type, event.ActorID,
+		pq.Array(event.Reviewers), event.Reason, decision, event.CreatedAt).Scan(&event.EventID)
+	if err != nil {
+		log.Error(ctx, "failed to add PR event", zap.Error(err), zap.String("pr_id", event.PullRequestID))
+		return fmt.Errorf("failed to add PR event: %w", err)
+	}
+
+	log.Debug(ctx, "PR event added", zap.String("pr_id", event.PullRequestID),
+		zap.String("type", string(event.Type)), zap.Int64("event_id", event.EventID))
+	return nil
+}
+
+// GetPREvents returns a PR's history, oldest first.
+func (s *Storage) GetPREvents(ctx context.Context, prID string) ([]*domain.PREvent, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT id, pull_request_id, event_type, actor_id, reviewers, reason, decision, created_at
+              FROM pull_request_events WHERE pull_request_id = $1 ORDER BY id`
+
+	rows, err := s.db.QueryContext(ctx, query, prID)
+	if err != nil {
+		log.Error(ctx, "failed to get PR events", zap.Error(err), zap.String("pr_id", prID))
+		return nil, fmt.Errorf("failed to get PR events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*domain.PREvent, 0)
+	for rows.Next() {
+		event := &domain.PREvent{}
+		var decision []byte
+		if err := rows.Scan(&event.EventID, &event.PullRequestID, &event.Type, &event.ActorID,
+			pq.Array(&event.Reviewers), &event.Reason, &decision, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan PR event: %w", err)
+		}
+		if decision != nil {
+			event.Decision = &domain.AssignmentDecision{}
+			if err := json.Unmarshal(decision, event.Decision); err != nil {
+				return nil, fmt.Errorf("failed to decode decision: %w", err)
+			}
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// GetLatestPREventID returns the id of the newest PR event, or 0 when there are none.
+func (s *Storage) GetLatestPREventID(ctx context.Context) (int64, error) {
+	var id int64
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM pull_request_events`).Scan(&id); err != nil {
+		return 0, fmt.Errorf("failed to get latest PR event id: %w", err)
+	}
+	return id, nil
+}
+
+// GetUserActivity returns events of the given types after afterID on PRs the user authored or
+// currently reviews per the event, oldest first.
+func (s *Storage) GetUserActivity(
+	ctx context.Context,
+	userID string,
+	afterID int64,
+	types []domain.PREventType,
+	limit int,
+) ([]*domain.PRActivity, error) {
+	log := logger.FromContext(ctx)
+	query := `SELECT e.id, e.event_type, e.pull_request_id, pr.pull_request_name, pr.author_id,
+                     e.actor_id, e.reviewers, e.reason, e.created_at
+              FROM pull_request_events e
+              JOIN pull_requests pr ON pr.pull_request_id = e.pull_request_id
+              WHERE e.id > $2 AND e.event_type = ANY($3) AND (pr.author_id = $1 OR $1 = ANY(e.reviewers))
+              ORDER BY e.id
+              LIMIT $4`
+
+	eventTypes := make([]string, len(types))
+	for i, t := range types {
+		eventTypes[i] = string(t)
+	}
+	rows, err := s.db.QueryContext(ctx, query, userID, afterID, pq.Array(eventTypes), limit)
+	if err != nil {
+		log.Error(ctx, "failed to get user activity", zap.Error(err), zap.String("user_id", userID))
+		return nil, fmt.Errorf("failed to get user activity: %w", err)
+	}
+	defer rows.Close()
+
+	activity := make([]*domain.PRActivity, 0)
+	for rows.Next() {
+		item := &domain.PRActivity{}
+		if err := rows.Scan(&item.EventID, &item.Type, &item.PullRequestID, &item.PullRequestName, &item.AuthorID,
+			&item.ActorID, pq.Array(&item.Reviewers), &item.Reason, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user activity: %w", err)
+		}
+		activity = append(activity, item)
+	}
+
+	return activity, rows.Err()
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"time"
 
 	"github.com/Meldy183/pr-allocation-service/internal/domain"
 	"github.com/google/uuid"
@@ -9,32 +10,59 @@ import (
 
 // Storage defines the interface for data persistence.
 type Storage interface {
+	// CreateOrganization Organization operations
+	CreateOrganization(ctx context.Context, org *domain.Organization) error
+	GetOrganization(ctx context.Context, orgName string) (*domain.Organization, error)
+	ListOrganizations(ctx context.Context) ([]*domain.Organization, error)
 	// CreateUser User operations
 	CreateUser(ctx context.Context, user *domain.User) error
 	GetUser(ctx context.Context, userID string) (*domain.User, error)
 	UpdateUser(ctx context.Context, user *domain.User) error
 	GetUsersByTeamID(ctx context.Context, teamID uuid.UUID) ([]*domain.User, error)
+	SetUserSkills(ctx context.Context, userID string, skills []string) error
+	SetUserRole(ctx context.Context, userID string, role domain.Role) error
 	// CreateTeam Team operations
 	CreateTeam(ctx context.Context, team *domain.Team) error
-	GetTeam(ctx context.Context, teamName string) (*domain.Team, error)
-	TeamExists(ctx context.Context, teamName string) (bool, error)
-	GetTeamIDByName(ctx context.Context, teamName string) (uuid.UUID, error)
+	GetTeam(ctx context.Context, orgID uuid.UUID, teamName string) (*domain.Team, error)
+	TeamExists(ctx context.Context, orgID uuid.UUID, teamName string) (bool, error)
+	GetTeamIDByName(ctx context.Context, orgID uuid.UUID, teamName string) (uuid.UUID, error)
+	GetTeamPolicy(ctx context.Context, teamID uuid.UUID) (*domain.TeamPolicy, error)
+	SetTeamPolicy(ctx context.Context, teamID uuid.UUID, policy *domain.TeamPolicy) error
+	GetRecentReviewCounts(ctx context.Context, authorID string, since time.Time) (map[string]int, error)
+	GetRoutingRules(ctx context.Context, teamID uuid.UUID) ([]domain.RoutingRule, error)
+	SetRoutingRules(ctx context.Context, teamID uuid.UUID, rules []domain.RoutingRule) error
+	GetRotation(ctx context.Context, teamID uuid.UUID) (*domain.RotationSchedule, error)
+	SetRotation(ctx context.Context, teamID uuid.UUID, rotation *domain.RotationSchedule) error
+	DeleteRotation(ctx context.Context, teamID uuid.UUID) error
 	// CreatePR PR operations
 	CreatePR(ctx context.Context, pr *domain.PullRequest) error
 	GetPR(ctx context.Context, prID string) (*domain.PullRequest, error)
 	UpdatePR(ctx context.Context, pr *domain.PullRequest) error
-	GetPRsByReviewer(ctx context.Context, userID string) ([]*domain.PullRequest, error)
-	GetPRsByAuthor(ctx context.Context, authorID string) ([]*domain.PullRequest, error)
+	GetPRsByReviewer(ctx context.Context, orgID uuid.UUID, userID string) ([]*domain.PullRequest, error)
+	GetPRsByAuthor(ctx context.Context, orgID uuid.UUID, authorID string) ([]*domain.PullRequest, error)
 	PRExists(ctx context.Context, prID string) (bool, error)
-	GetAllPRs(ctx context.Context) ([]*domain.PullRequest, error)
+	GetAllPRs(ctx context.Context, orgID uuid.UUID) ([]*domain.PullRequest, error)
 	GetOpenPRsByReviewers(ctx context.Context, userIDs []string) ([]*domain.PullRequest, error)
+	GetPRDependents(ctx context.Context, prID string) ([]*domain.PullRequest, error)
+	SetPRDependencies(ctx context.Context, prID string, dependsOn []string) error
+	RejectPR(ctx context.Context, prID, reason string) ([]*domain.PullRequest, error)
+	SearchPRs(ctx context.Context, q *domain.PRSearchQuery) ([]domain.PRSearchHit, int, error)
+	// AddPREvent PR history operations
+	AddPREvent(ctx context.Context, event *domain.PREvent) error
+	GetPREvents(ctx context.Context, prID string) ([]*domain.PREvent, error)
+	GetLatestPREventID(ctx context.Context) (int64, error)
+	GetUserActivity(ctx context.Context, userID string, afterID int64, types []domain.PREventType, limit int) ([]*domain.PRActivity, error)
 	// GetTotalPRsCount Statistics operations
-	GetTotalPRsCount(ctx context.Context) (int, error)
-	GetPRsCountByStatus(ctx context.Context, status domain.PRStatus) (int, error)
-	GetTotalTeamsCount(ctx context.Context) (int, error)
-	GetTotalUsersCount(ctx context.Context) (int, error)
-	GetActiveUsersCount(ctx context.Context) (int, error)
-	GetAllUsers(ctx context.Context) ([]*domain.User, error)
+	GetTotalPRsCount(ctx context.Context, orgID uuid.UUID) (int, error)
+	GetPRsCountByStatus(ctx context.Context, orgID uuid.UUID, status domain.PRStatus) (int, error)
+	GetTotalTeamsCount(ctx context.Context, orgID uuid.UUID) (int, error)
+	GetTotalUsersCount(ctx context.Context, orgID uuid.UUID) (int, error)
+	GetActiveUsersCount(ctx context.Context, orgID uuid.UUID) (int, error)
+	GetAllUsers(ctx context.Context, orgID uuid.UUID) ([]*domain.User, error)
 	// BulkUpdateUsersActive Bulk operations
 	BulkUpdateUsersActive(ctx context.Context, userIDs []string, isActive bool) error
+	ApplyBulkDeactivation(ctx context.Context, changes []domain.PRReassignmentSummary, userIDs []string) error
+	ApplyBulkActivation(ctx context.Context, userIDs []string, changes []domain.PRReassignmentSummary) ([]string, error)
+	// AnonymizeUser Privacy operations
+	AnonymizeUser(ctx context.Context, userID, anonID string) (*domain.AnonymizationReport, error)
 }
@@ -0,0 +1,302 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/tracing"
+	"github.com/google/uuid"
+)
+
+// tracedStorage opens a span around every call of the wrapped storage.
+type tracedStorage struct {
+	next Storage
+}
+
+// WithTracing wraps a Storage so each call is traced as "storage.<Method>".
+func WithTracing(next Storage) Storage {
+	return &tracedStorage{next: next}
+}
+
+func (s *tracedStorage) CreateOrganization(ctx context.Context, org *domain.Organization) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.CreateOrganization")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateOrganization(ctx, org)
+}
+
+func (s *tracedStorage) GetOrganization(ctx context.Context, orgName string) (_ *domain.Organization, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetOrganization")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetOrganization(ctx, orgName)
+}
+
+func (s *tracedStorage) ListOrganizations(ctx context.Context) (_ []*domain.Organization, err error) {
+	ctx, span := tracing.Start(ctx, "storage.ListOrganizations")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ListOrganizations(ctx)
+}
+
+func (s *tracedStorage) CreateUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.CreateUser")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateUser(ctx, user)
+}
+
+func (s *tracedStorage) GetUser(ctx context.Context, userID string) (_ *domain.User, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetUser")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetUser(ctx, userID)
+}
+
+func (s *tracedStorage) UpdateUser(ctx context.Context, user *domain.User) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.UpdateUser")
+	defer func() { tracing.End(span, err) }()
+	return s.next.UpdateUser(ctx, user)
+}
+
+func (s *tracedStorage) GetUsersByTeamID(ctx context.Context, teamID uuid.UUID) (_ []*domain.User, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetUsersByTeamID")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetUsersByTeamID(ctx, teamID)
+}
+
+func (s *tracedStorage) SetUserSkills(ctx context.Context, userID string, skills []string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetUserSkills")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetUserSkills(ctx, userID, skills)
+}
+
+func (s *tracedStorage) SetUserRole(ctx context.Context, userID string, role domain.Role) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetUserRole")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetUserRole(ctx, userID, role)
+}
+
+func (s *tracedStorage) CreateTeam(ctx context.Context, team *domain.Team) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.CreateTeam")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreateTeam(ctx, team)
+}
+
+func (s *tracedStorage) GetTeam(ctx context.Context, orgID uuid.UUID, teamName string) (_ *domain.Team, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTeam")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTeam(ctx, orgID, teamName)
+}
+
+func (s *tracedStorage) TeamExists(ctx context.Context, orgID uuid.UUID, teamName string) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.TeamExists")
+	defer func() { tracing.End(span, err) }()
+	return s.next.TeamExists(ctx, orgID, teamName)
+}
+
+func (s *tracedStorage) GetTeamIDByName(ctx context.Context, orgID uuid.UUID, teamName string) (_ uuid.UUID, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTeamIDByName")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTeamIDByName(ctx, orgID, teamName)
+}
+
+func (s *tracedStorage) GetTeamPolicy(ctx context.Context, teamID uuid.UUID) (_ *domain.TeamPolicy, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTeamPolicy")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTeamPolicy(ctx, teamID)
+}
+
+func (s *tracedStorage) SetTeamPolicy(ctx context.Context, teamID uuid.UUID, policy *domain.TeamPolicy) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetTeamPolicy")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetTeamPolicy(ctx, teamID, policy)
+}
+
+func (s *tracedStorage) GetRecentReviewCounts(ctx context.Context, authorID string, since time.Time) (_ map[string]int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetRecentReviewCounts")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetRecentReviewCounts(ctx, authorID, since)
+}
+
+func (s *tracedStorage) GetRoutingRules(ctx context.Context, teamID uuid.UUID) (_ []domain.RoutingRule, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetRoutingRules")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetRoutingRules(ctx, teamID)
+}
+
+func (s *tracedStorage) SetRoutingRules(ctx context.Context, teamID uuid.UUID, rules []domain.RoutingRule) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetRoutingRules")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetRoutingRules(ctx, teamID, rules)
+}
+
+func (s *tracedStorage) GetRotation(ctx context.Context, teamID uuid.UUID) (_ *domain.RotationSchedule, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetRotation")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetRotation(ctx, teamID)
+}
+
+func (s *tracedStorage) SetRotation(ctx context.Context, teamID uuid.UUID, rotation *domain.RotationSchedule) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetRotation")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetRotation(ctx, teamID, rotation)
+}
+
+func (s *tracedStorage) DeleteRotation(ctx context.Context, teamID uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.DeleteRotation")
+	defer func() { tracing.End(span, err) }()
+	return s.next.DeleteRotation(ctx, teamID)
+}
+
+func (s *tracedStorage) CreatePR(ctx context.Context, pr *domain.PullRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.CreatePR")
+	defer func() { tracing.End(span, err) }()
+	return s.next.CreatePR(ctx, pr)
+}
+
+func (s *tracedStorage) GetPR(ctx context.Context, prID string) (_ *domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetPR")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetPR(ctx, prID)
+}
+
+func (s *tracedStorage) UpdatePR(ctx context.Context, pr *domain.PullRequest) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.UpdatePR")
+	defer func() { tracing.End(span, err) }()
+	return s.next.UpdatePR(ctx, pr)
+}
+
+func (s *tracedStorage) GetPRsByReviewer(ctx context.Context, orgID uuid.UUID, userID string) (_ []*domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetPRsByReviewer")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetPRsByReviewer(ctx, orgID, userID)
+}
+
+func (s *tracedStorage) GetPRsByAuthor(ctx context.Context, orgID uuid.UUID, authorID string) (_ []*domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetPRsByAuthor")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetPRsByAuthor(ctx, orgID, authorID)
+}
+
+func (s *tracedStorage) PRExists(ctx context.Context, prID string) (_ bool, err error) {
+	ctx, span := tracing.Start(ctx, "storage.PRExists")
+	defer func() { tracing.End(span, err) }()
+	return s.next.PRExists(ctx, prID)
+}
+
+func (s *tracedStorage) GetAllPRs(ctx context.Context, orgID uuid.UUID) (_ []*domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetAllPRs")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetAllPRs(ctx, orgID)
+}
+
+func (s *tracedStorage) GetOpenPRsByReviewers(ctx context.Context, userIDs []string) (_ []*domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetOpenPRsByReviewers")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetOpenPRsByReviewers(ctx, userIDs)
+}
+
+func (s *tracedStorage) GetPRDependents(ctx context.Context, prID string) (_ []*domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetPRDependents")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetPRDependents(ctx, prID)
+}
+
+func (s *tracedStorage) SetPRDependencies(ctx context.Context, prID string, dependsOn []string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.SetPRDependencies")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SetPRDependencies(ctx, prID, dependsOn)
+}
+
+func (s *tracedStorage) RejectPR(ctx context.Context, prID, reason string) (_ []*domain.PullRequest, err error) {
+	ctx, span := tracing.Start(ctx, "storage.RejectPR")
+	defer func() { tracing.End(span, err) }()
+	return s.next.RejectPR(ctx, prID, reason)
+}
+
+func (s *tracedStorage) SearchPRs(ctx context.Context, q *domain.PRSearchQuery) (_ []domain.PRSearchHit, _ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.SearchPRs")
+	defer func() { tracing.End(span, err) }()
+	return s.next.SearchPRs(ctx, q)
+}
+
+func (s *tracedStorage) AddPREvent(ctx context.Context, event *domain.PREvent) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.AddPREvent")
+	defer func() { tracing.End(span, err) }()
+	return s.next.AddPREvent(ctx, event)
+}
+
+func (s *tracedStorage) GetPREvents(ctx context.Context, prID string) (_ []*domain.PREvent, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetPREvents")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetPREvents(ctx, prID)
+}
+
+func (s *tracedStorage) GetLatestPREventID(ctx context.Context) (_ int64, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetLatestPREventID")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetLatestPREventID(ctx)
+}
+
+func (s *tracedStorage) GetUserActivity(ctx context.Context, userID string, afterID int64, types []domain.PREventType, limit int) (_ []*domain.PRActivity, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetUserActivity")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetUserActivity(ctx, userID, afterID, types, limit)
+}
+
+func (s *tracedStorage) GetTotalPRsCount(ctx context.Context, orgID uuid.UUID) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTotalPRsCount")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTotalPRsCount(ctx, orgID)
+}
+
+func (s *tracedStorage) GetPRsCountByStatus(ctx context.Context, orgID uuid.UUID, status domain.PRStatus) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetPRsCountByStatus")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetPRsCountByStatus(ctx, orgID, status)
+}
+
+func (s *tracedStorage) GetTotalTeamsCount(ctx context.Context, orgID uuid.UUID) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTotalTeamsCount")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTotalTeamsCount(ctx, orgID)
+}
+
+func (s *tracedStorage) GetTotalUsersCount(ctx context.Context, orgID uuid.UUID) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetTotalUsersCount")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetTotalUsersCount(ctx, orgID)
+}
+
+func (s *tracedStorage) GetActiveUsersCount(ctx context.Context, orgID uuid.UUID) (_ int, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetActiveUsersCount")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetActiveUsersCount(ctx, orgID)
+}
+
+func (s *tracedStorage) GetAllUsers(ctx context.Context, orgID uuid.UUID) (_ []*domain.User, err error) {
+	ctx, span := tracing.Start(ctx, "storage.GetAllUsers")
+	defer func() { tracing.End(span, err) }()
+	return s.next.GetAllUsers(ctx, orgID)
+}
+
+func (s *tracedStorage) BulkUpdateUsersActive(ctx context.Context, userIDs []string, isActive bool) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.BulkUpdateUsersActive")
+	defer func() { tracing.End(span, err) }()
+	return s.next.BulkUpdateUsersActive(ctx, userIDs, isActive)
+}
+
+func (s *tracedStorage) ApplyBulkDeactivation(ctx context.Context, changes []domain.PRReassignmentSummary, userIDs []string) (err error) {
+	ctx, span := tracing.Start(ctx, "storage.ApplyBulkDeactivation")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ApplyBulkDeactivation(ctx, changes, userIDs)
+}
+
+func (s *tracedStorage) ApplyBulkActivation(ctx context.Context, userIDs []string, changes []domain.PRReassignmentSummary) (_ []string, err error) {
+	ctx, span := tracing.Start(ctx, "storage.ApplyBulkActivation")
+	defer func() { tracing.End(span, err) }()
+	return s.next.ApplyBulkActivation(ctx, userIDs, changes)
+}
+
+func (s *tracedStorage) AnonymizeUser(ctx context.Context, userID, anonID string) (_ *domain.AnonymizationReport, err error) {
+	ctx, span := tracing.Start(ctx, "storage.AnonymizeUser")
+	defer func() { tracing.End(span, err) }()
+	return s.next.AnonymizeUser(ctx, userID, anonID)
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// SetPRDependencies POST /pullRequest/setDependencies.
+func (h *Handler) SetPRDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.PRDependenciesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PullRequestID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.service.SetPRDependencies(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set PR dependencies", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		if errors.Is(err, domain.ErrPRNotOpen) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRNotOpen, "PR is not open")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]*domain.PullRequest{"pr": pr})
+}
+
+// GetPRDependencies GET /pullRequest/dependencies?pull_request_id=...
+func (h *Handler) GetPRDependencies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id query parameter required")
+		return
+	}
+
+	graph, err := h.service.GetPRDependencyGraph(ctx, prID)
+	if err != nil {
+		log.Error(ctx, "failed to get PR dependencies", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, graph)
+}
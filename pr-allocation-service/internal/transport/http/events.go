@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// Activity stream timing.
+const (
+	activityPollInterval = time.Second
+	activityHeartbeat    = 15 * time.Second
+	// activityRetry is the reconnect delay suggested to clients, in milliseconds
+	activityRetry = 3000
+)
+
+// StreamEvents GET /events/stream?user_id=...
+// Streams the user's PR activity as text/event-stream. Event ids are PR event ids, so a client
+// reconnecting with Last-Event-ID resumes right after the last event it saw.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id query parameter required")
+		return
+	}
+	var lastEventID int64 = -1
+	if raw := r.Header.Get("Last-Event-ID"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id < 0 {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "Last-Event-ID must be a non-negative integer")
+			return
+		}
+		lastEventID = id
+	}
+
+	cursor, err := h.service.ActivityCursor(ctx, userID)
+	if err != nil {
+		log.Error(ctx, "failed to open event stream", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "user not found")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+	if lastEventID >= 0 {
+		cursor = lastEventID
+	}
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn(ctx, "failed to clear write deadline for event stream", zap.Error(err))
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", activityRetry); err != nil || rc.Flush() != nil {
+		return
+	}
+	log.Info(ctx, "event stream opened", zap.String("user_id", userID), zap.Int64("cursor", cursor))
+
+	poll := time.NewTicker(activityPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(activityHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.closing:
+			return
+		case <-heartbeat.C:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-poll.C:
+			activity, err := h.service.GetUserActivity(ctx, userID, cursor)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Error(ctx, "failed to poll user activity", zap.Error(err), zap.String("user_id", userID))
+				}
+				continue
+			}
+			if len(activity) == 0 {
+				continue
+			}
+			for _, item := range activity {
+				if err := writeActivity(w, item); err != nil {
+					return
+				}
+				cursor = item.EventID
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeActivity writes one server-sent event named after the activity kind.
+func writeActivity(w io.Writer, item *domain.PRActivity) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", item.EventID, item.Kind, data)
+	return err
+}
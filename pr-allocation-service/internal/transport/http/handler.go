@@ -1,12 +1,17 @@
 package http
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/Meldy183/pr-allocation-service/internal/domain"
 	"github.com/Meldy183/pr-allocation-service/internal/service"
 	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/problem"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
@@ -15,14 +20,23 @@ import (
 
 type Handler struct {
 	service *service.Service
+	// closing ends open event streams on shutdown
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 
 func NewHandler(svc *service.Service) *Handler {
 	return &Handler{
 		service: svc,
+		closing: make(chan struct{}),
 	}
 }
 
+// CloseStreams ends open event streams so graceful shutdown does not wait on them.
+func (h *Handler) CloseStreams() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
 func (h *Handler) LoggingMiddleware(log logger.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -47,28 +61,64 @@ func (h *Handler) RegisterRoutes(router *mux.Router, log logger.Logger) {
 	// Health
 	router.HandleFunc("/health", h.HealthCheck).Methods("GET")
 
+	// Organizations
+	router.HandleFunc("/org/add", h.CreateOrganization).Methods("POST")
+	router.HandleFunc("/org/list", h.ListOrganizations).Methods("GET")
+
+	// Everything below is scoped to the organization named in X-Organization
+	scoped := router.NewRoute().Subrouter()
+	scoped.Use(h.OrganizationMiddleware, h.ActorMiddleware)
+
 	// Teams - matching OpenAPI spec
-	router.HandleFunc("/team/add", h.CreateTeam).Methods("POST")
-	router.HandleFunc("/team/get", h.GetTeam).Methods("GET")
-	router.HandleFunc("/team/resolve", h.ResolveTeamID).Methods("GET")
-	router.HandleFunc("/team/deactivateUsers", h.BulkDeactivateTeamUsers).Methods("POST")
+	scoped.HandleFunc("/team/add", h.CreateTeam).Methods("POST")
+	scoped.HandleFunc("/team/get", h.GetTeam).Methods("GET")
+	scoped.HandleFunc("/team/resolve", h.ResolveTeamID).Methods("GET")
+	scoped.HandleFunc("/team/deactivateUsers", h.BulkDeactivateTeamUsers).Methods("POST")
+	scoped.HandleFunc("/team/activateUsers", h.BulkActivateTeamUsers).Methods("POST")
+	scoped.HandleFunc("/team/getPolicy", h.GetTeamPolicy).Methods("GET")
+	scoped.HandleFunc("/team/setPolicy", h.SetTeamPolicy).Methods("POST")
+	scoped.HandleFunc("/team/getRoutingRules", h.GetRoutingRules).Methods("GET")
+	scoped.HandleFunc("/team/setRoutingRules", h.SetRoutingRules).Methods("POST")
+	scoped.HandleFunc("/team/setMemberRole", h.SetMemberRole).Methods("POST")
+	scoped.HandleFunc("/team/getRotation", h.GetRotation).Methods("GET")
+	scoped.HandleFunc("/team/setRotation", h.SetRotation).Methods("POST")
+	scoped.HandleFunc("/team/onCall", h.GetOnCall).Methods("GET")
 
 	// Users - matching OpenAPI spec
-	router.HandleFunc("/users/setIsActive", h.SetUserActive).Methods("POST")
-	router.HandleFunc("/users/getReview", h.GetPRsByReviewer).Methods("GET")
-	router.HandleFunc("/users/getAuthored", h.GetPRsByAuthor).Methods("GET")
-	router.HandleFunc("/users/get", h.GetUser).Methods("GET")
+	scoped.HandleFunc("/users/setIsActive", h.SetUserActive).Methods("POST")
+	scoped.HandleFunc("/users/getReview", h.GetPRsByReviewer).Methods("GET")
+	scoped.HandleFunc("/users/getAuthored", h.GetPRsByAuthor).Methods("GET")
+	scoped.HandleFunc("/users/get", h.GetUser).Methods("GET")
+	scoped.HandleFunc("/users/addSkills", h.AddUserSkills).Methods("POST")
+	scoped.HandleFunc("/users/removeSkills", h.RemoveUserSkills).Methods("POST")
+	scoped.HandleFunc("/users/setSkills", h.SetUserSkills).Methods("POST")
+	scoped.HandleFunc("/users/anonymize", h.AnonymizeUser).Methods("POST")
 
 	// PRs - matching OpenAPI spec
-	router.HandleFunc("/pullRequest/create", h.CreatePR).Methods("POST")
-	router.HandleFunc("/pullRequest/get", h.GetPR).Methods("GET")
-	router.HandleFunc("/pullRequest/approve", h.ApprovePR).Methods("POST")
-	router.HandleFunc("/pullRequest/reject", h.RejectPR).Methods("POST")
-	router.HandleFunc("/pullRequest/merge", h.MergePR).Methods("POST")
-	router.HandleFunc("/pullRequest/reassign", h.ReassignReviewer).Methods("POST")
+	scoped.HandleFunc("/pullRequest/create", h.CreatePR).Methods("POST")
+	scoped.HandleFunc("/pullRequest/get", h.GetPR).Methods("GET")
+	scoped.HandleFunc("/pullRequest/approve", h.ApprovePR).Methods("POST")
+	scoped.HandleFunc("/pullRequest/reject", h.RejectPR).Methods("POST")
+	scoped.HandleFunc("/pullRequest/merge", h.MergePR).Methods("POST")
+	scoped.HandleFunc("/pullRequest/reassign", h.ReassignReviewer).Methods("POST")
+	scoped.HandleFunc("/pullRequest/history", h.GetPRHistory).Methods("GET")
+	scoped.HandleFunc("/pullRequest/replay", h.ReplayAssignment).Methods("GET")
+	scoped.HandleFunc("/pullRequest/explain", h.ExplainAssignment).Methods("GET")
+	scoped.HandleFunc("/pullRequest/addLabels", h.AddPRLabels).Methods("POST")
+	scoped.HandleFunc("/pullRequest/removeLabels", h.RemovePRLabels).Methods("POST")
+	scoped.HandleFunc("/pullRequest/setLabels", h.SetPRLabels).Methods("POST")
+	scoped.HandleFunc("/pullRequest/setRequiredSkills", h.SetPRRequiredSkills).Methods("POST")
+	scoped.HandleFunc("/pullRequest/setPriority", h.SetPRPriority).Methods("POST")
+	scoped.HandleFunc("/pullRequest/updateSource", h.UpdatePRSource).Methods("POST")
+	scoped.HandleFunc("/pullRequest/setDependencies", h.SetPRDependencies).Methods("POST")
+	scoped.HandleFunc("/pullRequest/dependencies", h.GetPRDependencies).Methods("GET")
+	scoped.HandleFunc("/pullRequest/search", h.SearchPRs).Methods("GET")
 
 	// Statistics
-	router.HandleFunc("/statistics", h.GetStatistics).Methods("GET")
+	scoped.HandleFunc("/statistics", h.GetStatistics).Methods("GET")
+
+	// Events
+	scoped.HandleFunc("/events/stream", h.StreamEvents).Methods("GET")
 }
 
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -82,17 +132,17 @@ func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	var req domain.CreateTeamRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	// Validate required fields
 	if req.TeamName == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 	if len(req.Members) == 0 {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "at least one member is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "at least one member is required")
 		return
 	}
 
@@ -100,11 +150,15 @@ func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error(ctx, "failed to create team", zap.Error(err))
 		// Check if team exists error
-		if contains(err.Error(), domain.ErrTeamExists) {
-			h.respondError(w, r, http.StatusBadRequest, domain.ErrTeamExists, "team_name already exists")
+		if errors.Is(err, domain.ErrTeamExists) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeTeamExists, "team_name already exists")
 			return
 		}
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -118,14 +172,14 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "team_name query parameter required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter required")
 		return
 	}
 
 	team, err := h.service.GetTeam(ctx, teamName)
 	if err != nil {
 		log.Error(ctx, "failed to get team", zap.Error(err))
-		h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "team not found")
+		h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
 		return
 	}
 
@@ -140,19 +194,22 @@ func (h *Handler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 	var req domain.SetUserActiveRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.UserID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "user_id is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id is required")
 		return
 	}
 
 	user, err := h.service.SetUserActive(ctx, &req)
 	if err != nil {
 		log.Error(ctx, "failed to set user active", zap.Error(err))
-		h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "user not found")
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "user not found")
 		return
 	}
 
@@ -167,13 +224,13 @@ func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	var req domain.CreatePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	// Validate required fields
 	if req.PullRequestID == "" || req.PullRequestName == "" || req.AuthorID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "pull_request_id, pull_request_name, and author_id are required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id, pull_request_name, and author_id are required")
 		return
 	}
 
@@ -182,16 +239,20 @@ func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 		log.Error(ctx, "failed to create PR", zap.Error(err))
 
 		// Check specific error codes
-		if contains(err.Error(), domain.ErrPRExists) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrPRExists, "PR id already exists")
+		if errors.Is(err, domain.ErrPRExists) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRExists, "PR id already exists")
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "author or team not found")
 			return
 		}
-		if contains(err.Error(), domain.ErrNotFound) {
-			h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "author or team not found")
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
 			return
 		}
 
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -205,14 +266,14 @@ func (h *Handler) GetPR(w http.ResponseWriter, r *http.Request) {
 
 	prID := r.URL.Query().Get("pull_request_id")
 	if prID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "pull_request_id query parameter required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id query parameter required")
 		return
 	}
 
 	pr, err := h.service.GetPR(ctx, prID)
 	if err != nil {
 		log.Error(ctx, "failed to get PR", zap.Error(err))
-		h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "PR not found")
+		h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
 		return
 	}
 
@@ -227,12 +288,12 @@ func (h *Handler) ApprovePR(w http.ResponseWriter, r *http.Request) {
 	var req domain.ApprovePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.PullRequestID == "" || req.ReviewerID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "pull_request_id and reviewer_id are required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id and reviewer_id are required")
 		return
 	}
 
@@ -240,20 +301,20 @@ func (h *Handler) ApprovePR(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error(ctx, "failed to approve PR", zap.Error(err))
 
-		if contains(err.Error(), domain.ErrNotFound) {
-			h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "PR not found")
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
 			return
 		}
-		if contains(err.Error(), domain.ErrNotAssigned) {
-			h.respondError(w, r, http.StatusForbidden, domain.ErrNotAssigned, "reviewer is not assigned to this PR")
+		if errors.Is(err, domain.ErrNotAssigned) {
+			h.respondError(w, r, http.StatusForbidden, domain.ErrCodeNotAssigned, "reviewer is not assigned to this PR")
 			return
 		}
-		if contains(err.Error(), domain.ErrPRNotOpen) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrPRNotOpen, "PR is not open")
+		if errors.Is(err, domain.ErrPRNotOpen) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRNotOpen, "PR is not open")
 			return
 		}
 
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -271,12 +332,12 @@ func (h *Handler) RejectPR(w http.ResponseWriter, r *http.Request) {
 	var req domain.RejectPRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.PullRequestID == "" || req.ReviewerID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "pull_request_id and reviewer_id are required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id and reviewer_id are required")
 		return
 	}
 
@@ -284,20 +345,20 @@ func (h *Handler) RejectPR(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		log.Error(ctx, "failed to reject PR", zap.Error(err))
 
-		if contains(err.Error(), domain.ErrNotFound) {
-			h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "PR not found")
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
 			return
 		}
-		if contains(err.Error(), domain.ErrNotAssigned) {
-			h.respondError(w, r, http.StatusForbidden, domain.ErrNotAssigned, "reviewer is not assigned to this PR")
+		if errors.Is(err, domain.ErrNotAssigned) {
+			h.respondError(w, r, http.StatusForbidden, domain.ErrCodeNotAssigned, "reviewer is not assigned to this PR")
 			return
 		}
-		if contains(err.Error(), domain.ErrPRNotOpen) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrPRNotOpen, "PR is not open")
+		if errors.Is(err, domain.ErrPRNotOpen) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRNotOpen, "PR is not open")
 			return
 		}
 
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -312,33 +373,40 @@ func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
 	var req domain.MergePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.PullRequestID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "pull_request_id is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id is required")
 		return
 	}
 
 	pr, err := h.service.MergePR(ctx, &req)
 	if err != nil {
 		log.Error(ctx, "failed to merge PR", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
 
-		if contains(err.Error(), domain.ErrNotFound) {
-			h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "PR not found")
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
 			return
 		}
-		if contains(err.Error(), domain.ErrNotAllApproved) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrNotAllApproved, "not all reviewers have approved")
+		if errors.Is(err, domain.ErrNotAllApproved) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodeNotAllApproved, "not all reviewers have approved")
 			return
 		}
-		if contains(err.Error(), domain.ErrPRRejected) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrPRRejected, "PR was rejected")
+		if errors.Is(err, domain.ErrDepsNotMerged) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodeDepsNotMerged, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrPRRejected) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRRejected, "PR was rejected")
 			return
 		}
 
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -353,38 +421,41 @@ func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 	var req domain.ReassignRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.PullRequestID == "" || req.OldUserID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "pull_request_id and old_user_id are required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id and old_user_id are required")
 		return
 	}
 
 	newReviewerID, pr, err := h.service.ReassignReviewer(ctx, &req)
 	if err != nil {
 		log.Error(ctx, "failed to reassign reviewer", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
 
 		// Check specific error codes
-		if contains(err.Error(), domain.ErrPRMerged) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrPRMerged, "cannot reassign on merged PR")
+		if errors.Is(err, domain.ErrPRMerged) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRMerged, "cannot reassign on merged PR")
 			return
 		}
-		if contains(err.Error(), domain.ErrNotAssigned) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrNotAssigned, "reviewer is not assigned to this PR")
+		if errors.Is(err, domain.ErrNotAssigned) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodeNotAssigned, "reviewer is not assigned to this PR")
 			return
 		}
-		if contains(err.Error(), domain.ErrNoCandidate) {
-			h.respondError(w, r, http.StatusConflict, domain.ErrNoCandidate, "no active replacement candidate in team")
+		if errors.Is(err, domain.ErrNoCandidate) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodeNoCandidate, "no active replacement candidate in team")
 			return
 		}
-		if contains(err.Error(), domain.ErrNotFound) {
-			h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "PR or user not found")
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR or user not found")
 			return
 		}
 
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -402,14 +473,14 @@ func (h *Handler) GetPRsByReviewer(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "user_id query parameter required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id query parameter required")
 		return
 	}
 
 	prs, err := h.service.GetPRsByReviewer(ctx, userID)
 	if err != nil {
 		log.Error(ctx, "failed to get PRs by reviewer", zap.Error(err))
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -427,14 +498,14 @@ func (h *Handler) GetPRsByAuthor(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "user_id query parameter required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id query parameter required")
 		return
 	}
 
 	prs, err := h.service.GetPRsByAuthor(ctx, userID)
 	if err != nil {
 		log.Error(ctx, "failed to get PRs by author", zap.Error(err))
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 
@@ -456,18 +527,20 @@ func (h *Handler) respondJSON(w http.ResponseWriter, r *http.Request, status int
 	}
 }
 
+// respondError sends an application/problem+json error, or the legacy ErrorResponse
+// to clients that accept only application/json.
 func (h *Handler) respondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
-	errorResponse := domain.ErrorResponse{
+	legacy := domain.ErrorResponse{
 		Error: domain.ErrorDetail{
 			Code:    code,
 			Message: message,
 		},
 	}
-	h.respondJSON(w, r, status, errorResponse)
-}
-
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && s[:len(substr)] == substr
+	if err := problem.Write(w, r, status, code, message, legacy); err != nil {
+		ctx := r.Context()
+		log := logger.FromContext(ctx)
+		log.Error(ctx, "failed to encode error response", zap.Error(err))
+	}
 }
 
 // GetStatistics GET /statistics
@@ -477,34 +550,79 @@ func (h *Handler) GetStatistics(w http.ResponseWriter, r *http.Request) {
 	stats, err := h.service.GetStatistics(ctx)
 	if err != nil {
 		log.Error(ctx, "failed to get statistics", zap.Error(err))
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 	h.respondJSON(w, r, http.StatusOK, stats)
 }
 
-// BulkDeactivateTeamUsers POST /team/deactivateUsers
+// BulkDeactivateTeamUsers POST /team/deactivateUsers (supports dry_run and plan_token).
 func (h *Handler) BulkDeactivateTeamUsers(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	log := logger.FromContext(ctx)
 	var req domain.BulkDeactivateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 	if req.TeamName == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+	if req.DryRun && req.PlanToken != "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "dry_run and plan_token are mutually exclusive")
 		return
 	}
 	response, err := h.service.BulkDeactivateTeamUsers(ctx, &req)
 	if err != nil {
 		log.Error(ctx, "failed to bulk deactivate team users", zap.Error(err))
-		if contains(err.Error(), domain.ErrNotFound) {
-			h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "team not found")
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "malformed plan_token")
+			return
+		}
+		if errors.Is(err, domain.ErrPlanStale) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePlanStale, "team or its pull requests changed since the plan was made")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, response)
+}
+
+// BulkActivateTeamUsers POST /team/activateUsers
+func (h *Handler) BulkActivateTeamUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	var req domain.BulkActivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+	response, err := h.service.BulkActivateTeamUsers(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to bulk activate team users", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
 			return
 		}
-		h.respondError(w, r, http.StatusInternalServerError, domain.ErrNotFound, err.Error())
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team or user not found")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
 		return
 	}
 	h.respondJSON(w, r, http.StatusOK, response)
@@ -517,14 +635,14 @@ func (h *Handler) ResolveTeamID(w http.ResponseWriter, r *http.Request) {
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "team_name query parameter required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter required")
 		return
 	}
 
 	teamID, err := h.service.GetTeamIDByName(ctx, teamName)
 	if err != nil {
 		log.Error(ctx, "failed to resolve team", zap.Error(err))
-		h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "team not found")
+		h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
 		return
 	}
 
@@ -541,16 +659,490 @@ func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
 
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		h.respondError(w, r, http.StatusBadRequest, domain.ErrInvalidRequest, "user_id query parameter required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id query parameter required")
 		return
 	}
 
 	user, err := h.service.GetUser(ctx, userID)
 	if err != nil {
 		log.Error(ctx, "failed to get user", zap.Error(err))
-		h.respondError(w, r, http.StatusNotFound, domain.ErrNotFound, "user not found")
+		h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "user not found")
 		return
 	}
 
 	h.respondJSON(w, r, http.StatusOK, map[string]any{"user": user})
 }
+
+// GetPRHistory GET /pullRequest/history?pull_request_id=...
+func (h *Handler) GetPRHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id query parameter required")
+		return
+	}
+
+	events, err := h.service.GetPRHistory(ctx, prID)
+	if err != nil {
+		log.Error(ctx, "failed to get PR history", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{
+		"pull_request_id": prID,
+		"events":          events,
+	})
+}
+
+// ReplayAssignment GET /pullRequest/replay?pull_request_id=...&event_id=...
+func (h *Handler) ReplayAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id query parameter required")
+		return
+	}
+	var eventID int64
+	if raw := r.URL.Query().Get("event_id"); raw != "" {
+		var err error
+		if eventID, err = strconv.ParseInt(raw, 10, 64); err != nil || eventID <= 0 {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "event_id must be a positive integer")
+			return
+		}
+	}
+
+	response, err := h.service.ReplayAssignment(ctx, prID, eventID)
+	if err != nil {
+		log.Error(ctx, "failed to replay assignment", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response)
+}
+
+// ExplainAssignment GET /pullRequest/explain?pull_request_id=...
+func (h *Handler) ExplainAssignment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id query parameter required")
+		return
+	}
+
+	response, err := h.service.ExplainAssignment(ctx, prID)
+	if err != nil {
+		log.Error(ctx, "failed to explain assignment", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response)
+}
+
+// GetTeamPolicy GET /team/getPolicy?team_name=...
+func (h *Handler) GetTeamPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter required")
+		return
+	}
+
+	policy, err := h.service.GetTeamPolicy(ctx, teamName)
+	if err != nil {
+		log.Error(ctx, "failed to get team policy", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{"policy": policy})
+}
+
+// SetTeamPolicy POST /team/setPolicy.
+func (h *Handler) SetTeamPolicy(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.SetTeamPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+
+	policy, err := h.service.SetTeamPolicy(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set team policy", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{"policy": policy})
+}
+
+// AddPRLabels POST /pullRequest/addLabels.
+func (h *Handler) AddPRLabels(w http.ResponseWriter, r *http.Request) {
+	h.changePRLabels(w, r, h.service.AddPRLabels)
+}
+
+// RemovePRLabels POST /pullRequest/removeLabels.
+func (h *Handler) RemovePRLabels(w http.ResponseWriter, r *http.Request) {
+	h.changePRLabels(w, r, h.service.RemovePRLabels)
+}
+
+// SetPRLabels POST /pullRequest/setLabels.
+func (h *Handler) SetPRLabels(w http.ResponseWriter, r *http.Request) {
+	h.changePRLabels(w, r, h.service.SetPRLabels)
+}
+
+func (h *Handler) changePRLabels(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, req *domain.PRLabelsRequest) (*domain.PullRequest, error),
+) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.PRLabelsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PullRequestID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id is required")
+		return
+	}
+
+	pr, err := change(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to change PR labels", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]*domain.PullRequest{"pr": pr})
+}
+
+// GetRoutingRules GET /team/getRoutingRules?team_name=...
+func (h *Handler) GetRoutingRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter required")
+		return
+	}
+
+	rules, err := h.service.GetRoutingRules(ctx, teamName)
+	if err != nil {
+		log.Error(ctx, "failed to get routing rules", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{
+		"team_name": teamName,
+		"rules":     rules,
+	})
+}
+
+// SetRoutingRules POST /team/setRoutingRules.
+func (h *Handler) SetRoutingRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.SetRoutingRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+
+	rules, err := h.service.SetRoutingRules(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set routing rules", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{
+		"team_name": req.TeamName,
+		"rules":     rules,
+	})
+}
+
+// AddUserSkills POST /users/addSkills.
+func (h *Handler) AddUserSkills(w http.ResponseWriter, r *http.Request) {
+	h.changeUserSkills(w, r, h.service.AddUserSkills)
+}
+
+// RemoveUserSkills POST /users/removeSkills.
+func (h *Handler) RemoveUserSkills(w http.ResponseWriter, r *http.Request) {
+	h.changeUserSkills(w, r, h.service.RemoveUserSkills)
+}
+
+// SetUserSkills POST /users/setSkills.
+func (h *Handler) SetUserSkills(w http.ResponseWriter, r *http.Request) {
+	h.changeUserSkills(w, r, h.service.SetUserSkills)
+}
+
+func (h *Handler) changeUserSkills(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, req *domain.UserSkillsRequest) (*domain.User, error),
+) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.UserSkillsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.UserID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id is required")
+		return
+	}
+
+	user, err := change(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to change user skills", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "user not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{"user": user})
+}
+
+// SetPRRequiredSkills POST /pullRequest/setRequiredSkills.
+func (h *Handler) SetPRRequiredSkills(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.PRSkillsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PullRequestID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id is required")
+		return
+	}
+
+	pr, err := h.service.SetPRRequiredSkills(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set PR required skills", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]*domain.PullRequest{"pr": pr})
+}
+
+// SetPRPriority POST /pullRequest/setPriority.
+func (h *Handler) SetPRPriority(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.PRPriorityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PullRequestID == "" || req.Priority == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id and priority are required")
+		return
+	}
+
+	pr, err := h.service.SetPRPriority(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set PR priority", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]*domain.PullRequest{"pr": pr})
+}
+
+// SearchPRs GET /pullRequest/search?q=...&team_name=...&status=...&author_id=...&reviewer_id=...&limit=...&offset=...
+func (h *Handler) SearchPRs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	params := r.URL.Query()
+	q := domain.PRSearchQuery{
+		Query:      params.Get("q"),
+		TeamName:   params.Get("team_name"),
+		Status:     domain.PRStatus(params.Get("status")),
+		AuthorID:   params.Get("author_id"),
+		ReviewerID: params.Get("reviewer_id"),
+	}
+	var err error
+	if raw := params.Get("limit"); raw != "" {
+		if q.Limit, err = strconv.Atoi(raw); err != nil {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "limit must be an integer")
+			return
+		}
+	}
+	if raw := params.Get("offset"); raw != "" {
+		if q.Offset, err = strconv.Atoi(raw); err != nil {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "offset must be an integer")
+			return
+		}
+	}
+
+	response, err := h.service.SearchPRs(ctx, &q)
+	if err != nil {
+		log.Error(ctx, "failed to search PRs", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, response)
+}
+
+// AnonymizeUser POST /users/anonymize.
+func (h *Handler) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.AnonymizeUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.UserID == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "user_id is required")
+		return
+	}
+
+	report, err := h.service.AnonymizeUser(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to anonymize user", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "user not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{"report": report})
+}
@@ -0,0 +1,74 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/pr-allocation-service/internal/service"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// OrganizationMiddleware scopes the request to the organization named in the X-Organization header,
+// or to the default organization when the header is absent. Unknown organizations get 404.
+func (h *Handler) OrganizationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		orgName := r.Header.Get(domain.OrganizationHeader)
+		if orgName == "" {
+			orgName = domain.DefaultOrganization
+		}
+		org, err := h.service.GetOrganization(ctx, orgName)
+		if err != nil {
+			logger.FromContext(ctx).Warn(ctx, "unknown organization", zap.String("org_name", orgName))
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "organization not found")
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(service.WithOrganization(ctx, org)))
+	})
+}
+
+// CreateOrganization POST /org/add.
+func (h *Handler) CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	var req domain.CreateOrganizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.OrgName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "org_name is required")
+		return
+	}
+
+	org, err := h.service.CreateOrganization(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to create organization", zap.Error(err))
+		if errors.Is(err, domain.ErrOrgExists) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodeOrgExists, "org_name already exists")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusCreated, map[string]*domain.Organization{"organization": org})
+}
+
+// ListOrganizations GET /org/list.
+func (h *Handler) ListOrganizations(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	orgs, err := h.service.ListOrganizations(ctx)
+	if err != nil {
+		log.Error(ctx, "failed to list organizations", zap.Error(err))
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string][]*domain.Organization{"organizations": orgs})
+}
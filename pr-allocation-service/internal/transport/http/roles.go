@@ -0,0 +1,73 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/pr-allocation-service/internal/service"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// ActorMiddleware records the user named in the X-Actor-ID header; role checks are made against them.
+func (h *Handler) ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if userID := r.Header.Get(domain.ActorHeader); userID != "" {
+			r = r.WithContext(service.WithActor(r.Context(), userID))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// respondAuthError writes 401 for a missing actor and 403 for an insufficient role.
+// It reports whether err was one of them.
+func (h *Handler) respondAuthError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case errors.Is(err, domain.ErrUnauthorized):
+		h.respondError(w, r, http.StatusUnauthorized, domain.ErrCodeUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrForbidden):
+		h.respondError(w, r, http.StatusForbidden, domain.ErrCodeForbidden, err.Error())
+	default:
+		return false
+	}
+	return true
+}
+
+// SetMemberRole POST /team/setMemberRole.
+func (h *Handler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	var req domain.SetMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.TeamName == "" || req.UserID == "" || req.Role == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name, user_id and role are required")
+		return
+	}
+
+	user, err := h.service.SetMemberRole(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set member role", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]*domain.User{"user": user})
+}
@@ -0,0 +1,93 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// GetRotation GET /team/getRotation?team_name=...
+func (h *Handler) GetRotation(w http.ResponseWriter, r *http.Request) {
+	h.getTeamRotation(w, r, "rotation", func(ctx context.Context, teamName string) (any, error) {
+		return h.service.GetRotation(ctx, teamName)
+	})
+}
+
+// GetOnCall GET /team/onCall?team_name=...
+func (h *Handler) GetOnCall(w http.ResponseWriter, r *http.Request) {
+	h.getTeamRotation(w, r, "on_call", func(ctx context.Context, teamName string) (any, error) {
+		return h.service.GetOnCall(ctx, teamName)
+	})
+}
+
+func (h *Handler) getTeamRotation(
+	w http.ResponseWriter,
+	r *http.Request,
+	key string,
+	get func(ctx context.Context, teamName string) (any, error),
+) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter required")
+		return
+	}
+
+	result, err := get(ctx, teamName)
+	if err != nil {
+		log.Error(ctx, "failed to get rotation", zap.Error(err))
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{key: result})
+}
+
+// SetRotation POST /team/setRotation.
+func (h *Handler) SetRotation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.SetRotationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.TeamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+
+	rotation, err := h.service.SetRotation(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to set rotation", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "team not found")
+			return
+		}
+		if errors.Is(err, domain.ErrInvalidRequest) {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, err.Error())
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	h.respondJSON(w, r, http.StatusOK, map[string]any{"rotation": rotation})
+}
@@ -0,0 +1,55 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Meldy183/pr-allocation-service/internal/domain"
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// UpdatePRSource POST /pullRequest/updateSource.
+func (h *Handler) UpdatePRSource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+
+	var req domain.UpdatePRSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+	if req.PullRequestID == "" || req.SourceCommit == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pull_request_id and source_commit are required")
+		return
+	}
+
+	pr, dismissed, err := h.service.UpdatePRSource(ctx, &req)
+	if err != nil {
+		log.Error(ctx, "failed to update PR source", zap.Error(err))
+		if h.respondAuthError(w, r, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrNotFound) {
+			h.respondError(w, r, http.StatusNotFound, domain.ErrCodeNotFound, "PR not found")
+			return
+		}
+		if errors.Is(err, domain.ErrPRNotOpen) {
+			h.respondError(w, r, http.StatusConflict, domain.ErrCodePRNotOpen, "PR is not open")
+			return
+		}
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternal, err.Error())
+		return
+	}
+
+	if dismissed == nil {
+		dismissed = []string{}
+	}
+	h.respondJSON(w, r, http.StatusOK, map[string]any{
+		"pr":                  pr,
+		"dismissed_approvals": dismissed,
+	})
+}
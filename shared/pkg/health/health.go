@@ -0,0 +1,175 @@
+// Package health serves liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Meldy183/shared/pkg/logger"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Status of a probe or a single dependency.
+type Status string
+
+const (
+	StatusOK          Status = "ok"
+	StatusUnavailable Status = "unavailable"
+)
+
+// DefaultTimeout bounds a dependency check that sets no timeout of its own.
+const DefaultTimeout = 2 * time.Second
+
+// Check probes one dependency. Run returns details to include in the report; an error marks the
+// dependency, and with it the service, unavailable.
+type Check struct {
+	Name    string
+	Timeout time.Duration
+	Run     func(ctx context.Context) (map[string]any, error)
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Status    Status         `json:"status"`
+	LatencyMS int64          `json:"latency_ms"`
+	Error     string         `json:"error,omitempty"`
+	Details   map[string]any `json:"details,omitempty"`
+}
+
+// Report is the body of /readyz: the service is ok only if every check is.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Run executes the checks concurrently, each under its own timeout.
+func Run(ctx context.Context, checks []Check) Report {
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runCheck(ctx, check)
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[check.Name] = result
+			if result.Status != StatusOK {
+				report.Status = StatusUnavailable
+			}
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) Result {
+	timeout := check.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := check.Run(ctx)
+	result := Result{Status: StatusOK, LatencyMS: time.Since(start).Milliseconds(), Details: details}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Register adds GET /livez, which only says the process serves HTTP, and GET /readyz, which runs
+// the dependency checks and answers 503 when any of them fails.
+func Register(router *mux.Router, checks ...Check) {
+	router.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		respond(w, r, http.StatusOK, map[string]Status{"status": StatusOK})
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		report := Run(ctx, checks)
+		status := http.StatusOK
+		if report.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+			logger.FromContext(ctx).Warn(ctx, "readiness check failed", zap.Any("checks", report.Checks))
+		}
+		respond(w, r, status, report)
+	}).Methods(http.MethodGet)
+}
+
+func respond(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		ctx := r.Context()
+		logger.FromContext(ctx).Error(ctx, "failed to encode probe response", zap.Error(err))
+	}
+}
+
+// Database checks connectivity with a ping and reports connection pool usage.
+// A pool that has every connection in use with callers waiting is reported as exhausted.
+func Database(name string, db *sql.DB) Check {
+	return Check{
+		Name: name,
+		Run: func(ctx context.Context) (map[string]any, error) {
+			stats := db.Stats()
+			details := map[string]any{
+				"open_connections": stats.OpenConnections,
+				"in_use":           stats.InUse,
+				"idle":             stats.Idle,
+				"max_open":         stats.MaxOpenConnections,
+				"wait_count":       stats.WaitCount,
+				"wait_duration_ms": stats.WaitDuration.Milliseconds(),
+			}
+			if err := db.PingContext(ctx); err != nil {
+				return details, fmt.Errorf("ping failed: %w", err)
+			}
+			if stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections && stats.WaitCount > 0 {
+				return details, fmt.Errorf("connection pool exhausted: %d of %d connections in use",
+					stats.InUse, stats.MaxOpenConnections)
+			}
+			return details, nil
+		},
+	}
+}
+
+// Downstream checks another service's /readyz and includes its report.
+func Downstream(name, baseURL string, client *http.Client) Check {
+	return Check{
+		Name:    name,
+		Timeout: 2 * DefaultTimeout, // room for the downstream's own checks
+		Run: func(ctx context.Context) (map[string]any, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/readyz", nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create request: %w", err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("unreachable: %w", err)
+			}
+			defer resp.Body.Close()
+
+			var report Report
+			if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+				return nil, fmt.Errorf("failed to decode readiness report (status %d): %w", resp.StatusCode, err)
+			}
+			details := map[string]any{"checks": report.Checks}
+			if resp.StatusCode != http.StatusOK || report.Status != StatusOK {
+				return details, fmt.Errorf("not ready (status %d)", resp.StatusCode)
+			}
+			return details, nil
+		},
+	}
+}
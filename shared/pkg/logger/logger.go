@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -44,6 +45,7 @@ func (l *L) Info(ctx context.Context, mgs string, fields ...zap.Field) {
 		id = uuid.NewString()
 	}
 	fields = append(fields, zap.String(loggerRequestIDKey, id))
+	fields = appendTraceFields(ctx, fields)
 	l.z.Info(mgs, fields...)
 }
 func (l *L) Debug(ctx context.Context, mgs string, fields ...zap.Field) {
@@ -52,6 +54,7 @@ func (l *L) Debug(ctx context.Context, mgs string, fields ...zap.Field) {
 		id = uuid.NewString()
 	}
 	fields = append(fields, zap.String(loggerRequestIDKey, id))
+	fields = appendTraceFields(ctx, fields)
 	l.z.Debug(mgs, fields...)
 }
 func (l *L) Warn(ctx context.Context, mgs string, fields ...zap.Field) {
@@ -60,6 +63,7 @@ func (l *L) Warn(ctx context.Context, mgs string, fields ...zap.Field) {
 		id = uuid.NewString()
 	}
 	fields = append(fields, zap.String(loggerRequestIDKey, id))
+	fields = appendTraceFields(ctx, fields)
 	l.z.Warn(mgs, fields...)
 }
 func (l *L) Error(ctx context.Context, mgs string, fields ...zap.Field) {
@@ -68,6 +72,7 @@ func (l *L) Error(ctx context.Context, mgs string, fields ...zap.Field) {
 		id = uuid.NewString()
 	}
 	fields = append(fields, zap.String(loggerRequestIDKey, id))
+	fields = appendTraceFields(ctx, fields)
 	l.z.Error(mgs, fields...)
 }
 
@@ -77,12 +82,31 @@ func (l *L) Fatal(ctx context.Context, mgs string, fields ...zap.Field) {
 		id = uuid.NewString()
 	}
 	fields = append(fields, zap.String(loggerRequestIDKey, id))
+	fields = appendTraceFields(ctx, fields)
 	l.z.Fatal(mgs, fields...)
 }
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, loggerRequestIDKey, requestID)
 }
 
+// RequestIDFromContext returns the request ID set by WithRequestID.
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(loggerRequestIDKey).(string)
+	return id, ok
+}
+
+// appendTraceFields adds the trace and span IDs of the span in ctx, if any.
+func appendTraceFields(ctx context.Context, fields []zap.Field) []zap.Field {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if !spanCtx.IsValid() {
+		return fields
+	}
+	return append(fields,
+		zap.String("trace_id", spanCtx.TraceID().String()),
+		zap.String("span_id", spanCtx.SpanID().String()),
+	)
+}
+
 func WithLogger(ctx context.Context, logger Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
 }
@@ -0,0 +1,247 @@
+// Package openapi validates HTTP traffic against a service's embedded OpenAPI spec.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/problem"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Error codes of validation failures.
+const (
+	ErrCodeInvalidRequest  = "INVALID_REQUEST"
+	ErrCodeInvalidResponse = "INVALID_RESPONSE"
+)
+
+// LegacyFunc builds the service's legacy error body for clients that do not accept problem+json.
+type LegacyFunc func(code, message string) any
+
+// Validator checks requests (and, when enabled, responses) against the spec.
+type Validator struct {
+	doc              *openapi3.T
+	router           routers.Router
+	legacy           LegacyFunc
+	validateResponse bool
+}
+
+// NewValidator loads and validates the spec. Servers are ignored: routes match on path only,
+// since every service is reached under several hosts.
+// validateResponse buffers JSON responses and replaces invalid ones with a 500; use it in dev only.
+func NewValidator(spec []byte, legacy LegacyFunc, validateResponse bool) (*Validator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	doc.Servers = nil
+	for _, pathItem := range doc.Paths.Map() {
+		pathItem.Servers = nil
+	}
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+	return &Validator{doc: doc, router: router, legacy: legacy, validateResponse: validateResponse}, nil
+}
+
+// CheckRoutes reports routes registered on the router but missing from the spec and vice versa.
+func (v *Validator) CheckRoutes(router *mux.Router) error {
+	registered := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil // subrouters and matchers without a path
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			registered[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to walk routes: %w", err)
+	}
+	documented := make(map[string]bool)
+	for path, pathItem := range v.doc.Paths.Map() {
+		for method := range pathItem.Operations() {
+			documented[method+" "+path] = true
+		}
+	}
+
+	var drift []string
+	for route := range registered {
+		if !documented[route] {
+			drift = append(drift, "not in spec: "+route)
+		}
+	}
+	for route := range documented {
+		if !registered[route] {
+			drift = append(drift, "not registered: "+route)
+		}
+	}
+	if len(drift) > 0 {
+		slices.Sort(drift)
+		return fmt.Errorf("routes drift from the OpenAPI spec: %s", strings.Join(drift, "; "))
+	}
+	return nil
+}
+
+// Middleware validates each request whose route is in the spec, answering 400 with the list of
+// invalid params on failure. Routes not in the spec pass through untouched.
+func (v *Validator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		route, pathParams, err := v.router.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				MultiError:         true,
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+				// File uploads are not buffered a second time just to be validated
+				ExcludeRequestBody: strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/"),
+			},
+		}
+		if err := openapi3filter.ValidateRequest(ctx, input); err != nil {
+			logger.FromContext(ctx).Warn(ctx, "request failed OpenAPI validation", zap.Error(err))
+			v.respond(w, r, http.StatusBadRequest, ErrCodeInvalidRequest, "request does not match the API spec", err)
+			return
+		}
+		if !v.validateResponse {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(recorder, r)
+		if !recorder.buffering {
+			return
+		}
+		responseInput := &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 recorder.status,
+			Header:                 recorder.Header(),
+			Options:                &openapi3filter.Options{MultiError: true},
+		}
+		responseInput.SetBodyBytes(recorder.body.Bytes())
+		if err := openapi3filter.ValidateResponse(ctx, responseInput); err != nil {
+			logger.FromContext(ctx).Error(ctx, "response failed OpenAPI validation", zap.Error(err),
+				zap.String("path", r.URL.Path), zap.Int("status", recorder.status))
+			v.respond(w, r, http.StatusInternalServerError, ErrCodeInvalidResponse, "response does not match the API spec", err)
+			return
+		}
+		w.WriteHeader(recorder.status)
+		_, _ = w.Write(recorder.body.Bytes())
+	})
+}
+
+func (v *Validator) respond(w http.ResponseWriter, r *http.Request, status int, code, detail string, err error) {
+	details := problem.New(r, status, code, detail)
+	details.InvalidParams = invalidParams("", err)
+	reasons := make([]string, len(details.InvalidParams))
+	for i, param := range details.InvalidParams {
+		reasons[i] = param.Name + ": " + param.Reason
+	}
+	_ = problem.WriteDetails(w, r, details, v.legacy(code, detail+": "+strings.Join(reasons, "; ")))
+}
+
+// invalidParams flattens kin-openapi validation errors into named params. It matches concrete
+// types rather than errors.As, since both RequestError and MultiError unwrap into each other.
+func invalidParams(name string, err error) []problem.InvalidParam {
+	switch e := err.(type) {
+	case openapi3.MultiError:
+		var params []problem.InvalidParam
+		for _, inner := range e {
+			params = append(params, invalidParams(name, inner)...)
+		}
+		return params
+	case *openapi3filter.RequestError:
+		switch {
+		case e.Parameter != nil:
+			name = e.Parameter.In + "." + e.Parameter.Name
+		case e.RequestBody != nil:
+			name = "body"
+		}
+		if e.Err != nil {
+			return invalidParams(name, e.Err)
+		}
+		return []problem.InvalidParam{{Name: name, Reason: e.Reason}}
+	case *openapi3filter.ResponseError:
+		if e.Err != nil {
+			return invalidParams("response", e.Err)
+		}
+		return []problem.InvalidParam{{Name: "response", Reason: e.Reason}}
+	case *openapi3.SchemaError:
+		if pointer := e.JSONPointer(); len(pointer) > 0 {
+			name += "/" + strings.Join(pointer, "/")
+		}
+		return []problem.InvalidParam{{Name: name, Reason: e.Reason}}
+	}
+	return []problem.InvalidParam{{Name: name, Reason: err.Error()}}
+}
+
+// responseRecorder buffers JSON responses for validation; anything else (downloads, streams,
+// problem documents) is passed straight through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	buffering bool
+	body      bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status != 0 {
+		return
+	}
+	rec.status = status
+	contentType := rec.Header().Get("Content-Type")
+	rec.buffering = strings.HasPrefix(contentType, "application/json")
+	if !rec.buffering {
+		rec.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.status == 0 {
+		rec.WriteHeader(http.StatusOK)
+	}
+	if rec.buffering {
+		return rec.body.Write(data)
+	}
+	return rec.ResponseWriter.Write(data)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Flush lets streaming handlers flush through the recorder.
+func (rec *responseRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok && !rec.buffering {
+		flusher.Flush()
+	}
+}
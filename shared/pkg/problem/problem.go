@@ -0,0 +1,83 @@
+// Package problem writes RFC 7807 (application/problem+json) error responses.
+package problem
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ContentType is the media type of a problem document.
+	ContentType = "application/problem+json"
+	// TypePrefix prefixes the error code to form the problem type URI.
+	TypePrefix = "urn:problem-type:"
+)
+
+// Details is a problem document; Code is the service's stable error code (also encoded in Type).
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	// InvalidParams lists what failed request or response validation.
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam names one invalid input ("query.team_name", "body/members/0/username") and why.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// New builds a problem document for the request.
+func New(r *http.Request, status int, code, detail string) Details {
+	return Details{
+		Type:     TypePrefix + strings.ToLower(strings.ReplaceAll(code, "_", "-")),
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+		Code:     code,
+	}
+}
+
+// WantsLegacy reports whether the client asked for plain application/json without accepting
+// application/problem+json, so it gets the service's legacy error shape instead.
+// A missing Accept header or */* gets a problem document.
+func WantsLegacy(r *http.Request) bool {
+	legacy := false
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case ContentType:
+			return false
+		case "application/json":
+			legacy = true
+		}
+	}
+	return legacy
+}
+
+// Write sends a problem document, or legacy as application/json when the client negotiated it.
+func Write(w http.ResponseWriter, r *http.Request, status int, code, detail string, legacy any) error {
+	return WriteDetails(w, r, New(r, status, code, detail), legacy)
+}
+
+// WriteDetails is Write for a prepared problem document.
+func WriteDetails(w http.ResponseWriter, r *http.Request, details Details, legacy any) error {
+	if WantsLegacy(r) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(details.Status)
+		return json.NewEncoder(w).Encode(legacy)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(details.Status)
+	return json.NewEncoder(w).Encode(details)
+}
@@ -9,9 +9,14 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/Meldy183/shared/pkg/health"
 	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/openapi"
+	"github.com/Meldy183/shared/pkg/tracing"
+	"github.com/Meldy183/user-gateway-service/api"
 	"github.com/Meldy183/user-gateway-service/internal/client"
 	"github.com/Meldy183/user-gateway-service/internal/config"
+	"github.com/Meldy183/user-gateway-service/internal/domain"
 	"github.com/Meldy183/user-gateway-service/internal/service"
 	"github.com/Meldy183/user-gateway-service/internal/transport"
 	"github.com/google/uuid"
@@ -44,6 +49,16 @@ func main() {
 		zap.String("env", env),
 	)
 
+	shutdownTracing, err := tracing.Init(ctx, "user-gateway-service", cfg.Tracing)
+	if err != nil {
+		log.Fatal(ctx, "failed to init tracing", zap.Error(err))
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error(ctx, "failed to shutdown tracing", zap.Error(err))
+		}
+	}()
+
 	// Initialize clients
 	prClient := client.NewPRAllocationClient(cfg.GetPRAllocationURL())
 	codeClient := client.NewCodeStorageClient(cfg.GetCodeStorageURL())
@@ -58,14 +73,31 @@ func main() {
 	handler := transport.NewHandler(svc)
 	router := mux.NewRouter()
 	handler.RegisterRoutes(router, log)
+	health.Register(router,
+		health.Downstream("pr-allocation-service", cfg.GetPRAllocationURL(), http.DefaultClient),
+		health.Downstream("code-storage-service", cfg.GetCodeStorageURL(), http.DefaultClient),
+	)
+
+	// Validate traffic against the embedded OpenAPI spec; responses too outside prod
+	validator, err := openapi.NewValidator(api.Spec, func(code, message string) any {
+		return domain.NewErrorResponse(code, message)
+	}, env == "dev" || env == "development")
+	if err != nil {
+		log.Fatal(ctx, "failed to load OpenAPI spec", zap.Error(err))
+	}
+	if err := validator.CheckRoutes(router); err != nil {
+		log.Fatal(ctx, "registered routes do not match the OpenAPI spec", zap.Error(err))
+	}
+	router.Use(validator.Middleware)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
-		Handler:      router,
+		Handler:      tracing.Handler(router, "user-gateway-service"),
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 60 * time.Second, // Longer for file uploads
 		IdleTimeout:  60 * time.Second,
 	}
+	server.RegisterOnShutdown(handler.CloseStreams)
 
 	// Channel to listen for errors from the server
 	serverErrors := make(chan error, 1)
@@ -6,10 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strconv"
 	"time"
 
+	"github.com/Meldy183/shared/pkg/tracing"
 	"github.com/google/uuid"
 )
 
@@ -17,6 +21,8 @@ import (
 type PRAllocationClient struct {
 	baseURL    string
 	httpClient *http.Client
+	// streamClient has no overall timeout; event streams stay open until either side closes them
+	streamClient *http.Client
 }
 
 // NewPRAllocationClient creates a new PR allocation client
@@ -24,7 +30,11 @@ func NewPRAllocationClient(baseURL string) *PRAllocationClient {
 	return &PRAllocationClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: scopeTransport{base: tracing.Transport(nil)},
+		},
+		streamClient: &http.Client{
+			Transport: scopeTransport{base: tracing.Transport(nil)},
 		},
 	}
 }
@@ -34,6 +44,7 @@ type TeamMember struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role,omitempty"`
 }
 
 // Team represents a team
@@ -49,6 +60,7 @@ type User struct {
 	TeamID   uuid.UUID `json:"team_id"`
 	TeamName string    `json:"team_name"`
 	IsActive bool      `json:"is_active"`
+	Role     string    `json:"role"`
 }
 
 // PRResponse represents PR response from pr-allocation-service
@@ -58,6 +70,8 @@ type PRResponse struct {
 	AuthorID          string     `json:"author_id"`
 	Status            string     `json:"status"`
 	AssignedReviewers []string   `json:"assigned_reviewers"`
+	ApprovedBy        []string   `json:"approved_by"`
+	DependsOn         []string   `json:"depends_on"`
 	CreatedAt         time.Time  `json:"createdAt"`
 	MergedAt          *time.Time `json:"mergedAt"`
 }
@@ -95,12 +109,8 @@ func (c *PRAllocationClient) CreateTeam(ctx context.Context, teamName string, me
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusBadRequest {
-		return nil, fmt.Errorf("team already exists")
-	}
 	if resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -128,11 +138,8 @@ func (c *PRAllocationClient) GetTeam(ctx context.Context, teamName string) (*Tea
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("team not found")
-	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, decodeError(resp)
 	}
 
 	var team TeamResponse
@@ -158,11 +165,8 @@ func (c *PRAllocationClient) ResolveTeamID(ctx context.Context, teamName string)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return uuid.Nil, fmt.Errorf("team not found")
-	}
 	if resp.StatusCode != http.StatusOK {
-		return uuid.Nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return uuid.Nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -191,11 +195,49 @@ func (c *PRAllocationClient) GetUser(ctx context.Context, userID string) (*User,
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("user not found")
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
 	}
+
+	var result struct {
+		User User `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result.User, nil
+}
+
+// SetMemberRole changes a team member's role; the actor in ctx must be a team admin
+func (c *PRAllocationClient) SetMemberRole(ctx context.Context, teamName, userID, role string) (*User, error) {
+	url := fmt.Sprintf("%s/team/setMemberRole", c.baseURL)
+
+	body := map[string]string{
+		"team_name": teamName,
+		"user_id":   userID,
+		"role":      role,
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -266,12 +308,8 @@ func (c *PRAllocationClient) CreatePR(ctx context.Context, prID, prName, authorI
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusConflict {
-		return nil, fmt.Errorf("PR already exists")
-	}
 	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -286,10 +324,21 @@ func (c *PRAllocationClient) CreatePR(ctx context.Context, prID, prName, authorI
 
 // MergePR marks PR as merged
 func (c *PRAllocationClient) MergePR(ctx context.Context, prID string) (*PRResponse, error) {
+	return c.mergePR(ctx, prID, false)
+}
+
+// CheckMergePR runs the merge checks (role, approvals, dependencies) without marking the PR as merged
+// and returns the PR as it is
+func (c *PRAllocationClient) CheckMergePR(ctx context.Context, prID string) (*PRResponse, error) {
+	return c.mergePR(ctx, prID, true)
+}
+
+func (c *PRAllocationClient) mergePR(ctx context.Context, prID string, dryRun bool) (*PRResponse, error) {
 	url := fmt.Sprintf("%s/pullRequest/merge", c.baseURL)
 
-	body := map[string]string{
+	body := map[string]any{
 		"pull_request_id": prID,
+		"dry_run":         dryRun,
 	}
 
 	jsonBody, err := json.Marshal(body)
@@ -310,8 +359,36 @@ func (c *PRAllocationClient) MergePR(ctx context.Context, prID string) (*PRRespo
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, decodeError(resp)
+	}
+
+	var result struct {
+		PR PRResponse `json:"pr"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result.PR, nil
+}
+
+// GetPR gets a PR by ID
+func (c *PRAllocationClient) GetPR(ctx context.Context, prID string) (*PRResponse, error) {
+	url := fmt.Sprintf("%s/pullRequest/get?pull_request_id=%s", c.baseURL, prID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -351,8 +428,7 @@ func (c *PRAllocationClient) ApprovePR(ctx context.Context, prID, reviewerID str
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, false, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, false, decodeError(resp)
 	}
 
 	var result struct {
@@ -394,8 +470,7 @@ func (c *PRAllocationClient) RejectPR(ctx context.Context, prID, reviewerID, rea
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -408,6 +483,47 @@ func (c *PRAllocationClient) RejectPR(ctx context.Context, prID, reviewerID, rea
 	return &result.PR, nil
 }
 
+// UpdatePRSource records a new source commit of an open PR; it returns the reviewers whose approvals were dismissed
+func (c *PRAllocationClient) UpdatePRSource(ctx context.Context, prID, sourceCommit string) (*PRResponse, []string, error) {
+	url := fmt.Sprintf("%s/pullRequest/updateSource", c.baseURL)
+
+	body := map[string]string{
+		"pull_request_id": prID,
+		"source_commit":   sourceCommit,
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, decodeError(resp)
+	}
+
+	var result struct {
+		PR                 PRResponse `json:"pr"`
+		DismissedApprovals []string   `json:"dismissed_approvals"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result.PR, result.DismissedApprovals, nil
+}
+
 // GetPRsByAuthor gets PRs by author
 func (c *PRAllocationClient) GetPRsByAuthor(ctx context.Context, authorID string) ([]PRResponse, error) {
 	url := fmt.Sprintf("%s/users/getAuthored?user_id=%s", c.baseURL, authorID)
@@ -427,7 +543,7 @@ func (c *PRAllocationClient) GetPRsByAuthor(ctx context.Context, authorID string
 		return []PRResponse{}, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -459,7 +575,7 @@ func (c *PRAllocationClient) GetPRsByReviewer(ctx context.Context, reviewerID st
 		return []PRResponse{}, nil
 	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -472,6 +588,101 @@ func (c *PRAllocationClient) GetPRsByReviewer(ctx context.Context, reviewerID st
 	return result.PRs, nil
 }
 
+// PRSearchParams are the filters of a PR search; empty fields are not sent
+type PRSearchParams struct {
+	Query      string
+	TeamName   string
+	Status     string
+	AuthorID   string
+	ReviewerID string
+	Limit      int
+	Offset     int
+}
+
+// PRSearchResult is a page of ranked PR search hits
+type PRSearchResult struct {
+	Results []struct {
+		PR   PRResponse `json:"pr"`
+		Rank float64    `json:"rank"`
+	} `json:"results"`
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// SearchPRs runs a full-text PR search
+func (c *PRAllocationClient) SearchPRs(ctx context.Context, params PRSearchParams) (*PRSearchResult, error) {
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"q":           params.Query,
+		"team_name":   params.TeamName,
+		"status":      params.Status,
+		"author_id":   params.AuthorID,
+		"reviewer_id": params.ReviewerID,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	if params.Limit != 0 {
+		query.Set("limit", strconv.Itoa(params.Limit))
+	}
+	if params.Offset != 0 {
+		query.Set("offset", strconv.Itoa(params.Offset))
+	}
+	url := fmt.Sprintf("%s/pullRequest/search?%s", c.baseURL, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, decodeError(resp)
+	}
+
+	var result PRSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// SubscribeEvents opens the user's PR activity stream (text/event-stream); the caller closes it.
+// A non-empty lastEventID resumes the stream right after that event.
+func (c *PRAllocationClient) SubscribeEvents(ctx context.Context, userID, lastEventID string) (io.ReadCloser, error) {
+	query := url.Values{}
+	query.Set("user_id", userID)
+	url := fmt.Sprintf("%s/events/stream?%s", c.baseURL, query.Encode())
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := c.streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp.Body, nil
+	}
+	defer resp.Body.Close()
+
+	return nil, decodeError(resp)
+}
+
 // CodeStorageClient is a client for code-storage-service
 type CodeStorageClient struct {
 	baseURL    string
@@ -483,7 +694,8 @@ func NewCodeStorageClient(baseURL string) *CodeStorageClient {
 	return &CodeStorageClient{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second, // Longer timeout for file uploads
+			Timeout:   60 * time.Second, // Longer timeout for file uploads
+			Transport: tracing.Transport(nil),
 		},
 	}
 }
@@ -494,6 +706,7 @@ type CommitResponse struct {
 	TeamID          uuid.UUID   `json:"team_id"`
 	RootCommit      uuid.UUID   `json:"root_commit"`
 	ParentCommitIDs []uuid.UUID `json:"parent_commit_ids"`
+	Tree            bool        `json:"tree"`
 	CreatedAt       time.Time   `json:"createdAt"`
 	CommitName      *string     `json:"commit_name,omitempty"`
 }
@@ -529,8 +742,7 @@ func (c *CodeStorageClient) InitRepository(ctx context.Context, teamID uuid.UUID
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -575,12 +787,8 @@ func (c *CodeStorageClient) Push(ctx context.Context, teamID, rootCommit, parent
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("commit not found")
-	}
 	if resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -593,10 +801,27 @@ func (c *CodeStorageClient) Push(ctx context.Context, teamID, rootCommit, parent
 	return &result.Commit, nil
 }
 
-// Checkout retrieves code for a commit
-func (c *CodeStorageClient) Checkout(ctx context.Context, teamID, rootCommit, commitID uuid.UUID) ([]byte, error) {
-	url := fmt.Sprintf("%s/storage/checkout?team_id=%s&root_commit=%s&commit_id=%s",
-		c.baseURL, teamID.String(), rootCommit.String(), commitID.String())
+// CheckoutResponse is checked-out code with the content type and filename code-storage-service serves it as
+type CheckoutResponse struct {
+	Content     []byte
+	ContentType string
+	Filename    string
+}
+
+// Checkout retrieves code for a commit: a tree commit as an archive of the format ("" for zip), or with
+// a path just that file
+func (c *CodeStorageClient) Checkout(ctx context.Context, teamID, rootCommit, commitID uuid.UUID, filePath, format string) (*CheckoutResponse, error) {
+	query := url.Values{}
+	query.Set("team_id", teamID.String())
+	query.Set("root_commit", rootCommit.String())
+	query.Set("commit_id", commitID.String())
+	if filePath != "" {
+		query.Set("path", filePath)
+	}
+	if format != "" {
+		query.Set("format", format)
+	}
+	url := fmt.Sprintf("%s/storage/checkout?%s", c.baseURL, query.Encode())
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
@@ -609,14 +834,19 @@ func (c *CodeStorageClient) Checkout(ctx context.Context, teamID, rootCommit, co
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("commit not found")
-	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, decodeError(resp)
 	}
 
-	return io.ReadAll(resp.Body)
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	checkout := &CheckoutResponse{Content: content, ContentType: resp.Header.Get("Content-Type"), Filename: "code.zip"}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		checkout.Filename = params["filename"]
+	}
+	return checkout, nil
 }
 
 // Merge merges two commits
@@ -648,8 +878,7 @@ func (c *CodeStorageClient) Merge(ctx context.Context, teamID, rootCommit, commi
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -662,6 +891,41 @@ func (c *CodeStorageClient) Merge(ctx context.Context, teamID, rootCommit, commi
 	return &result.Commit, nil
 }
 
+// CheckMerge checks that two commits merge cleanly without creating the merge commit
+func (c *CodeStorageClient) CheckMerge(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) error {
+	url := fmt.Sprintf("%s/storage/merge", c.baseURL)
+
+	body := map[string]any{
+		"team_id":     teamID.String(),
+		"root_commit": rootCommit.String(),
+		"commit_id1":  commitID1.String(),
+		"commit_id2":  commitID2.String(),
+		"dry_run":     true,
+	}
+
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+	return nil
+}
+
 // ListCommits returns all commits for a repository
 func (c *CodeStorageClient) ListCommits(ctx context.Context, teamID, rootCommit uuid.UUID) ([]CommitResponse, error) {
 	url := fmt.Sprintf("%s/storage/commits?team_id=%s&root_commit=%s", c.baseURL, teamID.String(), rootCommit.String())
@@ -678,8 +942,7 @@ func (c *CodeStorageClient) ListCommits(ctx context.Context, teamID, rootCommit
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -707,12 +970,8 @@ func (c *CodeStorageClient) GetRootCommitByRepoName(ctx context.Context, teamID
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return uuid.Nil, fmt.Errorf("repository not found")
-	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return uuid.Nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return uuid.Nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -725,8 +984,9 @@ func (c *CodeStorageClient) GetRootCommitByRepoName(ctx context.Context, teamID
 	return result.RootCommit, nil
 }
 
-// InitRepositoryWithName initializes a new repository with a name
-func (c *CodeStorageClient) InitRepositoryWithName(ctx context.Context, teamID uuid.UUID, repoName string, code []byte) (*CommitResponse, error) {
+// InitRepositoryWithName initializes a new repository with a name; with a tar or zip format the code is
+// an archive unpacked into a tree commit
+func (c *CodeStorageClient) InitRepositoryWithName(ctx context.Context, teamID uuid.UUID, repoName string, code []byte, format string) (*CommitResponse, error) {
 	url := fmt.Sprintf("%s/storage/init", c.baseURL)
 
 	body := &bytes.Buffer{}
@@ -734,6 +994,9 @@ func (c *CodeStorageClient) InitRepositoryWithName(ctx context.Context, teamID u
 
 	_ = writer.WriteField("team_id", teamID.String())
 	_ = writer.WriteField("commit_name", repoName) // repo name = root commit name
+	if format != "" {
+		_ = writer.WriteField("format", format)
+	}
 
 	part, err := writer.CreateFormFile("code", "code.zip")
 	if err != nil {
@@ -757,8 +1020,7 @@ func (c *CodeStorageClient) InitRepositoryWithName(ctx context.Context, teamID u
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -771,8 +1033,9 @@ func (c *CodeStorageClient) InitRepositoryWithName(ctx context.Context, teamID u
 	return &result.Commit, nil
 }
 
-// PushWithName creates a new commit with a name
-func (c *CodeStorageClient) PushWithName(ctx context.Context, teamID, rootCommit, parentCommit uuid.UUID, commitName string, code []byte) (*CommitResponse, error) {
+// PushWithName creates a new commit with a name; with a tar or zip format the code is an archive
+// unpacked into a tree commit
+func (c *CodeStorageClient) PushWithName(ctx context.Context, teamID, rootCommit, parentCommit uuid.UUID, commitName string, code []byte, format string) (*CommitResponse, error) {
 	url := fmt.Sprintf("%s/storage/push", c.baseURL)
 
 	body := &bytes.Buffer{}
@@ -782,6 +1045,9 @@ func (c *CodeStorageClient) PushWithName(ctx context.Context, teamID, rootCommit
 	_ = writer.WriteField("root_commit", rootCommit.String())
 	_ = writer.WriteField("commit_id", parentCommit.String())
 	_ = writer.WriteField("commit_name", commitName)
+	if format != "" {
+		_ = writer.WriteField("format", format)
+	}
 
 	part, err := writer.CreateFormFile("code", "code.zip")
 	if err != nil {
@@ -804,12 +1070,8 @@ func (c *CodeStorageClient) PushWithName(ctx context.Context, teamID, rootCommit
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, fmt.Errorf("commit not found")
-	}
 	if resp.StatusCode != http.StatusCreated {
-		respBody, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+		return nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -838,12 +1100,8 @@ func (c *CodeStorageClient) GetCommitIDByName(ctx context.Context, teamID, rootC
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusNotFound {
-		return uuid.Nil, fmt.Errorf("commit not found")
-	}
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return uuid.Nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+		return uuid.Nil, decodeError(resp)
 	}
 
 	var result struct {
@@ -862,3 +1120,127 @@ func (c *CodeStorageClient) ResolveCommitByName(ctx context.Context, teamID uuid
 	// which requires root_commit UUID
 	return uuid.Nil, fmt.Errorf("ResolveCommitByName is deprecated, use service.resolveCommitByName instead")
 }
+
+// MergeBase returns the lowest common ancestor of two commits
+func (c *CodeStorageClient) MergeBase(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (uuid.UUID, error) {
+	url := fmt.Sprintf("%s/storage/mergeBase?team_id=%s&root_commit=%s&commit_id1=%s&commit_id2=%s",
+		c.baseURL, teamID.String(), rootCommit.String(), commitID1.String(), commitID2.String())
+
+	var result struct {
+		MergeBase uuid.UUID `json:"merge_base"`
+	}
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return uuid.Nil, err
+	}
+	return result.MergeBase, nil
+}
+
+// IsAncestor checks if ancestorID is commitID or one of its ancestors
+func (c *CodeStorageClient) IsAncestor(ctx context.Context, teamID, rootCommit, ancestorID, commitID uuid.UUID) (bool, error) {
+	url := fmt.Sprintf("%s/storage/isAncestor?team_id=%s&root_commit=%s&ancestor_id=%s&commit_id=%s",
+		c.baseURL, teamID.String(), rootCommit.String(), ancestorID.String(), commitID.String())
+
+	var result struct {
+		IsAncestor bool `json:"is_ancestor"`
+	}
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return false, err
+	}
+	return result.IsAncestor, nil
+}
+
+// AheadBehind counts the commits reachable from commitID1 but not commitID2 (ahead) and the other way round (behind)
+func (c *CodeStorageClient) AheadBehind(ctx context.Context, teamID, rootCommit, commitID1, commitID2 uuid.UUID) (int, int, error) {
+	url := fmt.Sprintf("%s/storage/aheadBehind?team_id=%s&root_commit=%s&commit_id1=%s&commit_id2=%s",
+		c.baseURL, teamID.String(), rootCommit.String(), commitID1.String(), commitID2.String())
+
+	var result struct {
+		Ahead  int `json:"ahead"`
+		Behind int `json:"behind"`
+	}
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return 0, 0, err
+	}
+	return result.Ahead, result.Behind, nil
+}
+
+// GetCommitName returns the name of a commit
+func (c *CodeStorageClient) GetCommitName(ctx context.Context, commitID uuid.UUID) (string, error) {
+	url := fmt.Sprintf("%s/storage/commitName/%s", c.baseURL, commitID.String())
+
+	var result struct {
+		Name string `json:"name"`
+	}
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return "", err
+	}
+	return result.Name, nil
+}
+
+// getJSON performs a GET request against code-storage and decodes the 200 response into result
+func (c *CodeStorageClient) getJSON(ctx context.Context, url string, result any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return decodeError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// DiffLine is a line of a diff hunk
+type DiffLine struct {
+	Op        string `json:"op"`
+	Text      string `json:"text"`
+	NoNewline bool   `json:"no_newline,omitempty"`
+}
+
+// DiffHunk is a run of changes with its context
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// FileDiff is the diff of one file of a tree commit
+type FileDiff struct {
+	Path   string     `json:"path"`
+	Status string     `json:"status"`
+	Binary bool       `json:"binary"`
+	Hunks  []DiffHunk `json:"hunks"`
+}
+
+// DiffResponse is the line diff between the code of two commits; tree commits are diffed file by file
+type DiffResponse struct {
+	Context int        `json:"context"`
+	Binary  bool       `json:"binary"`
+	Hunks   []DiffHunk `json:"hunks"`
+	Files   []FileDiff `json:"files"`
+	Unified string     `json:"unified"`
+}
+
+// Diff returns the diff turning the code of fromCommit into the code of toCommit with contextLines lines of context
+func (c *CodeStorageClient) Diff(ctx context.Context, teamID, rootCommit, fromCommit, toCommit uuid.UUID, contextLines int) (*DiffResponse, error) {
+	url := fmt.Sprintf("%s/storage/diff?team_id=%s&root_commit=%s&from_commit_id=%s&to_commit_id=%s&context=%d",
+		c.baseURL, teamID.String(), rootCommit.String(), fromCommit.String(), toCommit.String(), contextLines)
+
+	var result DiffResponse
+	if err := c.getJSON(ctx, url, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
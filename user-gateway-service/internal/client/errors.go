@@ -0,0 +1,107 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/Meldy183/shared/pkg/problem"
+)
+
+// Errors the backend error codes map to; match them with errors.Is
+var (
+	ErrNotFound       = errors.New("not found")
+	ErrFileNotFound   = errors.New("file not found")
+	ErrForbidden      = errors.New("forbidden")
+	ErrInvalidRequest = errors.New("invalid request")
+	ErrAlreadyExists  = errors.New("already exists")
+	ErrNotOpen        = errors.New("pull request is not open")
+	ErrNotAllApproved = errors.New("not all reviewers approved")
+	ErrDepsNotMerged  = errors.New("dependencies not merged")
+	ErrMergeConflict  = errors.New("merge conflict")
+	ErrMixedContent   = errors.New("mixed content")
+	ErrInvalidArchive = errors.New("invalid archive")
+)
+
+// codeErrors maps the error codes of pr-allocation-service and code-storage-service to the errors above
+var codeErrors = map[string]error{
+	"NOT_FOUND":                      ErrNotFound,
+	"TEAM_NOT_FOUND":                 ErrNotFound,
+	"ROOT_COMMIT_NOT_FOUND":          ErrNotFound,
+	"COMMIT_NOT_FOUND":               ErrNotFound,
+	"FILE_NOT_FOUND":                 ErrFileNotFound,
+	"UNAUTHORIZED":                   ErrForbidden,
+	"FORBIDDEN":                      ErrForbidden,
+	"INVALID_REQUEST":                ErrInvalidRequest,
+	"INVALID_PARENT":                 ErrInvalidRequest,
+	"INVALID_COMMIT_NAME":            ErrInvalidRequest,
+	"COMMIT_NOT_LEAF":                ErrInvalidRequest,
+	"TEAM_EXISTS":                    ErrAlreadyExists,
+	"ORG_EXISTS":                     ErrAlreadyExists,
+	"PR_EXISTS":                      ErrAlreadyExists,
+	"COMMIT_NAME_EXISTS":             ErrAlreadyExists,
+	"REPOSITORY_ALREADY_INITIALIZED": ErrAlreadyExists,
+	"PR_MERGED":                      ErrNotOpen,
+	"PR_REJECTED":                    ErrNotOpen,
+	"PR_NOT_OPEN":                    ErrNotOpen,
+	"NOT_ALL_APPROVED":               ErrNotAllApproved,
+	"DEPENDENCIES_NOT_MERGED":        ErrDepsNotMerged,
+	"MERGE_CONFLICT":                 ErrMergeConflict,
+	"MIXED_CONTENT":                  ErrMixedContent,
+	"INVALID_ARCHIVE":                ErrInvalidArchive,
+}
+
+// statusErrors covers responses without a known error code
+var statusErrors = map[int]error{
+	http.StatusBadRequest:   ErrInvalidRequest,
+	http.StatusUnauthorized: ErrForbidden,
+	http.StatusForbidden:    ErrForbidden,
+	http.StatusNotFound:     ErrNotFound,
+}
+
+// APIError is an error response of a backend service
+type APIError struct {
+	Status int
+	Code   string
+	Detail string
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("unexpected status code: %d, body: %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Detail)
+}
+
+// Unwrap returns the error the code maps to, falling back to the one of the status
+func (e *APIError) Unwrap() error {
+	if err, ok := codeErrors[e.Code]; ok {
+		return err
+	}
+	return statusErrors[e.Status]
+}
+
+// decodeError reads the error response of a backend: a problem document, or the legacy
+// {"error": {"code", "message"}} body
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	var doc struct {
+		problem.Details
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return &APIError{Status: resp.StatusCode, Detail: string(body)}
+	}
+	if doc.Code != "" {
+		return &APIError{Status: resp.StatusCode, Code: doc.Code, Detail: doc.Detail}
+	}
+	if doc.Error.Code != "" {
+		return &APIError{Status: resp.StatusCode, Code: doc.Error.Code, Detail: doc.Error.Message}
+	}
+	return &APIError{Status: resp.StatusCode, Detail: string(body)}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDecodeError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   error
+		code   string
+	}{
+		{
+			name:   "problem document",
+			status: http.StatusConflict,
+			body:   `{"type":"about:blank","title":"Conflict","status":409,"detail":"conflicts in a.txt","code":"MERGE_CONFLICT"}`,
+			want:   ErrMergeConflict,
+			code:   "MERGE_CONFLICT",
+		},
+		{
+			name:   "legacy body",
+			status: http.StatusForbidden,
+			body:   `{"error":{"code":"FORBIDDEN","message":"maintainer role required"}}`,
+			want:   ErrForbidden,
+			code:   "FORBIDDEN",
+		},
+		{
+			name:   "code-storage not found",
+			status: http.StatusNotFound,
+			body:   `{"code":"COMMIT_NOT_FOUND","detail":"commit not found"}`,
+			want:   ErrNotFound,
+			code:   "COMMIT_NOT_FOUND",
+		},
+		{
+			name:   "file not found is not a missing commit",
+			status: http.StatusNotFound,
+			body:   `{"code":"FILE_NOT_FOUND","detail":"file not found"}`,
+			want:   ErrFileNotFound,
+			code:   "FILE_NOT_FOUND",
+		},
+		{
+			name:   "unknown code falls back to the status",
+			status: http.StatusBadRequest,
+			body:   `{"code":"SOMETHING_NEW"}`,
+			want:   ErrInvalidRequest,
+			code:   "SOMETHING_NEW",
+		},
+		{
+			name:   "plain text body",
+			status: http.StatusNotFound,
+			body:   "404 page not found",
+			want:   ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}
+			err := decodeError(resp)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("decodeError() = %v, want it to match %v", err, tt.want)
+			}
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Code != tt.code || apiErr.Status != tt.status {
+				t.Fatalf("decodeError() = %#v, want code %q and status %d", err, tt.code, tt.status)
+			}
+		})
+	}
+	if err := decodeError(&http.Response{StatusCode: http.StatusInternalServerError, Body: io.NopCloser(strings.NewReader("boom"))}); errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("decodeError(500) = %v, want no sentinel match", err)
+	}
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Request scoping: OrganizationHeader names the organization pr-allocation-service scopes
+// a request to; requests without it run in DefaultOrganization. ActorHeader names the user
+// whose team role pr-allocation-service checks on merges, reassignments, policy and role changes.
+const (
+	OrganizationHeader  = "X-Organization"
+	DefaultOrganization = "default"
+	ActorHeader         = "X-Actor-ID"
+)
+
+type organizationKey struct{}
+
+type actorKey struct{}
+
+// WithOrganization makes calls to pr-allocation-service made with ctx run in the named organization.
+func WithOrganization(ctx context.Context, orgName string) context.Context {
+	return context.WithValue(ctx, organizationKey{}, orgName)
+}
+
+// OrganizationFromContext returns the organization set by WithOrganization.
+func OrganizationFromContext(ctx context.Context) (string, bool) {
+	orgName, ok := ctx.Value(organizationKey{}).(string)
+	return orgName, ok && orgName != ""
+}
+
+// WithActor makes calls to pr-allocation-service made with ctx act on behalf of userID.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext returns the user set by WithActor.
+func ActorFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(actorKey{}).(string)
+	return userID, ok && userID != ""
+}
+
+// scopeTransport forwards the request's organization and actor to pr-allocation-service.
+type scopeTransport struct {
+	base http.RoundTripper
+}
+
+func (t scopeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	orgName, hasOrg := OrganizationFromContext(req.Context())
+	userID, hasActor := ActorFromContext(req.Context())
+	if !hasOrg && !hasActor {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	if hasOrg {
+		req.Header.Set(OrganizationHeader, orgName)
+	}
+	if hasActor {
+		req.Header.Set(ActorHeader, userID)
+	}
+	return t.base.RoundTrip(req)
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/Meldy183/shared/pkg/tracing"
 	"github.com/spf13/viper"
 )
 
@@ -18,6 +19,9 @@ func MustLoadConfig(path string) error {
 	viper.SetDefault("services.code_storage.host", "localhost")
 	viper.SetDefault("services.code_storage.port", "8081")
 	viper.SetDefault("env", "development")
+	viper.SetDefault("tracing.exporter", "none")
+	viper.SetDefault("tracing.endpoint", "localhost:4318")
+	viper.SetDefault("tracing.sample_ratio", 1.0)
 
 	viper.SetConfigName("config")
 	viper.SetConfigType("yaml")
@@ -44,6 +48,9 @@ func MustLoadConfig(path string) error {
 	bindEnvWithDefault("services.code_storage.host", "CODE_STORAGE_HOST")
 	bindEnvWithDefault("services.code_storage.port", "CODE_STORAGE_PORT")
 	bindEnvWithDefault("env", "ENV")
+	bindEnvWithDefault("tracing.exporter", "TRACING_EXPORTER")
+	bindEnvWithDefault("tracing.endpoint", "TRACING_ENDPOINT")
+	bindEnvWithDefault("tracing.sample_ratio", "TRACING_SAMPLE_RATIO")
 
 	// Support full URL env vars
 	if url := os.Getenv("PR_ALLOCATION_SERVICE_URL"); url != "" {
@@ -68,6 +75,7 @@ type Config struct {
 	Server   ServerConfig   `mapstructure:"server"`
 	Services ServicesConfig `mapstructure:"services"`
 	ENV      string         `mapstructure:"env"`
+	Tracing  tracing.Config `mapstructure:"tracing"`
 }
 
 // ServerConfig holds server configuration
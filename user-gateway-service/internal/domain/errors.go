@@ -13,6 +13,14 @@ const (
 	ErrCodePRAlreadyExists = "PR_ALREADY_EXISTS"
 	ErrCodePRAlreadyMerged = "PR_ALREADY_MERGED"
 	ErrCodeNotReviewer     = "NOT_REVIEWER"
+	ErrCodeNotAllApproved  = "NOT_ALL_APPROVED"
+	ErrCodeDepsNotMerged   = "DEPENDENCIES_NOT_MERGED"
+	ErrCodePRAlreadyQueued = "PR_ALREADY_QUEUED"
+	ErrCodeMergeEjected    = "MERGE_EJECTED"
+	ErrCodeMergeConflict   = "MERGE_CONFLICT"
+	ErrCodeFileNotFound    = "FILE_NOT_FOUND"
+	ErrCodeInvalidArchive  = "INVALID_ARCHIVE"
+	ErrCodeMixedContent    = "MIXED_CONTENT"
 	ErrCodeInvalidRequest  = "INVALID_REQUEST"
 	ErrCodeInternalError   = "INTERNAL_ERROR"
 	ErrCodeTeamExists      = "TEAM_EXISTS"
@@ -30,6 +38,14 @@ var (
 	ErrPRAlreadyExists = errors.New("pull request already exists")
 	ErrPRAlreadyMerged = errors.New("pull request already merged")
 	ErrNotReviewer     = errors.New("user is not a reviewer of this PR")
+	ErrNotAllApproved  = errors.New("not all reviewers have approved")
+	ErrDepsNotMerged   = errors.New("PRs this one depends on are not merged yet")
+	ErrPRAlreadyQueued = errors.New("pull request is already in the merge queue")
+	ErrMergeEjected    = errors.New("pull request was ejected from the merge queue")
+	ErrMergeConflict   = errors.New("source and target changed the same lines")
+	ErrFileNotFound    = errors.New("file not found")
+	ErrInvalidArchive  = errors.New("invalid archive")
+	ErrMixedContent    = errors.New("a tree commit can't be compared with a single-file commit")
 	ErrInvalidRequest  = errors.New("invalid request")
 	ErrInternalError   = errors.New("internal error")
 )
@@ -78,6 +94,22 @@ func MapErrorToCode(err error) string {
 		return ErrCodePRAlreadyMerged
 	case errors.Is(err, ErrNotReviewer):
 		return ErrCodeNotReviewer
+	case errors.Is(err, ErrNotAllApproved):
+		return ErrCodeNotAllApproved
+	case errors.Is(err, ErrDepsNotMerged):
+		return ErrCodeDepsNotMerged
+	case errors.Is(err, ErrPRAlreadyQueued):
+		return ErrCodePRAlreadyQueued
+	case errors.Is(err, ErrMergeEjected):
+		return ErrCodeMergeEjected
+	case errors.Is(err, ErrMergeConflict):
+		return ErrCodeMergeConflict
+	case errors.Is(err, ErrFileNotFound):
+		return ErrCodeFileNotFound
+	case errors.Is(err, ErrInvalidArchive):
+		return ErrCodeInvalidArchive
+	case errors.Is(err, ErrMixedContent):
+		return ErrCodeMixedContent
 	case errors.Is(err, ErrInvalidRequest):
 		return ErrCodeInvalidRequest
 	default:
@@ -13,6 +13,7 @@ type UserProfile struct {
 	TeamID   uuid.UUID `json:"team_id"`
 	TeamName string    `json:"team_name"`
 	IsActive bool      `json:"is_active"`
+	Role     string    `json:"role"`
 }
 
 // Commit represents a code commit
@@ -22,9 +23,17 @@ type Commit struct {
 	ParentCommitIDs []uuid.UUID `json:"parent_commit_ids"`
 	CommitName      *string     `json:"commit_name,omitempty"`
 	RepoName        *string     `json:"repo_name,omitempty"`
+	Tree            bool        `json:"tree"`
 	CreatedAt       time.Time   `json:"created_at"`
 }
 
+// Checkout is checked-out code: a tree commit as an archive or one file of it, or a single-file commit's code
+type Checkout struct {
+	Content     []byte
+	ContentType string
+	Filename    string
+}
+
 // PullRequest represents a pull request
 type PullRequest struct {
 	PRID             string     `json:"pr_id"`
@@ -56,15 +65,146 @@ type CreatePRRequest struct {
 	TargetCommitName string `json:"target_commit_name"`
 }
 
+// SearchPRsRequest holds the filters of GET /api/pr/search (team defaults to the caller's team)
+type SearchPRsRequest struct {
+	Query    string
+	TeamName string
+	Status   string
+	Author   string
+	Reviewer string
+	Limit    int
+	Offset   int
+}
+
+// PRSearchHit is a ranked search result
+type PRSearchHit struct {
+	PullRequest PullRequest `json:"pull_request"`
+	Rank        float64     `json:"rank"`
+}
+
+// PRSearchResult is a page of search results
+type PRSearchResult struct {
+	Results []PRSearchHit `json:"results"`
+	Total   int           `json:"total"`
+	Limit   int           `json:"limit"`
+	Offset  int           `json:"offset"`
+}
+
+// UpdatePRSourceRequest is the request for moving a PR to a new source commit
+type UpdatePRSourceRequest struct {
+	SourceCommitName string `json:"source_commit_name"`
+}
+
+// MergeQueue lists the PRs waiting to be merged into a repository, in merge order,
+// and the PRs most recently ejected from it
+type MergeQueue struct {
+	TeamName string               `json:"team_name"`
+	RepoName string               `json:"repo_name"`
+	Entries  []MergeQueueEntry    `json:"entries"`
+	Ejected  []MergeQueueEjection `json:"ejected"`
+}
+
+// MergeQueueEntry is a PR in the merge queue; position 1 is being merged
+type MergeQueueEntry struct {
+	Position   int       `json:"position"`
+	PRID       string    `json:"pr_id"`
+	PRName     string    `json:"pr_name"`
+	EnqueuedBy string    `json:"enqueued_by"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	Merging    bool      `json:"merging"`
+}
+
+// MergeQueueEjection is a PR that failed re-validation or the merge itself and left the queue
+type MergeQueueEjection struct {
+	PRID      string    `json:"pr_id"`
+	PRName    string    `json:"pr_name"`
+	Reason    string    `json:"reason"`
+	EjectedAt time.Time `json:"ejected_at"`
+}
+
+// MergeBase is the lowest common ancestor of two commits of a repository
+type MergeBase struct {
+	CommitName1   string    `json:"commit_name1"`
+	CommitName2   string    `json:"commit_name2"`
+	MergeBaseID   uuid.UUID `json:"merge_base_id"`
+	MergeBaseName string    `json:"merge_base_name,omitempty"`
+}
+
+// Ancestry tells whether one commit is an ancestor of another; a commit is its own ancestor
+type Ancestry struct {
+	AncestorName string `json:"ancestor_name"`
+	CommitName   string `json:"commit_name"`
+	IsAncestor   bool   `json:"is_ancestor"`
+}
+
+// AheadBehind counts the commits reachable from CommitName1 but not CommitName2 (Ahead) and the other way round (Behind)
+type AheadBehind struct {
+	CommitName1 string `json:"commit_name1"`
+	CommitName2 string `json:"commit_name2"`
+	Ahead       int    `json:"ahead"`
+	Behind      int    `json:"behind"`
+}
+
+// DiffLine is a line of a diff hunk: op is context, delete or insert
+type DiffLine struct {
+	Op        string `json:"op"`
+	Text      string `json:"text"`
+	NoNewline bool   `json:"no_newline,omitempty"`
+}
+
+// DiffHunk is a run of changes with its context, numbered as in a unified diff header
+type DiffHunk struct {
+	OldStart int        `json:"old_start"`
+	OldLines int        `json:"old_lines"`
+	NewStart int        `json:"new_start"`
+	NewLines int        `json:"new_lines"`
+	Lines    []DiffLine `json:"lines"`
+}
+
+// FileDiff is the diff of one file of a tree commit; binary files have no hunks
+type FileDiff struct {
+	Path   string     `json:"path"`
+	Status string     `json:"status"`
+	Binary bool       `json:"binary"`
+	Hunks  []DiffHunk `json:"hunks"`
+}
+
+// PRDiff is what a PR changes: the diff from its target commit to its source commit.
+// Tree commits are diffed file by file into Files.
+type PRDiff struct {
+	PRName           string     `json:"pr_name"`
+	SourceCommitName string     `json:"source_commit_name"`
+	TargetCommitName string     `json:"target_commit_name"`
+	Context          int        `json:"context"`
+	Binary           bool       `json:"binary"`
+	Hunks            []DiffHunk `json:"hunks"`
+	Files            []FileDiff `json:"files,omitempty"`
+	Unified          string     `json:"unified"`
+}
+
 // RejectPRRequest is the request for rejecting a PR
 type RejectPRRequest struct {
 	Reason string `json:"reason,omitempty"`
 }
 
+// Team roles; each role has the permissions of the ones below it
+const (
+	RoleMember     = "member"
+	RoleMaintainer = "maintainer"
+	RoleAdmin      = "admin"
+)
+
+// RoleAtLeast reports whether role grants everything required grants
+func RoleAtLeast(role, required string) bool {
+	ranks := map[string]int{RoleMember: 1, RoleMaintainer: 2, RoleAdmin: 3}
+	return ranks[role] >= ranks[required]
+}
+
 // CreateTeamMember is a member in CreateTeamRequest (using username only)
 type CreateTeamMember struct {
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role,omitempty"`
 }
 
 // TeamMemberResponse is a member in response (includes ID)
@@ -72,6 +212,14 @@ type TeamMemberResponse struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	IsActive bool   `json:"is_active"`
+	Role     string `json:"role"`
+}
+
+// SetMemberRoleRequest is the request for changing a team member's role
+type SetMemberRoleRequest struct {
+	TeamName string `json:"team_name"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
 }
 
 // CreateTeamRequest is the request for creating a team
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/user-gateway-service/internal/client"
+	"github.com/Meldy183/user-gateway-service/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// resolveRepoCommits resolves the repository's team, root commit and the named commits, checking the user's access
+func (s *Service) resolveRepoCommits(ctx context.Context, username, teamName, repoName string, commitNames ...string) (uuid.UUID, uuid.UUID, []uuid.UUID, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.verifyUserAccess(ctx, username, teamName); err != nil {
+		return uuid.Nil, uuid.Nil, nil, err
+	}
+
+	teamID, err := s.prClient.ResolveTeamID(ctx, teamName)
+	if err != nil {
+		log.Error(ctx, "failed to resolve team", zap.Error(err))
+		return uuid.Nil, uuid.Nil, nil, domain.ErrTeamNotFound
+	}
+
+	// Get root commit from cache, or recover it from code-storage
+	rootCommit, ok := s.getRootCommit(teamID, repoName)
+	if !ok {
+		rootCommit, err = s.codeClient.GetRootCommitByRepoName(ctx, teamID, repoName)
+		if err != nil {
+			log.Error(ctx, "repository not found", zap.String("repo", repoName), zap.Error(err))
+			return uuid.Nil, uuid.Nil, nil, domain.ErrCommitNotFound
+		}
+		s.setRootCommit(teamID, repoName, rootCommit)
+	}
+
+	commitIDs := make([]uuid.UUID, len(commitNames))
+	for i, commitName := range commitNames {
+		commitIDs[i], err = s.resolveCommitByName(ctx, teamID, repoName, commitName)
+		if err != nil {
+			log.Error(ctx, "failed to resolve commit", zap.String("commit_name", commitName), zap.Error(err))
+			return uuid.Nil, uuid.Nil, nil, fmt.Errorf("%w: %s", domain.ErrCommitNotFound, commitName)
+		}
+	}
+	return teamID, rootCommit, commitIDs, nil
+}
+
+// GetMergeBase returns the lowest common ancestor of two commits, by name
+func (s *Service) GetMergeBase(ctx context.Context, username, teamName, repoName, commitName1, commitName2 string) (*domain.MergeBase, error) {
+	log := logger.FromContext(ctx)
+
+	teamID, rootCommit, commitIDs, err := s.resolveRepoCommits(ctx, username, teamName, repoName, commitName1, commitName2)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeBase, err := s.codeClient.MergeBase(ctx, teamID, rootCommit, commitIDs[0], commitIDs[1])
+	if err != nil {
+		log.Error(ctx, "failed to find merge base", zap.Error(err))
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, domain.ErrCommitNotFound
+		}
+		return nil, fmt.Errorf("failed to find merge base: %w", err)
+	}
+
+	// Unnamed commits (merge commits) are reported by ID only
+	name, err := s.codeClient.GetCommitName(ctx, mergeBase)
+	if err != nil && !errors.Is(err, client.ErrNotFound) {
+		log.Error(ctx, "failed to get merge base name", zap.Error(err))
+		return nil, fmt.Errorf("failed to get merge base name: %w", err)
+	}
+
+	return &domain.MergeBase{
+		CommitName1:   commitName1,
+		CommitName2:   commitName2,
+		MergeBaseID:   mergeBase,
+		MergeBaseName: name,
+	}, nil
+}
+
+// GetAncestry checks if ancestorName is commitName or one of its ancestors
+func (s *Service) GetAncestry(ctx context.Context, username, teamName, repoName, ancestorName, commitName string) (*domain.Ancestry, error) {
+	log := logger.FromContext(ctx)
+
+	teamID, rootCommit, commitIDs, err := s.resolveRepoCommits(ctx, username, teamName, repoName, ancestorName, commitName)
+	if err != nil {
+		return nil, err
+	}
+
+	isAncestor, err := s.codeClient.IsAncestor(ctx, teamID, rootCommit, commitIDs[0], commitIDs[1])
+	if err != nil {
+		log.Error(ctx, "failed to check ancestry", zap.Error(err))
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, domain.ErrCommitNotFound
+		}
+		return nil, fmt.Errorf("failed to check ancestry: %w", err)
+	}
+
+	return &domain.Ancestry{AncestorName: ancestorName, CommitName: commitName, IsAncestor: isAncestor}, nil
+}
+
+// GetAheadBehind counts how many commits each of two commits has that the other doesn't, by name
+func (s *Service) GetAheadBehind(ctx context.Context, username, teamName, repoName, commitName1, commitName2 string) (*domain.AheadBehind, error) {
+	log := logger.FromContext(ctx)
+
+	teamID, rootCommit, commitIDs, err := s.resolveRepoCommits(ctx, username, teamName, repoName, commitName1, commitName2)
+	if err != nil {
+		return nil, err
+	}
+
+	ahead, behind, err := s.codeClient.AheadBehind(ctx, teamID, rootCommit, commitIDs[0], commitIDs[1])
+	if err != nil {
+		log.Error(ctx, "failed to count ahead/behind commits", zap.Error(err))
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, domain.ErrCommitNotFound
+		}
+		return nil, fmt.Errorf("failed to count ahead/behind commits: %w", err)
+	}
+
+	return &domain.AheadBehind{CommitName1: commitName1, CommitName2: commitName2, Ahead: ahead, Behind: behind}, nil
+}
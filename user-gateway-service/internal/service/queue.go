@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/user-gateway-service/internal/client"
+	"github.com/Meldy183/user-gateway-service/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxEjections is how many recent ejections a merge queue remembers
+const maxEjections = 20
+
+// mergeQueue holds the PRs waiting to be merged into one repository; the head is being merged
+type mergeQueue struct {
+	entries []*queuedMerge
+	ejected []domain.MergeQueueEjection
+}
+
+// queuedMerge is a PR in a merge queue
+type queuedMerge struct {
+	entry domain.MergeQueueEntry
+	turn  chan struct{} // closed when the PR reaches the head of the queue
+}
+
+// mergeQueued merges the PR through its repository's merge queue. Merges into a repository run
+// one at a time, in the order they were requested; when its turn comes the PR is re-validated,
+// and a PR that can no longer be merged, or whose merge fails, is ejected with the reason.
+func (s *Service) mergeQueued(ctx context.Context, username, prID string, meta *PRMetadata, pr *domain.PullRequest) (*domain.Commit, error) {
+	log := logger.FromContext(ctx)
+	repoKey := mergeQueueKey(meta.TeamID, meta.RepoName)
+
+	// A source update in progress finishes before the PR is queued; once queued, the source stays put
+	meta.sourceMu.Lock()
+	queued, position, err := s.enqueueMerge(repoKey, domain.MergeQueueEntry{
+		PRID:       prID,
+		PRName:     meta.PRName,
+		EnqueuedBy: username,
+		EnqueuedAt: time.Now().UTC(),
+	})
+	meta.sourceMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	log.Info(ctx, "PR queued for merge",
+		zap.String("pr_name", meta.PRName),
+		zap.String("repo", meta.RepoName),
+		zap.Int("position", position),
+	)
+
+	select {
+	case <-queued.turn:
+	case <-ctx.Done():
+		s.leaveQueue(repoKey, queued, "")
+		return nil, ctx.Err()
+	}
+
+	reason, err := s.revalidateMerge(ctx, prID, meta)
+	if err != nil {
+		s.leaveQueue(repoKey, queued, err.Error())
+		return nil, err
+	}
+	if reason != "" {
+		log.Info(ctx, "PR ejected from merge queue", zap.String("pr_name", meta.PRName), zap.String("reason", reason))
+		s.leaveQueue(repoKey, queued, reason)
+		return nil, fmt.Errorf("%w: %s", domain.ErrMergeEjected, reason)
+	}
+
+	commit, err := s.mergePR(ctx, prID, meta, pr)
+	if err != nil {
+		log.Info(ctx, "PR ejected from merge queue", zap.String("pr_name", meta.PRName), zap.Error(err))
+		s.leaveQueue(repoKey, queued, err.Error())
+		return nil, err
+	}
+	s.leaveQueue(repoKey, queued, "")
+	return commit, nil
+}
+
+// mergeQueueKey keys merge queues by team and repository
+func mergeQueueKey(teamID uuid.UUID, repoName string) string {
+	return fmt.Sprintf("%s:%s", teamID.String(), repoName)
+}
+
+// inMergeQueue reports whether the PR is waiting in, or being merged by, its repository's merge queue
+func (s *Service) inMergeQueue(prID string, meta *PRMetadata) bool {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	queue, ok := s.mergeQueues[mergeQueueKey(meta.TeamID, meta.RepoName)]
+	return ok && slices.ContainsFunc(queue.entries, func(q *queuedMerge) bool { return q.entry.PRID == prID })
+}
+
+// enqueueMerge appends a PR to the repository's merge queue and returns its position
+func (s *Service) enqueueMerge(repoKey string, entry domain.MergeQueueEntry) (*queuedMerge, int, error) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	queue, ok := s.mergeQueues[repoKey]
+	if !ok {
+		queue = &mergeQueue{}
+		s.mergeQueues[repoKey] = queue
+	}
+	if slices.ContainsFunc(queue.entries, func(q *queuedMerge) bool { return q.entry.PRID == entry.PRID }) {
+		return nil, 0, domain.ErrPRAlreadyQueued
+	}
+
+	queued := &queuedMerge{entry: entry, turn: make(chan struct{})}
+	queue.entries = append(queue.entries, queued)
+	if len(queue.entries) == 1 {
+		close(queued.turn)
+	}
+	return queued, len(queue.entries), nil
+}
+
+// leaveQueue removes a PR from the repository's merge queue, recording the ejection reason
+// if there is one, and hands the turn to the next PR when the head leaves
+func (s *Service) leaveQueue(repoKey string, queued *queuedMerge, reason string) {
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	queue := s.mergeQueues[repoKey]
+	i := slices.Index(queue.entries, queued)
+	if i < 0 {
+		return
+	}
+	queue.entries = slices.Delete(queue.entries, i, i+1)
+	if i == 0 && len(queue.entries) > 0 {
+		close(queue.entries[0].turn)
+	}
+
+	if reason != "" {
+		queue.ejected = append(queue.ejected, domain.MergeQueueEjection{
+			PRID:      queued.entry.PRID,
+			PRName:    queued.entry.PRName,
+			Reason:    reason,
+			EjectedAt: time.Now().UTC(),
+		})
+		if len(queue.ejected) > maxEjections {
+			queue.ejected = queue.ejected[len(queue.ejected)-maxEjections:]
+		}
+	}
+}
+
+// revalidateMerge checks that a PR at the head of the queue can still be merged, since merges ahead of it
+// and changes made while it waited may have changed that: pr-allocation-service re-runs its role, approval
+// and dependency checks, the source and target must still be leaf commits, and they must still merge
+// without conflicts. Nothing is written until all of these pass. It returns why the PR can't be merged,
+// or "" if it can; a failed check with an error of its own is returned as that error.
+func (s *Service) revalidateMerge(ctx context.Context, prID string, meta *PRMetadata) (string, error) {
+	prResp, err := s.prClient.CheckMergePR(ctx, prID)
+	if err != nil {
+		switch {
+		case errors.Is(err, client.ErrForbidden):
+			return "", fmt.Errorf("%w: maintainer role required", domain.ErrAccessDenied)
+		case errors.Is(err, client.ErrNotAllApproved):
+			return "", fmt.Errorf("%w: approvals changed while the PR was queued", domain.ErrNotAllApproved)
+		case errors.Is(err, client.ErrDepsNotMerged):
+			return "", domain.ErrDepsNotMerged
+		case errors.Is(err, client.ErrNotOpen):
+			return backendDetail(err), nil
+		}
+		return "", fmt.Errorf("failed to check PR: %w", err)
+	}
+	if prResp.Status != "OPEN" {
+		return fmt.Sprintf("PR is %s", strings.ToLower(prResp.Status)), nil
+	}
+
+	sourceID, sourceName := meta.source()
+	commits, err := s.codeClient.ListCommits(ctx, meta.TeamID, meta.RootCommit)
+	if err != nil {
+		return "", fmt.Errorf("failed to list commits: %w", err)
+	}
+	for _, c := range commits {
+		switch {
+		case slices.Contains(c.ParentCommitIDs, meta.TargetCommit):
+			return fmt.Sprintf("target commit %s is no longer a leaf", meta.TargetCommitName), nil
+		case slices.Contains(c.ParentCommitIDs, sourceID):
+			return fmt.Sprintf("source commit %s is no longer a leaf", sourceName), nil
+		}
+	}
+
+	if err := s.codeClient.CheckMerge(ctx, meta.TeamID, meta.RootCommit, sourceID, meta.TargetCommit); err != nil {
+		switch {
+		case errors.Is(err, client.ErrMergeConflict):
+			return "", fmt.Errorf("%w: resolve the conflicts in %s and update the PR source", domain.ErrMergeConflict, sourceName)
+		case errors.Is(err, client.ErrMixedContent):
+			return "", domain.ErrMixedContent
+		}
+		return "", fmt.Errorf("failed to check merge: %w", err)
+	}
+	return "", nil
+}
+
+// GetMergeQueue returns the repository's merge queue and its recent ejections
+func (s *Service) GetMergeQueue(ctx context.Context, username, teamName, repoName string) (*domain.MergeQueue, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.verifyUserAccess(ctx, username, teamName); err != nil {
+		return nil, err
+	}
+
+	teamID, err := s.prClient.ResolveTeamID(ctx, teamName)
+	if err != nil {
+		log.Error(ctx, "failed to resolve team", zap.Error(err))
+		return nil, domain.ErrTeamNotFound
+	}
+
+	result := &domain.MergeQueue{
+		TeamName: teamName,
+		RepoName: repoName,
+		Entries:  []domain.MergeQueueEntry{},
+		Ejected:  []domain.MergeQueueEjection{},
+	}
+
+	s.queueMu.Lock()
+	defer s.queueMu.Unlock()
+
+	queue, ok := s.mergeQueues[mergeQueueKey(teamID, repoName)]
+	if !ok {
+		return result, nil
+	}
+	for i, queued := range queue.entries {
+		entry := queued.entry
+		entry.Position = i + 1
+		entry.Merging = i == 0
+		result.Entries = append(result.Entries, entry)
+	}
+	// Most recent ejections first
+	for i := len(queue.ejected) - 1; i >= 0; i-- {
+		result.Ejected = append(result.Ejected, queue.ejected[i])
+	}
+	return result, nil
+}
@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Meldy183/user-gateway-service/internal/client"
+	"github.com/Meldy183/user-gateway-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// backends fakes the merge endpoints of pr-allocation-service and code-storage-service
+type backends struct {
+	prCheck   func(w http.ResponseWriter)
+	codeCheck func(w http.ResponseWriter)
+	// merges are the non-dry-run merge calls, by service
+	merges []string
+}
+
+func (b *backends) start(t *testing.T) *Service {
+	openPR := map[string]any{"pr": map[string]any{"pull_request_id": "pr-1", "status": "OPEN"}}
+
+	pr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			DryRun bool `json:"dry_run"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !req.DryRun {
+			b.merges = append(b.merges, "pr")
+			_ = json.NewEncoder(w).Encode(map[string]any{"pr": map[string]any{"pull_request_id": "pr-1", "status": "MERGED"}})
+			return
+		}
+		if b.prCheck != nil {
+			b.prCheck(w)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(openPR)
+	}))
+	code := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/storage/commits" {
+			_ = json.NewEncoder(w).Encode(map[string]any{"commits": []any{}})
+			return
+		}
+		var req struct {
+			DryRun bool `json:"dry_run"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if !req.DryRun {
+			b.merges = append(b.merges, "code")
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"commit": map[string]any{"commit_id": uuid.New()}})
+			return
+		}
+		if b.codeCheck != nil {
+			b.codeCheck(w)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"merge_base": uuid.New()})
+	}))
+	t.Cleanup(pr.Close)
+	t.Cleanup(code.Close)
+	return NewService(client.NewPRAllocationClient(pr.URL), client.NewCodeStorageClient(code.URL))
+}
+
+// problemWith answers with a problem document of the given status and code
+func problemWith(status int, code string) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(map[string]any{"status": status, "code": code, "detail": code})
+	}
+}
+
+func TestMergeQueuedChecksBeforeWriting(t *testing.T) {
+	tests := []struct {
+		name      string
+		prCheck   func(w http.ResponseWriter)
+		codeCheck func(w http.ResponseWriter)
+		wantErr   error
+	}{
+		{name: "mergeable"},
+		{name: "approval dismissed", prCheck: problemWith(http.StatusConflict, "NOT_ALL_APPROVED"), wantErr: domain.ErrNotAllApproved},
+		{name: "dependency reopened", prCheck: problemWith(http.StatusConflict, "DEPENDENCIES_NOT_MERGED"), wantErr: domain.ErrDepsNotMerged},
+		{name: "actor demoted", prCheck: problemWith(http.StatusForbidden, "FORBIDDEN"), wantErr: domain.ErrAccessDenied},
+		{name: "PR rejected", prCheck: problemWith(http.StatusConflict, "PR_REJECTED"), wantErr: domain.ErrMergeEjected},
+		{name: "target moved into a conflict", codeCheck: problemWith(http.StatusConflict, "MERGE_CONFLICT"), wantErr: domain.ErrMergeConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &backends{prCheck: tt.prCheck, codeCheck: tt.codeCheck}
+			svc := b.start(t)
+			meta := &PRMetadata{PRName: "feature", RepoName: "repo", TeamID: uuid.New(), RootCommit: uuid.New(),
+				SourceCommit: uuid.New(), SourceCommitName: "feature-1", TargetCommit: uuid.New(), TargetCommitName: "main"}
+
+			_, err := svc.mergeQueued(context.Background(), "maintainer", "pr-1", meta, &domain.PullRequest{PRName: "feature"})
+			queue := svc.mergeQueues[mergeQueueKey(meta.TeamID, meta.RepoName)]
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("merge: %v", err)
+				}
+				if len(b.merges) != 2 || b.merges[0] != "code" || b.merges[1] != "pr" {
+					t.Fatalf("merge calls %v, want code then pr", b.merges)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(b.merges) > 0 {
+				t.Fatalf("merge calls %v after a failed check, want none", b.merges)
+			}
+			if len(queue.entries) != 0 || len(queue.ejected) != 1 || queue.ejected[0].Reason == "" {
+				t.Fatalf("queue %+v, want the PR ejected with a reason", queue)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/Meldy183/shared/pkg/logger"
 	"github.com/Meldy183/user-gateway-service/internal/client"
@@ -20,6 +24,9 @@ type Service struct {
 	prMetadata map[string]*PRMetadata
 	// In-memory storage for repo -> root_commit mapping
 	repoRootCommits map[string]uuid.UUID // key: "teamID:repoName"
+	// Merge queues per repository, guarded by queueMu
+	queueMu     sync.Mutex
+	mergeQueues map[string]*mergeQueue // key: "teamID:repoName"
 }
 
 // PRMetadata stores additional PR info not in pr-allocation-service
@@ -28,11 +35,22 @@ type PRMetadata struct {
 	TeamName         string
 	RepoName         string
 	RootCommit       uuid.UUID
-	SourceCommit     uuid.UUID
-	SourceCommitName string
+	SourceCommit     uuid.UUID // guarded by mu; read it with source
+	SourceCommitName string    // guarded by mu; read it with source
 	TargetCommit     uuid.UUID
 	TargetCommitName string
 	TeamID           uuid.UUID
+	// sourceMu serializes source updates with putting the PR in the merge queue,
+	// so the source never changes under a queued merge
+	sourceMu sync.Mutex
+	mu       sync.RWMutex
+}
+
+// source returns the PR's current source commit and its name
+func (m *PRMetadata) source() (uuid.UUID, string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.SourceCommit, m.SourceCommitName
 }
 
 // NewService creates a new Service instance
@@ -42,21 +60,32 @@ func NewService(prClient *client.PRAllocationClient, codeClient *client.CodeStor
 		codeClient:      codeClient,
 		prMetadata:      make(map[string]*PRMetadata),
 		repoRootCommits: make(map[string]uuid.UUID),
+		mergeQueues:     make(map[string]*mergeQueue),
 	}
 }
 
-// CreateTeam creates a new team
-func (s *Service) CreateTeam(ctx context.Context, req *domain.CreateTeamRequest) (*domain.Team, error) {
+// CreateTeam creates a new team; unless roles say otherwise, the creator becomes its admin
+func (s *Service) CreateTeam(ctx context.Context, creator string, req *domain.CreateTeamRequest) (*domain.Team, error) {
 	log := logger.FromContext(ctx)
 	log.Info(ctx, "creating team", zap.String("team_name", req.TeamName))
 
 	// Convert domain members to client members (username only, IDs are generated)
 	members := make([]client.TeamMember, len(req.Members))
+	hasAdmin := false
 	for i, m := range req.Members {
 		members[i] = client.TeamMember{
 			UserID:   m.Username, // Use username as user_id for simplicity
 			Username: m.Username,
 			IsActive: m.IsActive,
+			Role:     m.Role,
+		}
+		hasAdmin = hasAdmin || m.Role == domain.RoleAdmin
+	}
+	if !hasAdmin {
+		for i := range members {
+			if members[i].Username == creator {
+				members[i].Role = domain.RoleAdmin
+			}
 		}
 	}
 
@@ -64,9 +93,12 @@ func (s *Service) CreateTeam(ctx context.Context, req *domain.CreateTeamRequest)
 	team, err := s.prClient.CreateTeam(ctx, req.TeamName, members)
 	if err != nil {
 		log.Error(ctx, "failed to create team", zap.Error(err))
-		if strings.Contains(err.Error(), "already exists") {
+		if errors.Is(err, client.ErrAlreadyExists) {
 			return nil, domain.ErrTeamExists
 		}
+		if errors.Is(err, client.ErrInvalidRequest) {
+			return nil, fmt.Errorf("%w: a member belongs to another organization", domain.ErrInvalidRequest)
+		}
 		return nil, fmt.Errorf("failed to create team: %w", err)
 	}
 
@@ -77,6 +109,7 @@ func (s *Service) CreateTeam(ctx context.Context, req *domain.CreateTeamRequest)
 			UserID:   m.UserID,
 			Username: m.Username,
 			IsActive: m.IsActive,
+			Role:     m.Role,
 		}
 	}
 
@@ -106,6 +139,7 @@ func (s *Service) GetTeam(ctx context.Context, teamName string) (*domain.Team, e
 			UserID:   m.UserID,
 			Username: m.Username,
 			IsActive: m.IsActive,
+			Role:     m.Role,
 		}
 	}
 
@@ -133,6 +167,7 @@ func (s *Service) GetUserProfile(ctx context.Context, username string) (*domain.
 		TeamID:   user.TeamID,
 		TeamName: user.TeamName,
 		IsActive: user.IsActive,
+		Role:     user.Role,
 	}, nil
 }
 
@@ -141,9 +176,9 @@ func (s *Service) ResolveTeamName(ctx context.Context, teamName string) (uuid.UU
 	return s.prClient.ResolveTeamID(ctx, teamName)
 }
 
-// InitRepository initializes a new repository using names
-// InitRepository initializes a new repository using names
-func (s *Service) InitRepository(ctx context.Context, username, teamName, repoName, commitName string, code []byte) (*domain.Commit, error) {
+// InitRepository initializes a new repository using names; with a tar or zip format the code is an archive
+// of a file tree
+func (s *Service) InitRepository(ctx context.Context, username, teamName, repoName, commitName string, code []byte, format string) (*domain.Commit, error) {
 	log := logger.FromContext(ctx)
 
 	// Resolve team name to UUID
@@ -159,10 +194,13 @@ func (s *Service) InitRepository(ctx context.Context, username, teamName, repoNa
 	}
 
 	// Initialize repository in code-storage with commit name
-	commit, err := s.codeClient.InitRepositoryWithName(ctx, teamID, commitName, code)
+	commit, err := s.codeClient.InitRepositoryWithName(ctx, teamID, commitName, code, format)
 	if err != nil {
 		log.Error(ctx, "failed to init repository", zap.Error(err))
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, client.ErrInvalidArchive) {
+			return nil, domain.ErrInvalidArchive
+		}
+		if errors.Is(err, client.ErrNotFound) {
 			return nil, domain.ErrTeamNotFound
 		}
 		return nil, fmt.Errorf("failed to init repository: %w", err)
@@ -185,12 +223,13 @@ func (s *Service) InitRepository(ctx context.Context, username, teamName, repoNa
 		ParentCommitIDs: commit.ParentCommitIDs,
 		CommitName:      &commitName,
 		RepoName:        &repoName,
+		Tree:            commit.Tree,
 		CreatedAt:       commit.CreatedAt,
 	}, nil
 }
 
-// Push creates a new commit using names
-func (s *Service) Push(ctx context.Context, username, teamName, repoName, parentCommitName, commitName string, code []byte) (*domain.Commit, error) {
+// Push creates a new commit using names; with a tar or zip format the code is an archive of a file tree
+func (s *Service) Push(ctx context.Context, username, teamName, repoName, parentCommitName, commitName string, code []byte, format string) (*domain.Commit, error) {
 	log := logger.FromContext(ctx)
 
 	// Verify user access
@@ -220,10 +259,13 @@ func (s *Service) Push(ctx context.Context, username, teamName, repoName, parent
 	}
 
 	// Push commit with name
-	commit, err := s.codeClient.PushWithName(ctx, teamID, rootCommit, parentCommit, commitName, code)
+	commit, err := s.codeClient.PushWithName(ctx, teamID, rootCommit, parentCommit, commitName, code, format)
 	if err != nil {
 		log.Error(ctx, "failed to push commit", zap.Error(err))
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, client.ErrInvalidArchive) {
+			return nil, domain.ErrInvalidArchive
+		}
+		if errors.Is(err, client.ErrNotFound) {
 			return nil, domain.ErrCommitNotFound
 		}
 		return nil, fmt.Errorf("failed to push: %w", err)
@@ -241,12 +283,14 @@ func (s *Service) Push(ctx context.Context, username, teamName, repoName, parent
 		ParentCommitIDs: commit.ParentCommitIDs,
 		CommitName:      &commitName,
 		RepoName:        &repoName,
+		Tree:            commit.Tree,
 		CreatedAt:       commit.CreatedAt,
 	}, nil
 }
 
-// Checkout retrieves code for a commit using names
-func (s *Service) Checkout(ctx context.Context, username, teamName, repoName, commitName string) ([]byte, error) {
+// Checkout retrieves code for a commit using names: a tree commit as an archive of the format, or with a path
+// just that file
+func (s *Service) Checkout(ctx context.Context, username, teamName, repoName, commitName, filePath, format string) (*domain.Checkout, error) {
 	log := logger.FromContext(ctx)
 
 	// Verify user access
@@ -275,10 +319,13 @@ func (s *Service) Checkout(ctx context.Context, username, teamName, repoName, co
 		return nil, domain.ErrCommitNotFound
 	}
 
-	code, err := s.codeClient.Checkout(ctx, teamID, rootCommit, commitID)
+	checkout, err := s.codeClient.Checkout(ctx, teamID, rootCommit, commitID, filePath, format)
 	if err != nil {
 		log.Error(ctx, "failed to checkout", zap.Error(err))
-		if strings.Contains(err.Error(), "not found") {
+		if errors.Is(err, client.ErrFileNotFound) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrFileNotFound, filePath)
+		}
+		if errors.Is(err, client.ErrNotFound) {
 			return nil, domain.ErrCommitNotFound
 		}
 		return nil, fmt.Errorf("failed to checkout: %w", err)
@@ -287,9 +334,10 @@ func (s *Service) Checkout(ctx context.Context, username, teamName, repoName, co
 	log.Info(ctx, "checkout successful",
 		zap.String("username", username),
 		zap.String("commit_name", commitName),
+		zap.String("path", filePath),
 	)
 
-	return code, nil
+	return (*domain.Checkout)(checkout), nil
 }
 
 // ListCommits lists all commits for a repository using names
@@ -343,6 +391,7 @@ func (s *Service) ListCommits(ctx context.Context, username, teamName, repoName
 			ParentCommitIDs: c.ParentCommitIDs,
 			CommitName:      &commitName,
 			RepoName:        &repoName,
+			Tree:            c.Tree,
 			CreatedAt:       c.CreatedAt,
 		}
 		// Mark root commit
@@ -404,14 +453,14 @@ func (s *Service) CreatePR(ctx context.Context, username string, req *domain.Cre
 	prResp, err := s.prClient.CreatePR(ctx, prID, req.Title, username)
 	if err != nil {
 		log.Error(ctx, "failed to create PR", zap.Error(err))
-		if strings.Contains(err.Error(), "already exists") {
+		if errors.Is(err, client.ErrAlreadyExists) {
 			return nil, domain.ErrPRAlreadyExists
 		}
 		return nil, fmt.Errorf("failed to create PR: %w", err)
 	}
 
-	// Store metadata for later use (keyed by team_name:pr_name)
-	metaKey := fmt.Sprintf("%s:%s", req.TeamName, req.PRName)
+	// Store metadata for later use (keyed by team_name:pr_name@organization)
+	metaKey := prMetaKey(ctx, req.TeamName, req.PRName)
 	s.prMetadata[metaKey] = &PRMetadata{
 		PRName:           req.PRName,
 		TeamName:         req.TeamName,
@@ -485,8 +534,7 @@ func (s *Service) GetMyPRs(ctx context.Context, username string, status string)
 			domainPR.TeamName = meta.TeamName
 			domainPR.RepoName = meta.RepoName
 			domainPR.RootCommitID = meta.RootCommit
-			domainPR.SourceCommitID = meta.SourceCommit
-			domainPR.SourceCommitName = meta.SourceCommitName
+			domainPR.SourceCommitID, domainPR.SourceCommitName = meta.source()
 			domainPR.TargetCommitID = meta.TargetCommit
 			domainPR.TargetCommitName = meta.TargetCommitName
 		}
@@ -529,8 +577,7 @@ func (s *Service) GetReviewPRs(ctx context.Context, username string, status stri
 			domainPR.TeamName = meta.TeamName
 			domainPR.RepoName = meta.RepoName
 			domainPR.RootCommitID = meta.RootCommit
-			domainPR.SourceCommitID = meta.SourceCommit
-			domainPR.SourceCommitName = meta.SourceCommitName
+			domainPR.SourceCommitID, domainPR.SourceCommitName = meta.source()
 			domainPR.TargetCommitID = meta.TargetCommit
 			domainPR.TargetCommitName = meta.TargetCommitName
 		}
@@ -541,12 +588,114 @@ func (s *Service) GetReviewPRs(ctx context.Context, username string, status stri
 	return result, nil
 }
 
+// SearchPRs runs a full-text search over PRs of a team the user belongs to (their own team by default)
+func (s *Service) SearchPRs(ctx context.Context, username string, req *domain.SearchPRsRequest) (*domain.PRSearchResult, error) {
+	log := logger.FromContext(ctx)
+
+	teamName := req.TeamName
+	if teamName == "" {
+		user, err := s.prClient.GetUser(ctx, username)
+		if err != nil {
+			return nil, domain.ErrUserNotFound
+		}
+		teamName = user.TeamName
+	}
+	if err := s.verifyUserAccess(ctx, username, teamName); err != nil {
+		return nil, err
+	}
+
+	found, err := s.prClient.SearchPRs(ctx, client.PRSearchParams{
+		Query:      req.Query,
+		TeamName:   teamName,
+		Status:     req.Status,
+		AuthorID:   req.Author,
+		ReviewerID: req.Reviewer,
+		Limit:      req.Limit,
+		Offset:     req.Offset,
+	})
+	if err != nil {
+		log.Error(ctx, "failed to search PRs", zap.Error(err))
+		if errors.Is(err, client.ErrInvalidRequest) {
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidRequest, backendDetail(err))
+		}
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, domain.ErrTeamNotFound
+		}
+		return nil, fmt.Errorf("failed to search PRs: %w", err)
+	}
+
+	result := &domain.PRSearchResult{
+		Results: make([]domain.PRSearchHit, 0, len(found.Results)),
+		Total:   found.Total,
+		Limit:   found.Limit,
+		Offset:  found.Offset,
+	}
+	for _, hit := range found.Results {
+		pr := domain.PullRequest{
+			PRID:        hit.PR.PRID,
+			Title:       hit.PR.PRName,
+			AuthorID:    hit.PR.AuthorID,
+			AuthorName:  hit.PR.AuthorID, // username = user_id
+			Status:      hit.PR.Status,
+			ReviewerIDs: hit.PR.AssignedReviewers,
+			TeamName:    teamName,
+			CreatedAt:   hit.PR.CreatedAt,
+			MergedAt:    hit.PR.MergedAt,
+		}
+
+		// Add metadata if available
+		if meta, ok := s.prMetadata[hit.PR.PRID]; ok {
+			pr.PRName = meta.PRName
+			pr.RepoName = meta.RepoName
+			pr.RootCommitID = meta.RootCommit
+			pr.SourceCommitID, pr.SourceCommitName = meta.source()
+			pr.TargetCommitID = meta.TargetCommit
+			pr.TargetCommitName = meta.TargetCommitName
+		}
+
+		result.Results = append(result.Results, domain.PRSearchHit{PullRequest: pr, Rank: hit.Rank})
+	}
+
+	log.Info(ctx, "searched PRs",
+		zap.String("username", username),
+		zap.String("team_name", teamName),
+		zap.Int("total", result.Total),
+	)
+
+	return result, nil
+}
+
+// SubscribeEvents opens the PR activity stream of the user (assignments, approvals, rejections and
+// merges of PRs they authored or review). The stream is passed through as is; the caller closes it.
+func (s *Service) SubscribeEvents(ctx context.Context, username, lastEventID string) (io.ReadCloser, error) {
+	log := logger.FromContext(ctx)
+
+	stream, err := s.prClient.SubscribeEvents(ctx, username, lastEventID)
+	if err != nil {
+		log.Error(ctx, "failed to subscribe to PR events", zap.Error(err))
+		if errors.Is(err, client.ErrNotFound) {
+			return nil, domain.ErrUserNotFound
+		}
+		if errors.Is(err, client.ErrInvalidRequest) {
+			return nil, fmt.Errorf("%w: Last-Event-ID must be a non-negative integer", domain.ErrInvalidRequest)
+		}
+		return nil, fmt.Errorf("failed to subscribe to PR events: %w", err)
+	}
+
+	log.Info(ctx, "subscribed to PR events",
+		zap.String("username", username),
+		zap.String("last_event_id", lastEventID),
+	)
+
+	return stream, nil
+}
+
 // ApprovePR approves a PR and triggers merge if all approved using names
 func (s *Service) ApprovePR(ctx context.Context, username, teamName, prName string) (*domain.PullRequest, *domain.Commit, error) {
 	log := logger.FromContext(ctx)
 
 	// Get PR metadata by name
-	metaKey := fmt.Sprintf("%s:%s", teamName, prName)
+	metaKey := prMetaKey(ctx, teamName, prName)
 	meta, ok := s.prMetadata[metaKey]
 	if !ok {
 		return nil, nil, domain.ErrPRNotFound
@@ -582,6 +731,7 @@ func (s *Service) ApprovePR(ctx context.Context, username, teamName, prName stri
 		zap.Bool("all_approved", allApproved),
 	)
 
+	sourceID, sourceName := meta.source()
 	pr := &domain.PullRequest{
 		PRID:             prResp.PRID,
 		PRName:           prName,
@@ -589,8 +739,8 @@ func (s *Service) ApprovePR(ctx context.Context, username, teamName, prName stri
 		AuthorID:         prResp.AuthorID,
 		Status:           prResp.Status,
 		ReviewerIDs:      prResp.AssignedReviewers,
-		SourceCommitID:   meta.SourceCommit,
-		SourceCommitName: meta.SourceCommitName,
+		SourceCommitID:   sourceID,
+		SourceCommitName: sourceName,
 		TargetCommitID:   meta.TargetCommit,
 		TargetCommitName: meta.TargetCommitName,
 		RootCommitID:     meta.RootCommit,
@@ -600,41 +750,205 @@ func (s *Service) ApprovePR(ctx context.Context, username, teamName, prName stri
 		MergedAt:         prResp.MergedAt,
 	}
 
-	// If all reviewers approved, merge the code
-	if allApproved {
-		mergeCommit, err := s.codeClient.Merge(ctx, meta.TeamID, meta.RootCommit, meta.SourceCommit, meta.TargetCommit)
-		if err != nil {
-			log.Error(ctx, "failed to merge commits", zap.Error(err))
-			return nil, nil, fmt.Errorf("failed to merge: %w", err)
+	if !allApproved {
+		return pr, nil, nil
+	}
+
+	// The last approval merges the code only when a maintainer gives it; otherwise the PR waits for POST /api/pr/merge
+	if err := s.verifyUserRole(ctx, username, teamName, domain.RoleMaintainer); err != nil {
+		log.Info(ctx, "PR approved, waiting for a maintainer to merge", zap.String("pr_name", prName))
+		return pr, nil, nil
+	}
+	if err := s.checkDependencies(ctx, prResp); err != nil {
+		if !errors.Is(err, domain.ErrDepsNotMerged) {
+			return nil, nil, err
 		}
+		log.Info(ctx, "PR approved, waiting for its dependencies to merge", zap.String("pr_name", prName))
+		return pr, nil, nil
+	}
+	commit, err := s.mergeQueued(ctx, username, prID, meta, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pr, commit, nil
+}
 
-		// Mark PR as merged in pr-allocation-service
-		prResp, err = s.prClient.MergePR(ctx, prID)
+// MergePR merges a fully approved PR; only team maintainers and admins may do it
+func (s *Service) MergePR(ctx context.Context, username, teamName, prName string) (*domain.PullRequest, *domain.Commit, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.verifyUserRole(ctx, username, teamName, domain.RoleMaintainer); err != nil {
+		return nil, nil, err
+	}
+
+	metaKey := prMetaKey(ctx, teamName, prName)
+	meta, ok := s.prMetadata[metaKey]
+	if !ok {
+		return nil, nil, domain.ErrPRNotFound
+	}
+
+	var prID string
+	for key, m := range s.prMetadata {
+		if m == meta && strings.HasPrefix(key, "pr-") {
+			prID = key
+			break
+		}
+	}
+	if prID == "" {
+		return nil, nil, domain.ErrPRNotFound
+	}
+
+	prResp, err := s.prClient.GetPR(ctx, prID)
+	if err != nil {
+		log.Error(ctx, "failed to get PR", zap.Error(err))
+		return nil, nil, domain.ErrPRNotFound
+	}
+	switch {
+	case prResp.Status == "MERGED":
+		return nil, nil, domain.ErrPRAlreadyMerged
+	case prResp.Status != "OPEN":
+		return nil, nil, fmt.Errorf("%w: PR is %s", domain.ErrInvalidRequest, strings.ToLower(prResp.Status))
+	}
+	for _, reviewer := range prResp.AssignedReviewers {
+		if !slices.Contains(prResp.ApprovedBy, reviewer) {
+			return nil, nil, domain.ErrNotAllApproved
+		}
+	}
+	if err := s.checkDependencies(ctx, prResp); err != nil {
+		return nil, nil, err
+	}
+
+	sourceID, sourceName := meta.source()
+	pr := &domain.PullRequest{
+		PRID:             prResp.PRID,
+		PRName:           prName,
+		Title:            prResp.PRName,
+		AuthorID:         prResp.AuthorID,
+		Status:           prResp.Status,
+		ReviewerIDs:      prResp.AssignedReviewers,
+		SourceCommitID:   sourceID,
+		SourceCommitName: sourceName,
+		TargetCommitID:   meta.TargetCommit,
+		TargetCommitName: meta.TargetCommitName,
+		RootCommitID:     meta.RootCommit,
+		RepoName:         meta.RepoName,
+		TeamName:         meta.TeamName,
+		CreatedAt:        prResp.CreatedAt,
+	}
+	commit, err := s.mergeQueued(ctx, username, prID, meta, pr)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pr, commit, nil
+}
+
+// checkDependencies fails with ErrDepsNotMerged while any PR the given one depends on is not merged,
+// so its code is never merged ahead of theirs
+func (s *Service) checkDependencies(ctx context.Context, prResp *client.PRResponse) error {
+	var pending []string
+	for _, depID := range prResp.DependsOn {
+		dep, err := s.prClient.GetPR(ctx, depID)
 		if err != nil {
-			log.Error(ctx, "failed to mark PR as merged", zap.Error(err))
-			return nil, nil, fmt.Errorf("failed to update PR status: %w", err)
+			return fmt.Errorf("failed to get dependency %s: %w", depID, err)
+		}
+		if dep.Status != "MERGED" {
+			pending = append(pending, depID)
 		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: waiting for %s", domain.ErrDepsNotMerged, strings.Join(pending, ", "))
+	}
+	return nil
+}
 
-		pr.Status = prResp.Status
-		pr.MergedAt = prResp.MergedAt
+// mergePR merges the PR's source into its target in code-storage and marks it merged in pr-allocation-service.
+// It runs at the head of the merge queue once revalidateMerge has passed, so the merge commit is only written
+// for a PR that pr-allocation-service would mark merged.
+func (s *Service) mergePR(ctx context.Context, prID string, meta *PRMetadata, pr *domain.PullRequest) (*domain.Commit, error) {
+	log := logger.FromContext(ctx)
 
-		log.Info(ctx, "PR merged",
-			zap.String("pr_name", prName),
-			zap.String("merge_commit", mergeCommit.CommitID.String()),
-		)
+	sourceID, sourceName := meta.source()
+	mergeCommit, err := s.codeClient.Merge(ctx, meta.TeamID, meta.RootCommit, sourceID, meta.TargetCommit)
+	if err != nil {
+		log.Error(ctx, "failed to merge commits", zap.Error(err))
+		if errors.Is(err, client.ErrMergeConflict) {
+			return nil, fmt.Errorf("%w: resolve the conflicts in %s and update the PR source", domain.ErrMergeConflict, sourceName)
+		}
+		if errors.Is(err, client.ErrMixedContent) {
+			return nil, domain.ErrMixedContent
+		}
+		return nil, fmt.Errorf("failed to merge: %w", err)
+	}
 
-		commit := &domain.Commit{
-			CommitID:        mergeCommit.CommitID,
-			RootCommit:      mergeCommit.RootCommit,
-			ParentCommitIDs: mergeCommit.ParentCommitIDs,
-			RepoName:        &meta.RepoName,
-			CreatedAt:       mergeCommit.CreatedAt,
+	// Mark PR as merged in pr-allocation-service
+	prResp, err := s.prClient.MergePR(ctx, prID)
+	if err != nil {
+		log.Error(ctx, "failed to mark PR as merged", zap.Error(err))
+		if errors.Is(err, client.ErrForbidden) {
+			return nil, fmt.Errorf("%w: maintainer role required", domain.ErrAccessDenied)
 		}
+		if errors.Is(err, client.ErrNotAllApproved) {
+			return nil, domain.ErrNotAllApproved
+		}
+		if errors.Is(err, client.ErrDepsNotMerged) {
+			return nil, domain.ErrDepsNotMerged
+		}
+		return nil, fmt.Errorf("failed to update PR status: %w", err)
+	}
 
-		return pr, commit, nil
+	pr.Status = prResp.Status
+	pr.MergedAt = prResp.MergedAt
+
+	log.Info(ctx, "PR merged",
+		zap.String("pr_name", pr.PRName),
+		zap.String("merge_commit", mergeCommit.CommitID.String()),
+	)
+
+	return &domain.Commit{
+		CommitID:        mergeCommit.CommitID,
+		RootCommit:      mergeCommit.RootCommit,
+		ParentCommitIDs: mergeCommit.ParentCommitIDs,
+		RepoName:        &meta.RepoName,
+		Tree:            mergeCommit.Tree,
+		CreatedAt:       mergeCommit.CreatedAt,
+	}, nil
+}
+
+// SetMemberRole changes a team member's role; only team admins may do it
+func (s *Service) SetMemberRole(ctx context.Context, username string, req *domain.SetMemberRoleRequest) (*domain.TeamMemberResponse, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.verifyUserRole(ctx, username, req.TeamName, domain.RoleAdmin); err != nil {
+		return nil, err
 	}
 
-	return pr, nil, nil
+	user, err := s.prClient.SetMemberRole(ctx, req.TeamName, req.Username, req.Role)
+	if err != nil {
+		log.Error(ctx, "failed to set member role", zap.Error(err))
+		switch {
+		case errors.Is(err, client.ErrForbidden):
+			return nil, fmt.Errorf("%w: admin role required", domain.ErrAccessDenied)
+		case errors.Is(err, client.ErrNotFound):
+			return nil, domain.ErrUserNotFound
+		case errors.Is(err, client.ErrInvalidRequest):
+			return nil, fmt.Errorf("%w: %s", domain.ErrInvalidRequest, backendDetail(err))
+		}
+		return nil, fmt.Errorf("failed to set member role: %w", err)
+	}
+
+	log.Info(ctx, "member role set",
+		zap.String("team_name", req.TeamName),
+		zap.String("member", req.Username),
+		zap.String("role", user.Role),
+		zap.String("by", username),
+	)
+
+	return &domain.TeamMemberResponse{
+		UserID:   user.UserID,
+		Username: user.Username,
+		IsActive: user.IsActive,
+		Role:     user.Role,
+	}, nil
 }
 
 // RejectPR rejects a PR using names
@@ -642,7 +956,7 @@ func (s *Service) RejectPR(ctx context.Context, username, teamName, prName strin
 	log := logger.FromContext(ctx)
 
 	// Get PR metadata by name
-	metaKey := fmt.Sprintf("%s:%s", teamName, prName)
+	metaKey := prMetaKey(ctx, teamName, prName)
 	meta, ok := s.prMetadata[metaKey]
 	if !ok {
 		return nil, domain.ErrPRNotFound
@@ -678,6 +992,7 @@ func (s *Service) RejectPR(ctx context.Context, username, teamName, prName strin
 		zap.String("reason", reason),
 	)
 
+	sourceID, sourceName := meta.source()
 	return &domain.PullRequest{
 		PRID:             prResp.PRID,
 		PRName:           prName,
@@ -685,8 +1000,8 @@ func (s *Service) RejectPR(ctx context.Context, username, teamName, prName strin
 		AuthorID:         prResp.AuthorID,
 		Status:           prResp.Status,
 		ReviewerIDs:      prResp.AssignedReviewers,
-		SourceCommitID:   meta.SourceCommit,
-		SourceCommitName: meta.SourceCommitName,
+		SourceCommitID:   sourceID,
+		SourceCommitName: sourceName,
 		TargetCommitID:   meta.TargetCommit,
 		TargetCommitName: meta.TargetCommitName,
 		RootCommitID:     meta.RootCommit,
@@ -695,8 +1010,117 @@ func (s *Service) RejectPR(ctx context.Context, username, teamName, prName strin
 	}, nil
 }
 
+// UpdatePRSource moves an open PR to a new source commit: a leaf commit of the repository descending
+// from the current source. pr-allocation-service checks that the user is the author or a maintainer
+// and, unless the team turned it off, dismisses the approvals given so far; those reviewers are returned.
+func (s *Service) UpdatePRSource(ctx context.Context, username, teamName, prName, sourceCommitName string) (*domain.PullRequest, []string, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.verifyUserAccess(ctx, username, teamName); err != nil {
+		return nil, nil, err
+	}
+
+	metaKey := prMetaKey(ctx, teamName, prName)
+	meta, ok := s.prMetadata[metaKey]
+	if !ok {
+		return nil, nil, domain.ErrPRNotFound
+	}
+
+	var prID string
+	for key, m := range s.prMetadata {
+		if m == meta && strings.HasPrefix(key, "pr-") {
+			prID = key
+			break
+		}
+	}
+	if prID == "" {
+		return nil, nil, domain.ErrPRNotFound
+	}
+	// Held until the new source is recorded, so the PR can't be queued for merge in between
+	meta.sourceMu.Lock()
+	defer meta.sourceMu.Unlock()
+	if s.inMergeQueue(prID, meta) {
+		return nil, nil, fmt.Errorf("%w: wait for the merge to finish", domain.ErrPRAlreadyQueued)
+	}
+	currentID, currentName := meta.source()
+
+	sourceCommit, err := s.resolveCommitByName(ctx, meta.TeamID, meta.RepoName, sourceCommitName)
+	if err != nil {
+		log.Error(ctx, "failed to resolve source commit", zap.Error(err))
+		return nil, nil, domain.ErrCommitNotFound
+	}
+	if sourceCommit == currentID {
+		return nil, nil, fmt.Errorf("%w: %s is already the source of the PR", domain.ErrInvalidRequest, sourceCommitName)
+	}
+
+	commits, err := s.codeClient.ListCommits(ctx, meta.TeamID, meta.RootCommit)
+	if err != nil {
+		log.Error(ctx, "failed to list commits", zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to list commits: %w", err)
+	}
+	for _, c := range commits {
+		if slices.Contains(c.ParentCommitIDs, sourceCommit) {
+			return nil, nil, fmt.Errorf("%w: %s is not a leaf commit", domain.ErrInvalidRequest, sourceCommitName)
+		}
+	}
+	// The new source must build on the current one, so reviewers only see new work on top of it
+	descends, err := s.codeClient.IsAncestor(ctx, meta.TeamID, meta.RootCommit, currentID, sourceCommit)
+	if err != nil {
+		log.Error(ctx, "failed to check ancestry", zap.Error(err))
+		return nil, nil, fmt.Errorf("failed to check ancestry: %w", err)
+	}
+	if !descends {
+		return nil, nil, fmt.Errorf("%w: %s does not descend from the current source %s",
+			domain.ErrInvalidRequest, sourceCommitName, currentName)
+	}
+
+	// pr-allocation-service dismisses the approvals first; the gateway switches the source only once that succeeded
+	prResp, dismissed, err := s.prClient.UpdatePRSource(ctx, prID, sourceCommitName)
+	if err != nil {
+		log.Error(ctx, "failed to update PR source", zap.Error(err))
+		switch {
+		case errors.Is(err, client.ErrForbidden):
+			return nil, nil, fmt.Errorf("%w: only the author or a maintainer may update the PR", domain.ErrAccessDenied)
+		case errors.Is(err, client.ErrNotOpen):
+			return nil, nil, fmt.Errorf("%w: PR is not open", domain.ErrInvalidRequest)
+		}
+		return nil, nil, fmt.Errorf("failed to update PR source: %w", err)
+	}
+
+	meta.mu.Lock()
+	meta.SourceCommit = sourceCommit
+	meta.SourceCommitName = sourceCommitName
+	meta.mu.Unlock()
+
+	log.Info(ctx, "PR source updated",
+		zap.String("pr_name", prName),
+		zap.String("source_commit", sourceCommitName),
+		zap.Strings("dismissed_approvals", dismissed),
+	)
+
+	if dismissed == nil {
+		dismissed = []string{}
+	}
+	return &domain.PullRequest{
+		PRID:             prResp.PRID,
+		PRName:           prName,
+		Title:            prResp.PRName,
+		AuthorID:         prResp.AuthorID,
+		Status:           prResp.Status,
+		ReviewerIDs:      prResp.AssignedReviewers,
+		SourceCommitID:   sourceCommit,
+		SourceCommitName: sourceCommitName,
+		TargetCommitID:   meta.TargetCommit,
+		TargetCommitName: meta.TargetCommitName,
+		RootCommitID:     meta.RootCommit,
+		RepoName:         meta.RepoName,
+		TeamName:         meta.TeamName,
+		CreatedAt:        prResp.CreatedAt,
+	}, dismissed, nil
+}
+
 // GetPRCode gets code for a PR using names
-func (s *Service) GetPRCode(ctx context.Context, username, teamName, prName string) ([]byte, error) {
+func (s *Service) GetPRCode(ctx context.Context, username, teamName, prName string) (*domain.Checkout, error) {
 	log := logger.FromContext(ctx)
 
 	// Verify user access
@@ -705,23 +1129,114 @@ func (s *Service) GetPRCode(ctx context.Context, username, teamName, prName stri
 	}
 
 	// Get PR metadata by name
-	metaKey := fmt.Sprintf("%s:%s", teamName, prName)
+	metaKey := prMetaKey(ctx, teamName, prName)
 	meta, ok := s.prMetadata[metaKey]
 	if !ok {
 		return nil, domain.ErrPRNotFound
 	}
 
-	code, err := s.codeClient.Checkout(ctx, meta.TeamID, meta.RootCommit, meta.SourceCommit)
+	sourceID, _ := meta.source()
+	checkout, err := s.codeClient.Checkout(ctx, meta.TeamID, meta.RootCommit, sourceID, "", "")
 	if err != nil {
 		log.Error(ctx, "failed to get PR code", zap.Error(err))
 		return nil, fmt.Errorf("failed to get PR code: %w", err)
 	}
 
-	return code, nil
+	return (*domain.Checkout)(checkout), nil
+}
+
+// prMetaKey keys PR metadata by team and PR name within the caller's organization,
+// since team names repeat across organizations
+func prMetaKey(ctx context.Context, teamName, prName string) string {
+	orgName, _ := client.OrganizationFromContext(ctx)
+	return fmt.Sprintf("%s:%s@%s", teamName, prName, orgName)
+}
+
+// GetPRDiff returns what a PR changes: the line diff from the merge base of its target and source
+// commits to its source commit, with contextLines unchanged lines around each change. Diffing from
+// the merge base keeps commits that landed on the target after the PR branched off out of the diff
+func (s *Service) GetPRDiff(ctx context.Context, username, teamName, prName string, contextLines int) (*domain.PRDiff, error) {
+	log := logger.FromContext(ctx)
+
+	if err := s.verifyUserAccess(ctx, username, teamName); err != nil {
+		return nil, err
+	}
+
+	meta, ok := s.prMetadata[prMetaKey(ctx, teamName, prName)]
+	if !ok {
+		return nil, domain.ErrPRNotFound
+	}
+
+	// One snapshot of the source, so a concurrent source update can't mix two sources into the diff
+	sourceID, sourceName := meta.source()
+	mergeBase, err := s.codeClient.MergeBase(ctx, meta.TeamID, meta.RootCommit, meta.TargetCommit, sourceID)
+	if err != nil {
+		log.Error(ctx, "failed to find PR merge base", zap.Error(err))
+		return nil, fmt.Errorf("failed to find PR merge base: %w", err)
+	}
+
+	diff, err := s.codeClient.Diff(ctx, meta.TeamID, meta.RootCommit, mergeBase, sourceID, contextLines)
+	if err != nil {
+		log.Error(ctx, "failed to get PR diff", zap.Error(err))
+		if errors.Is(err, client.ErrMixedContent) {
+			return nil, domain.ErrMixedContent
+		}
+		return nil, fmt.Errorf("failed to get PR diff: %w", err)
+	}
+
+	result := &domain.PRDiff{
+		PRName:           meta.PRName,
+		SourceCommitName: sourceName,
+		TargetCommitName: meta.TargetCommitName,
+		Context:          diff.Context,
+		Binary:           diff.Binary,
+		Hunks:            make([]domain.DiffHunk, len(diff.Hunks)),
+		Unified:          diff.Unified,
+	}
+	for i, h := range diff.Hunks {
+		result.Hunks[i] = toDiffHunk(h)
+	}
+	for _, f := range diff.Files {
+		file := domain.FileDiff{Path: f.Path, Status: f.Status, Binary: f.Binary, Hunks: make([]domain.DiffHunk, len(f.Hunks))}
+		for i, h := range f.Hunks {
+			file.Hunks[i] = toDiffHunk(h)
+		}
+		result.Files = append(result.Files, file)
+	}
+	return result, nil
+}
+
+// toDiffHunk converts a code-storage-service diff hunk
+func toDiffHunk(h client.DiffHunk) domain.DiffHunk {
+	lines := make([]domain.DiffLine, len(h.Lines))
+	for j, line := range h.Lines {
+		lines[j] = domain.DiffLine(line)
+	}
+	return domain.DiffHunk{
+		OldStart: h.OldStart,
+		OldLines: h.OldLines,
+		NewStart: h.NewStart,
+		NewLines: h.NewLines,
+		Lines:    lines,
+	}
+}
+
+// backendDetail returns the message of a backend error response, or the error itself for other errors
+func backendDetail(err error) string {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) && apiErr.Detail != "" {
+		return apiErr.Detail
+	}
+	return err.Error()
 }
 
 // verifyUserAccess checks if user belongs to the team
 func (s *Service) verifyUserAccess(ctx context.Context, username, teamName string) error {
+	return s.verifyUserRole(ctx, username, teamName, domain.RoleMember)
+}
+
+// verifyUserRole checks if user belongs to the team with at least the required role
+func (s *Service) verifyUserRole(ctx context.Context, username, teamName, required string) error {
 	user, err := s.prClient.GetUser(ctx, username)
 	if err != nil {
 		return domain.ErrUserNotFound
@@ -735,6 +1250,10 @@ func (s *Service) verifyUserAccess(ctx context.Context, username, teamName strin
 		return domain.ErrAccessDenied
 	}
 
+	if !domain.RoleAtLeast(user.Role, required) {
+		return fmt.Errorf("%w: %s role required", domain.ErrAccessDenied, required)
+	}
+
 	return nil
 }
 
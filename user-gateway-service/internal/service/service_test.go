@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Meldy183/user-gateway-service/internal/client"
+	"github.com/google/uuid"
+)
+
+// commitIDOf is the ID the fake code-storage gives a commit name
+func commitIDOf(name string) uuid.UUID {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(name))
+}
+
+func TestUpdatePRSourceWhileReading(t *testing.T) {
+	pr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/get":
+			_ = json.NewEncoder(w).Encode(map[string]any{"user": map[string]any{
+				"user_id": "alice", "username": "alice", "team_name": "backend", "is_active": true, "role": "member",
+			}})
+		case "/pullRequest/updateSource":
+			_ = json.NewEncoder(w).Encode(map[string]any{"pr": map[string]any{"pull_request_id": "pr-1", "status": "OPEN"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	code := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/storage/commitID":
+			_ = json.NewEncoder(w).Encode(map[string]any{"commit_id": commitIDOf(query.Get("name"))})
+		case "/storage/commits":
+			_ = json.NewEncoder(w).Encode(map[string]any{"commits": []any{}})
+		case "/storage/isAncestor":
+			_ = json.NewEncoder(w).Encode(map[string]any{"is_ancestor": true})
+		case "/storage/mergeBase":
+			_ = json.NewEncoder(w).Encode(map[string]any{"merge_base": query.Get("commit_id1")})
+		case "/storage/diff":
+			_ = json.NewEncoder(w).Encode(map[string]any{"to_commit_id": query.Get("to_commit_id"), "hunks": []any{}})
+		case "/storage/checkout":
+			_, _ = w.Write([]byte("code"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(pr.Close)
+	t.Cleanup(code.Close)
+	svc := NewService(client.NewPRAllocationClient(pr.URL), client.NewCodeStorageClient(code.URL))
+
+	ctx := context.Background()
+	teamID, root := uuid.New(), uuid.New()
+	svc.repoRootCommits[fmt.Sprintf("%s:%s", teamID, "repo")] = root
+	meta := &PRMetadata{PRName: "feature", TeamName: "backend", RepoName: "repo", TeamID: teamID, RootCommit: root,
+		SourceCommit: commitIDOf("feature-0"), SourceCommitName: "feature-0", TargetCommit: uuid.New(), TargetCommitName: "main"}
+	svc.prMetadata[prMetaKey(ctx, "backend", "feature")] = meta
+	svc.prMetadata["pr-1"] = meta
+
+	const updates = 20
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= updates; i++ {
+			if _, _, err := svc.UpdatePRSource(ctx, "alice", "backend", "feature", fmt.Sprintf("feature-%d", i)); err != nil {
+				t.Errorf("update source to feature-%d: %v", i, err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range updates {
+			diff, err := svc.GetPRDiff(ctx, "alice", "backend", "feature", 3)
+			if err != nil {
+				t.Errorf("get PR diff: %v", err)
+				return
+			}
+			if diff.SourceCommitName == "" {
+				t.Error("PR diff without a source")
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range updates {
+			if _, err := svc.GetPRCode(ctx, "alice", "backend", "feature"); err != nil {
+				t.Errorf("get PR code: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if id, name := meta.source(); id != commitIDOf(fmt.Sprintf("feature-%d", updates)) || name != fmt.Sprintf("feature-%d", updates) {
+		t.Fatalf("source = %s %s, want the last update", id, name)
+	}
+}
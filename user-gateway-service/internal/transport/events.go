@@ -0,0 +1,76 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/user-gateway-service/internal/domain"
+	"go.uber.org/zap"
+)
+
+// StreamEvents handles GET /api/events
+// Streams PR activity of the current user as Server-Sent Events. The stream comes from
+// pr-allocation-service and is relayed event by event; Last-Event-ID is passed upstream to resume.
+func (h *Handler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	username := h.getUsername(r)
+
+	if username == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return
+	}
+
+	// The upstream stream ends with the client connection or on shutdown
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-h.closing:
+			cancel()
+		case <-streamCtx.Done():
+		}
+	}()
+
+	stream, err := h.service.SubscribeEvents(streamCtx, username, r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+	defer stream.Close()
+
+	rc := http.NewResponseController(w)
+	// The stream outlives the server's write timeout
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn(ctx, "failed to clear write deadline for event stream", zap.Error(err))
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	// Events end with a blank line; flush after each so it reaches the client immediately
+	reader := bufio.NewReader(stream)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if _, writeErr := w.Write(line); writeErr != nil {
+				return
+			}
+		}
+		if err != nil {
+			if streamCtx.Err() == nil {
+				log.Warn(ctx, "PR event stream closed by upstream", zap.Error(err))
+			}
+			return
+		}
+		if len(line) == 1 {
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -4,9 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"sync"
 
 	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/problem"
+	"github.com/Meldy183/user-gateway-service/internal/client"
 	"github.com/Meldy183/user-gateway-service/internal/domain"
 	"github.com/Meldy183/user-gateway-service/internal/service"
 	"github.com/google/uuid"
@@ -16,19 +21,34 @@ import (
 
 const maxUploadSize = 100 << 20 // 100MB
 
+// Context lines of PR diffs: the default and the most a client may ask for
+const (
+	defaultDiffContext = 3
+	maxDiffContext     = 1000
+)
+
 // Handler handles HTTP requests
 type Handler struct {
 	service *service.Service
+	// closing ends open event streams on shutdown
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 
 // NewHandler creates a new Handler instance
 func NewHandler(svc *service.Service) *Handler {
-	return &Handler{service: svc}
+	return &Handler{service: svc, closing: make(chan struct{})}
+}
+
+// CloseStreams ends open event streams so graceful shutdown does not wait on them
+func (h *Handler) CloseStreams() {
+	h.closeOnce.Do(func() { close(h.closing) })
 }
 
 // RegisterRoutes registers all routes
 func (h *Handler) RegisterRoutes(router *mux.Router, log logger.Logger) {
 	router.Use(h.LoggingMiddleware(log))
+	router.Use(h.OrganizationMiddleware, h.ActorMiddleware)
 
 	// Health
 	router.HandleFunc("/health", h.HealthCheck).Methods(http.MethodGet)
@@ -39,20 +59,32 @@ func (h *Handler) RegisterRoutes(router *mux.Router, log logger.Logger) {
 	// Teams
 	router.HandleFunc("/api/team/create", h.CreateTeam).Methods(http.MethodPost)
 	router.HandleFunc("/api/team/get", h.GetTeam).Methods(http.MethodGet)
+	router.HandleFunc("/api/team/setRole", h.SetMemberRole).Methods(http.MethodPost)
 
 	// Repository
 	router.HandleFunc("/api/repo/init", h.InitRepository).Methods(http.MethodPost)
 	router.HandleFunc("/api/repo/push", h.Push).Methods(http.MethodPost)
 	router.HandleFunc("/api/repo/checkout", h.Checkout).Methods(http.MethodGet)
 	router.HandleFunc("/api/repo/commits", h.ListCommits).Methods(http.MethodGet)
+	router.HandleFunc("/api/repo/mergeBase", h.GetMergeBase).Methods(http.MethodGet)
+	router.HandleFunc("/api/repo/isAncestor", h.GetAncestry).Methods(http.MethodGet)
+	router.HandleFunc("/api/repo/aheadBehind", h.GetAheadBehind).Methods(http.MethodGet)
 
 	// Pull Requests - now using query params instead of path params
 	router.HandleFunc("/api/pr/create", h.CreatePR).Methods(http.MethodPost)
 	router.HandleFunc("/api/pr/my", h.GetMyPRs).Methods(http.MethodGet)
 	router.HandleFunc("/api/pr/reviews", h.GetReviewPRs).Methods(http.MethodGet)
+	router.HandleFunc("/api/pr/search", h.SearchPRs).Methods(http.MethodGet)
 	router.HandleFunc("/api/pr/approve", h.ApprovePR).Methods(http.MethodPost)
 	router.HandleFunc("/api/pr/reject", h.RejectPR).Methods(http.MethodPost)
+	router.HandleFunc("/api/pr/merge", h.MergePR).Methods(http.MethodPost)
+	router.HandleFunc("/api/pr/queue", h.GetMergeQueue).Methods(http.MethodGet)
+	router.HandleFunc("/api/pr/updateSource", h.UpdatePRSource).Methods(http.MethodPost)
 	router.HandleFunc("/api/pr/code", h.GetPRCode).Methods(http.MethodGet)
+	router.HandleFunc("/api/pr/diff", h.GetPRDiff).Methods(http.MethodGet)
+
+	// Events
+	router.HandleFunc("/api/events", h.StreamEvents).Methods(http.MethodGet)
 }
 
 // LoggingMiddleware adds logging and request ID
@@ -74,6 +106,29 @@ func (h *Handler) LoggingMiddleware(log logger.Logger) func(next http.Handler) h
 	}
 }
 
+// OrganizationMiddleware carries the caller's organization (X-Organization, the default one when absent)
+// through to pr-allocation-service
+func (h *Handler) OrganizationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		orgName := r.Header.Get(client.OrganizationHeader)
+		if orgName == "" {
+			orgName = client.DefaultOrganization
+		}
+		next.ServeHTTP(w, r.WithContext(client.WithOrganization(r.Context(), orgName)))
+	})
+}
+
+// ActorMiddleware makes calls to pr-allocation-service act on behalf of the X-Username user,
+// so their team role is checked there as well
+func (h *Handler) ActorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if username := h.getUsername(r); username != "" {
+			r = r.WithContext(client.WithActor(r.Context(), username))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // getUsername extracts username from header
 func (h *Handler) getUsername(r *http.Request) string {
 	return r.Header.Get("X-Username")
@@ -91,54 +146,86 @@ func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	var req domain.CreateTeamRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.TeamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 	if len(req.Members) == 0 {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "at least one member is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "at least one member is required")
 		return
 	}
 
-	team, err := h.service.CreateTeam(ctx, &req)
+	team, err := h.service.CreateTeam(ctx, username, &req)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusCreated, map[string]interface{}{"team": team})
 }
 
+// SetMemberRole handles POST /api/team/setRole
+func (h *Handler) SetMemberRole(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := logger.FromContext(ctx)
+	username := h.getUsername(r)
+
+	if username == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return
+	}
+
+	var req domain.SetMemberRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error(ctx, "failed to decode request", zap.Error(err))
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || req.Username == "" || req.Role == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name, username and role are required")
+		return
+	}
+
+	member, err := h.service.SetMemberRole(ctx, username, &req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{"member": member})
+}
+
 // GetTeam handles GET /api/team/get
 func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name query parameter is required")
 		return
 	}
 
 	team, err := h.service.GetTeam(ctx, teamName)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -151,13 +238,13 @@ func (h *Handler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	profile, err := h.service.GetUserProfile(ctx, username)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -171,54 +258,60 @@ func (h *Handler) InitRepository(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
 		log.Error(ctx, "failed to parse multipart form", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "failed to parse form data")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "failed to parse form data")
 		return
 	}
 
 	// Get team_name from form
 	teamName := r.FormValue("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	// Get repo_name from form
 	repoName := r.FormValue("repo_name")
 	if repoName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
 		return
 	}
 
 	// Get commit_name from form
 	commitName := r.FormValue("commit_name")
 	if commitName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "commit_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "commit_name is required")
+		return
+	}
+
+	format, ok := uploadFormat(r.FormValue("format"))
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "format must be file, tar or zip")
 		return
 	}
 
 	// Get code file
 	file, _, err := r.FormFile("code")
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "code file is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "code file is required")
 		return
 	}
 	defer file.Close()
 
 	code, err := io.ReadAll(file)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, domain.ErrCodeInternalError, "failed to read code file")
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternalError, "failed to read code file")
 		return
 	}
 
-	commit, err := h.service.InitRepository(ctx, username, teamName, repoName, commitName, code)
+	commit, err := h.service.InitRepository(ctx, username, teamName, repoName, commitName, code, format)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -232,56 +325,62 @@ func (h *Handler) Push(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	if err := r.ParseMultipartForm(maxUploadSize); err != nil {
 		log.Error(ctx, "failed to parse multipart form", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "failed to parse form data")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "failed to parse form data")
 		return
 	}
 
 	teamName := r.FormValue("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	repoName := r.FormValue("repo_name")
 	if repoName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
 		return
 	}
 
 	parentCommitName := r.FormValue("parent_commit_name")
 	if parentCommitName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "parent_commit_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "parent_commit_name is required")
 		return
 	}
 
 	commitName := r.FormValue("commit_name")
 	if commitName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "commit_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "commit_name is required")
+		return
+	}
+
+	format, ok := uploadFormat(r.FormValue("format"))
+	if !ok {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "format must be file, tar or zip")
 		return
 	}
 
 	file, _, err := r.FormFile("code")
 	if err != nil {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "code file is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "code file is required")
 		return
 	}
 	defer file.Close()
 
 	code, err := io.ReadAll(file)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, domain.ErrCodeInternalError, "failed to read code file")
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternalError, "failed to read code file")
 		return
 	}
 
-	commit, err := h.service.Push(ctx, username, teamName, repoName, parentCommitName, commitName, code)
+	commit, err := h.service.Push(ctx, username, teamName, repoName, parentCommitName, commitName, code, format)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -294,38 +393,52 @@ func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	repoName := r.URL.Query().Get("repo_name")
 	if repoName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
 		return
 	}
 
 	commitName := r.URL.Query().Get("commit_name")
 	if commitName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "commit_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "commit_name is required")
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "tar" && format != "zip" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "format must be tar or zip")
 		return
 	}
 
-	code, err := h.service.Checkout(ctx, username, teamName, repoName, commitName)
+	checkout, err := h.service.Checkout(ctx, username, teamName, repoName, commitName, r.URL.Query().Get("path"), format)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/zip")
-	w.Header().Set("Content-Disposition", "attachment; filename=code.zip")
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(code)
+	h.respondCheckout(w, checkout)
+}
+
+// uploadFormat maps the format form field to what code-storage-service takes: "" for single-file code
+func uploadFormat(field string) (string, bool) {
+	switch field {
+	case "", "file":
+		return "", true
+	case "tar", "zip":
+		return field, true
+	}
+	return "", false
 }
 
 // ListCommits handles GET /api/repo/commits
@@ -334,31 +447,124 @@ func (h *Handler) ListCommits(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	repoName := r.URL.Query().Get("repo_name")
 	if repoName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
 		return
 	}
 
 	commits, err := h.service.ListCommits(ctx, username, teamName, repoName)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusOK, map[string]interface{}{"commits": commits})
 }
 
+// GetPRDiff handles GET /api/pr/diff
+func (h *Handler) GetPRDiff(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.requireQuery(w, r, "team_name", "pr_name")
+	if !ok {
+		return
+	}
+
+	contextLines := defaultDiffContext
+	if raw := r.URL.Query().Get("context"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 || n > maxDiffContext {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "context must be between 0 and 1000")
+			return
+		}
+		contextLines = n
+	}
+
+	diff, err := h.service.GetPRDiff(r.Context(), h.getUsername(r), params[0], params[1], contextLines)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, diff)
+}
+
+// GetMergeBase handles GET /api/repo/mergeBase
+func (h *Handler) GetMergeBase(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.requireQuery(w, r, "team_name", "repo_name", "commit_name1", "commit_name2")
+	if !ok {
+		return
+	}
+
+	mergeBase, err := h.service.GetMergeBase(r.Context(), h.getUsername(r), params[0], params[1], params[2], params[3])
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, mergeBase)
+}
+
+// GetAncestry handles GET /api/repo/isAncestor
+func (h *Handler) GetAncestry(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.requireQuery(w, r, "team_name", "repo_name", "ancestor_name", "commit_name")
+	if !ok {
+		return
+	}
+
+	ancestry, err := h.service.GetAncestry(r.Context(), h.getUsername(r), params[0], params[1], params[2], params[3])
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, ancestry)
+}
+
+// GetAheadBehind handles GET /api/repo/aheadBehind
+func (h *Handler) GetAheadBehind(w http.ResponseWriter, r *http.Request) {
+	params, ok := h.requireQuery(w, r, "team_name", "repo_name", "commit_name1", "commit_name2")
+	if !ok {
+		return
+	}
+
+	counts, err := h.service.GetAheadBehind(r.Context(), h.getUsername(r), params[0], params[1], params[2], params[3])
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, counts)
+}
+
+// requireQuery checks the X-Username header and returns the named query parameters in order,
+// responding 400 if any of them is missing
+func (h *Handler) requireQuery(w http.ResponseWriter, r *http.Request, names ...string) ([]string, bool) {
+	if h.getUsername(r) == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return nil, false
+	}
+
+	params := make([]string, len(names))
+	for i, name := range names {
+		params[i] = r.URL.Query().Get(name)
+		if params[i] == "" {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, name+" is required")
+			return nil, false
+		}
+	}
+	return params, true
+}
+
 // CreatePR handles POST /api/pr/create
 func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -366,45 +572,45 @@ func (h *Handler) CreatePR(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	var req domain.CreatePRRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		log.Error(ctx, "failed to decode request", zap.Error(err))
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "invalid request body")
 		return
 	}
 
 	if req.Title == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "title is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "title is required")
 		return
 	}
 	if req.PRName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
 		return
 	}
 	if req.TeamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 	if req.RepoName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
 		return
 	}
 	if req.SourceCommitName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "source_commit_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "source_commit_name is required")
 		return
 	}
 	if req.TargetCommitName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "target_commit_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "target_commit_name is required")
 		return
 	}
 
 	pr, err := h.service.CreatePR(ctx, username, &req)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -417,7 +623,7 @@ func (h *Handler) GetMyPRs(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
@@ -425,7 +631,7 @@ func (h *Handler) GetMyPRs(w http.ResponseWriter, r *http.Request) {
 
 	prs, err := h.service.GetMyPRs(ctx, username, status)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -438,7 +644,7 @@ func (h *Handler) GetReviewPRs(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
@@ -446,38 +652,79 @@ func (h *Handler) GetReviewPRs(w http.ResponseWriter, r *http.Request) {
 
 	prs, err := h.service.GetReviewPRs(ctx, username, status)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusOK, map[string]interface{}{"pull_requests": prs})
 }
 
+// SearchPRs handles GET /api/pr/search
+func (h *Handler) SearchPRs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := h.getUsername(r)
+
+	if username == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return
+	}
+
+	query := r.URL.Query()
+	req := domain.SearchPRsRequest{
+		Query:    query.Get("q"),
+		TeamName: query.Get("team_name"),
+		Status:   query.Get("status"),
+		Author:   query.Get("author"),
+		Reviewer: query.Get("reviewer"),
+	}
+	var err error
+	if raw := query.Get("limit"); raw != "" {
+		if req.Limit, err = strconv.Atoi(raw); err != nil {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "limit must be an integer")
+			return
+		}
+	}
+	if raw := query.Get("offset"); raw != "" {
+		if req.Offset, err = strconv.Atoi(raw); err != nil {
+			h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "offset must be an integer")
+			return
+		}
+	}
+
+	result, err := h.service.SearchPRs(ctx, username, &req)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
 // ApprovePR handles POST /api/pr/approve
 func (h *Handler) ApprovePR(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	prName := r.URL.Query().Get("pr_name")
 	if prName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
 		return
 	}
 
 	pr, mergeCommit, err := h.service.ApprovePR(ctx, username, teamName, prName)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
@@ -487,6 +734,71 @@ func (h *Handler) ApprovePR(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// MergePR handles POST /api/pr/merge
+func (h *Handler) MergePR(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := h.getUsername(r)
+
+	if username == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return
+	}
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+
+	prName := r.URL.Query().Get("pr_name")
+	if prName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
+		return
+	}
+
+	pr, mergeCommit, err := h.service.MergePR(ctx, username, teamName, prName)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pull_request": pr,
+		"merge_commit": mergeCommit,
+	})
+}
+
+// GetMergeQueue handles GET /api/pr/queue
+func (h *Handler) GetMergeQueue(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := h.getUsername(r)
+
+	if username == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return
+	}
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+
+	repoName := r.URL.Query().Get("repo_name")
+	if repoName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "repo_name is required")
+		return
+	}
+
+	queue, err := h.service.GetMergeQueue(ctx, username, teamName, repoName)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{"merge_queue": queue})
+}
+
 // RejectPR handles POST /api/pr/reject
 func (h *Handler) RejectPR(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -494,19 +806,19 @@ func (h *Handler) RejectPR(w http.ResponseWriter, r *http.Request) {
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	prName := r.URL.Query().Get("pr_name")
 	if prName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
 		return
 	}
 
@@ -517,45 +829,94 @@ func (h *Handler) RejectPR(w http.ResponseWriter, r *http.Request) {
 
 	pr, err := h.service.RejectPR(ctx, username, teamName, prName, req.Reason)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
 	h.respondJSON(w, http.StatusOK, map[string]interface{}{"pull_request": pr})
 }
 
+// UpdatePRSource handles POST /api/pr/updateSource
+func (h *Handler) UpdatePRSource(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := h.getUsername(r)
+
+	if username == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		return
+	}
+
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		return
+	}
+
+	prName := r.URL.Query().Get("pr_name")
+	if prName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
+		return
+	}
+
+	var req domain.UpdatePRSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.SourceCommitName == "" {
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "source_commit_name is required")
+		return
+	}
+
+	pr, dismissed, err := h.service.UpdatePRSource(ctx, username, teamName, prName, req.SourceCommitName)
+	if err != nil {
+		h.handleServiceError(w, r, err)
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]interface{}{
+		"pull_request":        pr,
+		"dismissed_approvals": dismissed,
+	})
+}
+
 // GetPRCode handles GET /api/pr/code
 func (h *Handler) GetPRCode(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	username := h.getUsername(r)
 
 	if username == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "X-Username header is required")
 		return
 	}
 
 	teamName := r.URL.Query().Get("team_name")
 	if teamName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "team_name is required")
 		return
 	}
 
 	prName := r.URL.Query().Get("pr_name")
 	if prName == "" {
-		h.respondError(w, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
+		h.respondError(w, r, http.StatusBadRequest, domain.ErrCodeInvalidRequest, "pr_name is required")
 		return
 	}
 
-	code, err := h.service.GetPRCode(ctx, username, teamName, prName)
+	checkout, err := h.service.GetPRCode(ctx, username, teamName, prName)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.handleServiceError(w, r, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/zip")
-	w.Header().Set("Content-Disposition", "attachment; filename=code.zip")
+	h.respondCheckout(w, checkout)
+}
+
+// respondCheckout sends checked-out code as an attachment, typed as code-storage-service served it
+func (h *Handler) respondCheckout(w http.ResponseWriter, checkout *domain.Checkout) {
+	contentType := checkout.ContentType
+	if contentType == "" {
+		contentType = "application/zip"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": checkout.Filename}))
 	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(code)
+	_, _ = w.Write(checkout.Content)
 }
 
 // respondJSON sends a JSON response
@@ -567,37 +928,56 @@ func (h *Handler) respondJSON(w http.ResponseWriter, status int, data interface{
 	}
 }
 
-// respondError sends an error response
-func (h *Handler) respondError(w http.ResponseWriter, status int, code, message string) {
-	h.respondJSON(w, status, domain.NewErrorResponse(code, message))
+// respondError sends an application/problem+json error, or the legacy ErrorResponse
+// to clients that accept only application/json
+func (h *Handler) respondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	_ = problem.Write(w, r, status, code, message, domain.NewErrorResponse(code, message))
 }
 
 // handleServiceError maps service errors to HTTP responses
-func (h *Handler) handleServiceError(w http.ResponseWriter, err error) {
+func (h *Handler) handleServiceError(w http.ResponseWriter, r *http.Request, err error) {
 	code := domain.MapErrorToCode(err)
 
 	switch {
 	case errors.Is(err, domain.ErrAccessDenied):
-		h.respondError(w, http.StatusForbidden, code, err.Error())
+		h.respondError(w, r, http.StatusForbidden, code, err.Error())
 	case errors.Is(err, domain.ErrUserNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrUserInactive):
-		h.respondError(w, http.StatusForbidden, code, err.Error())
+		h.respondError(w, r, http.StatusForbidden, code, err.Error())
 	case errors.Is(err, domain.ErrTeamNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrTeamExists):
-		h.respondError(w, http.StatusBadRequest, code, err.Error())
+		h.respondError(w, r, http.StatusBadRequest, code, err.Error())
 	case errors.Is(err, domain.ErrCommitNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrPRNotFound):
-		h.respondError(w, http.StatusNotFound, code, err.Error())
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
 	case errors.Is(err, domain.ErrPRAlreadyExists):
-		h.respondError(w, http.StatusConflict, code, err.Error())
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
 	case errors.Is(err, domain.ErrPRAlreadyMerged):
-		h.respondError(w, http.StatusConflict, code, err.Error())
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
 	case errors.Is(err, domain.ErrNotReviewer):
-		h.respondError(w, http.StatusForbidden, code, err.Error())
+		h.respondError(w, r, http.StatusForbidden, code, err.Error())
+	case errors.Is(err, domain.ErrNotAllApproved):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrDepsNotMerged):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrPRAlreadyQueued):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrMergeEjected):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrMergeConflict):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrFileNotFound):
+		h.respondError(w, r, http.StatusNotFound, code, err.Error())
+	case errors.Is(err, domain.ErrInvalidArchive):
+		h.respondError(w, r, http.StatusBadRequest, code, err.Error())
+	case errors.Is(err, domain.ErrMixedContent):
+		h.respondError(w, r, http.StatusConflict, code, err.Error())
+	case errors.Is(err, domain.ErrInvalidRequest):
+		h.respondError(w, r, http.StatusBadRequest, code, err.Error())
 	default:
-		h.respondError(w, http.StatusInternalServerError, domain.ErrCodeInternalError, "internal server error")
+		h.respondError(w, r, http.StatusInternalServerError, domain.ErrCodeInternalError, "internal server error")
 	}
 }
@@ -0,0 +1,26 @@
+package transport_test
+
+import (
+	"testing"
+
+	"github.com/Meldy183/shared/pkg/health"
+	"github.com/Meldy183/shared/pkg/logger"
+	"github.com/Meldy183/shared/pkg/openapi"
+	"github.com/Meldy183/user-gateway-service/api"
+	"github.com/Meldy183/user-gateway-service/internal/transport"
+	"github.com/gorilla/mux"
+)
+
+// TestRoutesMatchSpec fails when a route is registered without being documented in the spec, or the other way round.
+func TestRoutesMatchSpec(t *testing.T) {
+	validator, err := openapi.NewValidator(api.Spec, nil, false)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	router := mux.NewRouter()
+	transport.NewHandler(nil).RegisterRoutes(router, logger.NewLogger("test"))
+	health.Register(router)
+	if err := validator.CheckRoutes(router); err != nil {
+		t.Fatal(err)
+	}
+}